@@ -0,0 +1,7 @@
+package dtos
+
+type CreateKey struct {
+	Name      string `json:"name"`
+	Scope     string `json:"scope"`
+	ExpiresAt string `json:"expires_at"`
+}
@@ -0,0 +1,105 @@
+package apikey
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"todo-app/apikey/models"
+)
+
+type MemoryRepository struct {
+	mu   sync.RWMutex
+	keys map[string]models.Key
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		keys: make(map[string]models.Key),
+	}
+}
+
+func (m *MemoryRepository) Create(ctx context.Context, key models.Key) (models.Key, error) {
+	if ctx.Err() != nil {
+		return models.Key{}, ErrWhileCreating
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key.ID = uuid.NewString()
+	m.keys[key.ID] = cloneKey(key)
+	return key, nil
+}
+
+func (m *MemoryRepository) GetAll(ctx context.Context, owner string) ([]models.Key, error) {
+	if ctx.Err() != nil {
+		return nil, ErrWhileRetrieving
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := make([]models.Key, 0)
+	for _, key := range m.keys {
+		if key.Owner == owner {
+			keys = append(keys, cloneKey(key))
+		}
+	}
+
+	sortKeys(keys)
+	return keys, nil
+}
+
+func (m *MemoryRepository) GetByID(ctx context.Context, id string) (models.Key, error) {
+	if err := validateID(id); err != nil {
+		return models.Key{}, err
+	}
+
+	if ctx.Err() != nil {
+		return models.Key{}, ErrWhileRetrieving
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	key, ok := m.keys[id]
+	if !ok {
+		return models.Key{}, ErrKeyNotFound
+	}
+
+	return cloneKey(key), nil
+}
+
+func (m *MemoryRepository) Delete(ctx context.Context, owner string, id string) error {
+	if err := validateID(id); err != nil {
+		return err
+	}
+
+	if ctx.Err() != nil {
+		return ErrWhileDeleting
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key, ok := m.keys[id]
+	if !ok || key.Owner != owner {
+		return ErrKeyNotFound
+	}
+
+	delete(m.keys, id)
+	return nil
+}
+
+// cloneKey copies the expiration date, so the stored keys can't be changed
+// through the ones returned.
+func cloneKey(key models.Key) models.Key {
+	if key.ExpiresAt != nil {
+		expiresAt := *key.ExpiresAt
+		key.ExpiresAt = &expiresAt
+	}
+
+	return key
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+type Scope string
+
+const (
+	ScopeReadOnly  Scope = "read-only"
+	ScopeReadWrite Scope = "read-write"
+)
+
+// Key is an API key of an owner. Only the SHA-256 Hash of its secret is
+// stored, the secret itself is handed out once when the key is created.
+type Key struct {
+	ID        string     `json:"id"`
+	Owner     string     `json:"owner"`
+	Name      string     `json:"name"`
+	Scope     Scope      `json:"scope"`
+	Hash      string     `json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// IssuedKey is a key just created along with the value to send in the
+// X-API-Key header.
+type IssuedKey struct {
+	Key
+	Secret string `json:"key"`
+}
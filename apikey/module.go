@@ -0,0 +1,47 @@
+package apikey
+
+import (
+	"database/sql"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/fx"
+
+	"todo-app/internal/storage"
+	"todo-app/todo"
+)
+
+var Module = fx.Module(
+	"apikey-module",
+	fx.Provide(
+		fx.Private,
+		NewRepository,
+		fx.Annotate(
+			todo.NewSystemClock,
+			fx.As(new(todo.Clock)),
+		),
+	),
+	fx.Provide(
+		fx.Annotate(
+			NewKeysService,
+			fx.As(new(Service)),
+		),
+	),
+	fx.Invoke(MigrateRepository),
+)
+
+// NewRepository stores the keys along with the todos, in the configured
+// storage.
+func NewRepository(clients storage.Clients) (Repository, error) {
+	return storage.NewRepository(clients, storage.Repositories[Repository]{
+		Redis:    func(client redis.UniversalClient) Repository { return NewRedisRepository(client) },
+		SQLite:   func(db *sql.DB) Repository { return NewSQLiteRepository(db) },
+		Postgres: func(pool *pgxpool.Pool) Repository { return NewPostgresRepository(pool) },
+		Memory:   func() Repository { return NewMemoryRepository() },
+	})
+}
+
+// MigrateRepository migrates the repository when the app starts.
+func MigrateRepository(repository Repository, lc fx.Lifecycle) {
+	storage.MigrateOnStart(repository, lc)
+}
@@ -0,0 +1,96 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"todo-app/apikey/models"
+	"todo-app/internal/storage"
+)
+
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{
+		pool: pool,
+	}
+}
+
+func (p *PostgresRepository) Create(ctx context.Context, key models.Key) (models.Key, error) {
+	key.ID = uuid.NewString()
+	_, err := p.pool.Exec(
+		ctx,
+		"INSERT INTO api_keys ("+keyColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		key.ID, key.Owner, key.Name, key.Scope, key.Hash, key.CreatedAt.UTC(), storage.NullTime(key.ExpiresAt),
+	)
+	if err != nil {
+		return models.Key{}, ErrWhileCreating
+	}
+
+	return key, nil
+}
+
+// GetAll returns the keys of the owner from the oldest to the newest.
+func (p *PostgresRepository) GetAll(ctx context.Context, owner string) ([]models.Key, error) {
+	rows, err := p.pool.Query(ctx, "SELECT "+keyColumns+" FROM api_keys WHERE owner = $1 ORDER BY created_at, id", owner)
+	if err != nil {
+		return nil, ErrWhileRetrieving
+	}
+	defer rows.Close()
+
+	keys := make([]models.Key, 0)
+	for rows.Next() {
+		key, err := scanKey(rows)
+		if err != nil {
+			return nil, ErrWhileRetrieving
+		}
+
+		keys = append(keys, key)
+	}
+
+	if rows.Err() != nil {
+		return nil, ErrWhileRetrieving
+	}
+
+	return keys, nil
+}
+
+func (p *PostgresRepository) GetByID(ctx context.Context, id string) (models.Key, error) {
+	if err := validateID(id); err != nil {
+		return models.Key{}, err
+	}
+
+	key, err := scanKey(p.pool.QueryRow(ctx, "SELECT "+keyColumns+" FROM api_keys WHERE id = $1", id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Key{}, ErrKeyNotFound
+	}
+
+	if err != nil {
+		return models.Key{}, ErrWhileRetrieving
+	}
+
+	return key, nil
+}
+
+func (p *PostgresRepository) Delete(ctx context.Context, owner string, id string) error {
+	if err := validateID(id); err != nil {
+		return err
+	}
+
+	tag, err := p.pool.Exec(ctx, "DELETE FROM api_keys WHERE owner = $1 AND id = $2", owner, id)
+	if err != nil {
+		return ErrWhileDeleting
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrKeyNotFound
+	}
+
+	return nil
+}
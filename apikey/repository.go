@@ -0,0 +1,306 @@
+package apikey
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"todo-app/apikey/models"
+	"todo-app/internal/storage"
+	"todo-app/todo"
+)
+
+// redisKey holds every key by id and redisOwnerKey the ids of the keys of an
+// owner. They share the same hash tag, so they map to the same cluster slot
+// and can be used together in transactions. redisMigratedKey holds the
+// version of the last migration.
+const (
+	redisKey         = "apikey-{keys}"
+	redisOwnerKey    = "apikey-{keys}:%s"
+	redisMigratedKey = "apikey-migrated"
+)
+
+// redisMaxRetries bounds the attempts of a transaction aborted by concurrent
+// writes. redisMigrationVersion is increased whenever Migrate has new keys
+// to upgrade.
+const (
+	redisMaxRetries       = 5
+	redisMigrationVersion = 1
+)
+
+var (
+	ErrWhileCreating   = fmt.Errorf("error while creating")
+	ErrWhileRetrieving = fmt.Errorf("error while retreving")
+	ErrWhileDeleting   = fmt.Errorf("error while deleting")
+	ErrWhileMigrating  = fmt.Errorf("error while migrating")
+	ErrInvalidID       = fmt.Errorf("invalid id")
+	ErrKeyNotFound     = fmt.Errorf("api key not found")
+)
+
+// Repository stores the API keys of every owner. GetByID finds a key by id
+// alone, so it can be looked up from the value sent by a client, while Delete
+// only deletes the keys of the owner given.
+//
+//go:generate mockgen -destination mocks/repository_mock.go -package mocks . Repository
+type Repository interface {
+	Create(ctx context.Context, key models.Key) (models.Key, error)
+	GetAll(ctx context.Context, owner string) ([]models.Key, error)
+	GetByID(ctx context.Context, id string) (models.Key, error)
+	Delete(ctx context.Context, owner string, id string) error
+}
+
+// redisRecord is a key as it's stored, the hash is left out of the JSON of
+// models.Key.
+type redisRecord struct {
+	models.Key
+	Hash string `json:"hash"`
+}
+
+type RedisRepository struct {
+	client redis.UniversalClient
+}
+
+func NewRedisRepository(client redis.UniversalClient) *RedisRepository {
+	return &RedisRepository{
+		client: client,
+	}
+}
+
+func (r *RedisRepository) Create(ctx context.Context, key models.Key) (models.Key, error) {
+	key.ID = uuid.NewString()
+	keyBytes, err := json.Marshal(redisRecord{Key: key, Hash: key.Hash})
+	if err != nil {
+		return models.Key{}, ErrWhileCreating
+	}
+
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, redisKey, key.ID, keyBytes)
+		pipe.SAdd(ctx, fmt.Sprintf(redisOwnerKey, key.Owner), key.ID)
+		return nil
+	})
+	if err != nil {
+		return models.Key{}, ErrWhileCreating
+	}
+
+	return key, nil
+}
+
+// GetAll returns the keys of the owner from the oldest to the newest.
+func (r *RedisRepository) GetAll(ctx context.Context, owner string) ([]models.Key, error) {
+	ids, err := r.client.SMembers(ctx, fmt.Sprintf(redisOwnerKey, owner)).Result()
+	if err != nil {
+		return nil, ErrWhileRetrieving
+	}
+
+	keys := make([]models.Key, 0, len(ids))
+	if len(ids) == 0 {
+		return keys, nil
+	}
+
+	values, err := r.client.HMGet(ctx, redisKey, ids...).Result()
+	if err != nil {
+		return nil, ErrWhileRetrieving
+	}
+
+	for _, value := range values {
+		keyString, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		key, err := decode(keyString)
+		if err != nil {
+			return nil, ErrWhileRetrieving
+		}
+
+		keys = append(keys, key)
+	}
+
+	sortKeys(keys)
+	return keys, nil
+}
+
+func (r *RedisRepository) GetByID(ctx context.Context, id string) (models.Key, error) {
+	if err := validateID(id); err != nil {
+		return models.Key{}, err
+	}
+
+	result, err := r.client.HGet(ctx, redisKey, id).Result()
+	if errors.Is(err, redis.Nil) {
+		return models.Key{}, ErrKeyNotFound
+	}
+
+	if err != nil {
+		return models.Key{}, ErrWhileRetrieving
+	}
+
+	key, err := decode(result)
+	if err != nil {
+		return models.Key{}, ErrWhileRetrieving
+	}
+
+	return key, nil
+}
+
+func (r *RedisRepository) Delete(ctx context.Context, owner string, id string) error {
+	if err := validateID(id); err != nil {
+		return err
+	}
+
+	ownerKey := fmt.Sprintf(redisOwnerKey, owner)
+	fn := func(tx *redis.Tx) error {
+		owned, err := tx.SIsMember(ctx, ownerKey, id).Result()
+		if err != nil {
+			return err
+		}
+
+		if !owned {
+			return ErrKeyNotFound
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.SRem(ctx, ownerKey, id)
+			pipe.HDel(ctx, redisKey, id)
+			return nil
+		})
+		return err
+	}
+
+	var err error = redis.TxFailedErr
+	for i := 0; i < redisMaxRetries && errors.Is(err, redis.TxFailedErr); i++ {
+		err = r.client.Watch(ctx, fn, ownerKey)
+	}
+
+	if errors.Is(err, ErrKeyNotFound) {
+		return err
+	}
+
+	if err != nil {
+		return ErrWhileDeleting
+	}
+
+	return nil
+}
+
+// Migrate moves the keys of the owners written before they were normalized
+// to the normalized ones. It only runs once, the version is recorded when
+// it's done.
+func (r *RedisRepository) Migrate(ctx context.Context) error {
+	version, err := r.client.Get(ctx, redisMigratedKey).Int()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("%w: %w", ErrWhileMigrating, err)
+	}
+
+	if version >= redisMigrationVersion {
+		return nil
+	}
+
+	ownerKeys, err := storage.ScanRedisKeys(ctx, r.client, fmt.Sprintf(redisOwnerKey, "*"), "set")
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrWhileMigrating, err)
+	}
+
+	for _, ownerKey := range ownerKeys {
+		owner := strings.TrimPrefix(ownerKey, fmt.Sprintf(redisOwnerKey, ""))
+		email, err := todo.NewOwner(owner)
+		if err != nil || email.String() == owner {
+			continue
+		}
+
+		if err = r.moveOwner(ctx, owner, email.String()); err != nil {
+			return fmt.Errorf("%w: moving the keys of %s: %w", ErrWhileMigrating, owner, err)
+		}
+	}
+
+	if err = r.client.Set(ctx, redisMigratedKey, redisMigrationVersion, 0).Err(); err != nil {
+		return fmt.Errorf("%w: %w", ErrWhileMigrating, err)
+	}
+
+	return nil
+}
+
+// moveOwner moves the keys of an owner to another one, rewriting the owner
+// of the ones that can be decoded. The ids left without a key are dropped.
+func (r *RedisRepository) moveOwner(ctx context.Context, from string, to string) error {
+	fromKey, toKey := fmt.Sprintf(redisOwnerKey, from), fmt.Sprintf(redisOwnerKey, to)
+	fn := func(tx *redis.Tx) error {
+		ids, err := tx.SMembers(ctx, fromKey).Result()
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		values, err := tx.HMGet(ctx, redisKey, ids...).Result()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for i, value := range values {
+				keyString, ok := value.(string)
+				if !ok {
+					continue
+				}
+
+				pipe.SAdd(ctx, toKey, ids[i])
+
+				var record redisRecord
+				if json.Unmarshal([]byte(keyString), &record) != nil {
+					continue
+				}
+
+				record.Owner = to
+				keyBytes, err := json.Marshal(record)
+				if err != nil {
+					return err
+				}
+
+				pipe.HSet(ctx, redisKey, ids[i], keyBytes)
+			}
+			pipe.Del(ctx, fromKey)
+			return nil
+		})
+		return err
+	}
+
+	var err error = redis.TxFailedErr
+	for i := 0; i < redisMaxRetries && errors.Is(err, redis.TxFailedErr); i++ {
+		err = r.client.Watch(ctx, fn, fromKey, redisKey)
+	}
+
+	return err
+}
+
+func decode(value string) (models.Key, error) {
+	var record redisRecord
+	if err := json.Unmarshal([]byte(value), &record); err != nil {
+		return models.Key{}, err
+	}
+
+	key := record.Key
+	key.Hash = record.Hash
+	return key, nil
+}
+
+func sortKeys(keys []models.Key) {
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		}
+
+		return keys[i].ID < keys[j].ID
+	})
+}
+
+func validateID(id string) error {
+	if err := uuid.Validate(id); err != nil {
+		return ErrInvalidID
+	}
+
+	return nil
+}
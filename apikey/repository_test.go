@@ -0,0 +1,137 @@
+package apikey
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"todo-app/apikey/models"
+	"todo-app/internal/storage/storagetest"
+)
+
+func TestNewRedisRepository(t *testing.T) {
+	t.Run("should return a not nil instance", func(t *testing.T) {
+		repository := NewRedisRepository(redis.NewClient(&redis.Options{}))
+		assert.NotNil(t, repository)
+		assert.IsType(t, &RedisRepository{}, repository)
+	})
+}
+
+func TestRedisRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository {
+		return NewRedisRepository(storagetest.RedisClient(t))
+	})
+}
+
+func TestRedisRepository_Migrate(t *testing.T) {
+	t.Run("should move the keys of the owners written before they were normalized", func(t *testing.T) {
+		ctx := context.TODO()
+		client := storagetest.RedisClient(t)
+		id := "279f4a4e-48dc-4569-83df-8b30ce488599"
+		key, err := json.Marshal(redisRecord{Key: models.Key{ID: id, Owner: "Bob@Example.com", Name: "ci"}, Hash: "hash"})
+		assert.NoError(t, err)
+		assert.NoError(t, client.HSet(ctx, redisKey, id, string(key)).Err())
+		assert.NoError(t, client.SAdd(ctx, fmt.Sprintf(redisOwnerKey, "Bob@Example.com"), id).Err())
+
+		repository := NewRedisRepository(client)
+		assert.NoError(t, repository.Migrate(ctx))
+		assert.NoError(t, repository.Migrate(ctx))
+
+		keys, err := repository.GetAll(ctx, "bob@example.com")
+		assert.NoError(t, err)
+		if assert.Len(t, keys, 1) {
+			assert.Equal(t, "bob@example.com", keys[0].Owner)
+			assert.Equal(t, "hash", keys[0].Hash)
+		}
+
+		exists, err := client.Exists(ctx, fmt.Sprintf(redisOwnerKey, "Bob@Example.com")).Result()
+		assert.NoError(t, err)
+		assert.Zero(t, exists)
+	})
+}
+
+// testRepository checks the behaviour every Repository must share.
+func testRepository(t *testing.T, newRepository func(t *testing.T) Repository) {
+	ctx := context.TODO()
+	expiresAt := time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)
+	newKey := func(owner string, createdAt time.Time) models.Key {
+		return models.Key{
+			Owner:     owner,
+			Name:      "ci",
+			Scope:     models.ScopeReadOnly,
+			Hash:      "hash",
+			CreatedAt: createdAt,
+			ExpiresAt: &expiresAt,
+		}
+	}
+
+	t.Run("should create and get a key by id with its hash", func(t *testing.T) {
+		repository := newRepository(t)
+		created, err := repository.Create(ctx, newKey("test@test.test", time.Now().UTC()))
+		assert.NoError(t, err)
+		assert.NotEmpty(t, created.ID)
+
+		stored, err := repository.GetByID(ctx, created.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, created.ID, stored.ID)
+		assert.Equal(t, "test@test.test", stored.Owner)
+		assert.Equal(t, "hash", stored.Hash)
+		assert.True(t, expiresAt.Equal(*stored.ExpiresAt))
+	})
+
+	t.Run("should return an error for a missing or invalid id", func(t *testing.T) {
+		repository := newRepository(t)
+
+		_, err := repository.GetByID(ctx, "279f4a4e-48dc-4569-83df-8b30ce488599")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+
+		_, err = repository.GetByID(ctx, "invalid")
+		assert.ErrorIs(t, err, ErrInvalidID)
+	})
+
+	t.Run("should list the keys of the owner from the oldest", func(t *testing.T) {
+		repository := newRepository(t)
+		createdAt := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+		newer, _ := repository.Create(ctx, newKey("test@test.test", createdAt.Add(time.Hour)))
+		older, _ := repository.Create(ctx, newKey("test@test.test", createdAt))
+		_, _ = repository.Create(ctx, newKey("other@test.test", createdAt))
+
+		keys, err := repository.GetAll(ctx, "test@test.test")
+		assert.NoError(t, err)
+		assert.Len(t, keys, 2)
+		assert.Equal(t, older.ID, keys[0].ID)
+		assert.Equal(t, newer.ID, keys[1].ID)
+
+		keys, err = repository.GetAll(ctx, "nobody@test.test")
+		assert.NoError(t, err)
+		assert.Empty(t, keys)
+	})
+
+	t.Run("should only delete the keys of the owner", func(t *testing.T) {
+		repository := newRepository(t)
+		created, _ := repository.Create(ctx, newKey("test@test.test", time.Now().UTC()))
+
+		err := repository.Delete(ctx, "other@test.test", created.ID)
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+
+		err = repository.Delete(ctx, "test@test.test", created.ID)
+		assert.NoError(t, err)
+
+		_, err = repository.GetByID(ctx, created.ID)
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+
+		keys, _ := repository.GetAll(ctx, "test@test.test")
+		assert.Empty(t, keys)
+
+		err = repository.Delete(ctx, "test@test.test", created.ID)
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+
+		err = repository.Delete(ctx, "test@test.test", "invalid")
+		assert.ErrorIs(t, err, ErrInvalidID)
+	})
+}
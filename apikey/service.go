@@ -0,0 +1,146 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"todo-app/apikey/dtos"
+	"todo-app/apikey/models"
+	"todo-app/todo"
+)
+
+// secretSize is the number of random bytes of the secret of a key.
+const secretSize = 32
+
+var (
+	ErrInvalidName      = fmt.Errorf("the name of the key is required")
+	ErrInvalidScope     = fmt.Errorf("the scope must be %s or %s", models.ScopeReadOnly, models.ScopeReadWrite)
+	ErrInvalidExpiresAt = fmt.Errorf("expires at can not be parsed")
+	ErrExpiresAtInPast  = fmt.Errorf("the key must expire in the future")
+	ErrInvalidKey       = fmt.Errorf("invalid api key")
+	ErrKeyIsExpired     = fmt.Errorf("the api key is expired")
+)
+
+// Service manages the API keys of every owner. The value of a key is
+// "<id>.<secret>", it's only returned by Create. Keys are read-only unless
+// created with the read-write scope, and never expire unless created with an
+// expiration date. Authenticate returns the key with the value given.
+//
+//go:generate mockgen -destination mocks/service_mock.go -package mocks . Service
+type Service interface {
+	Create(ctx context.Context, owner string, dto dtos.CreateKey) (models.IssuedKey, error)
+	GetAll(ctx context.Context, owner string) ([]models.Key, error)
+	Delete(ctx context.Context, owner string, id string) error
+	Authenticate(ctx context.Context, value string) (models.Key, error)
+}
+
+type KeysService struct {
+	repository Repository
+	clock      todo.Clock
+}
+
+func NewKeysService(repository Repository, clock todo.Clock) *KeysService {
+	return &KeysService{
+		repository: repository,
+		clock:      clock,
+	}
+}
+
+func (k *KeysService) Create(ctx context.Context, owner string, dto dtos.CreateKey) (models.IssuedKey, error) {
+	if strings.TrimSpace(dto.Name) == "" {
+		return models.IssuedKey{}, ErrInvalidName
+	}
+
+	scope := models.Scope(dto.Scope)
+	switch scope {
+	case "":
+		scope = models.ScopeReadOnly
+	case models.ScopeReadOnly, models.ScopeReadWrite:
+	default:
+		return models.IssuedKey{}, ErrInvalidScope
+	}
+
+	now := k.clock.Now()
+	var expiresAt *time.Time
+	if dto.ExpiresAt != "" {
+		parsed, err := time.Parse(time.DateTime, dto.ExpiresAt)
+		if err != nil {
+			return models.IssuedKey{}, ErrInvalidExpiresAt
+		}
+
+		if !parsed.After(now) {
+			return models.IssuedKey{}, ErrExpiresAtInPast
+		}
+
+		expiresAt = &parsed
+	}
+
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return models.IssuedKey{}, ErrWhileCreating
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(secret)
+	key, err := k.repository.Create(ctx, models.Key{
+		Owner:     owner,
+		Name:      dto.Name,
+		Scope:     scope,
+		Hash:      hash(encoded),
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return models.IssuedKey{}, err
+	}
+
+	return models.IssuedKey{Key: key, Secret: key.ID + "." + encoded}, nil
+}
+
+func (k *KeysService) GetAll(ctx context.Context, owner string) ([]models.Key, error) {
+	return k.repository.GetAll(ctx, owner)
+}
+
+func (k *KeysService) Delete(ctx context.Context, owner string, id string) error {
+	return k.repository.Delete(ctx, owner, id)
+}
+
+func (k *KeysService) Authenticate(ctx context.Context, value string) (models.Key, error) {
+	id, secret, found := strings.Cut(value, ".")
+	if !found {
+		return models.Key{}, ErrInvalidKey
+	}
+
+	key, err := k.repository.GetByID(ctx, id)
+	if errors.Is(err, ErrInvalidID) || errors.Is(err, ErrKeyNotFound) {
+		return models.Key{}, ErrInvalidKey
+	}
+
+	if err != nil {
+		return models.Key{}, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hash(secret)), []byte(key.Hash)) != 1 {
+		return models.Key{}, ErrInvalidKey
+	}
+
+	if key.ExpiresAt != nil && !k.clock.Now().Before(*key.ExpiresAt) {
+		return models.Key{}, ErrKeyIsExpired
+	}
+
+	return key, nil
+}
+
+// hash is enough for the secrets of the keys, unlike passwords they're random
+// and long enough not to be guessed.
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
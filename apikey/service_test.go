@@ -0,0 +1,188 @@
+package apikey_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"todo-app/apikey"
+	"todo-app/apikey/dtos"
+	"todo-app/apikey/mocks"
+	"todo-app/apikey/models"
+	todomocks "todo-app/todo/mocks"
+)
+
+var now = time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+func newClock(ctrl *gomock.Controller) *todomocks.MockClock {
+	clock := todomocks.NewMockClock(ctrl)
+	clock.EXPECT().Now().Return(now).AnyTimes()
+	return clock
+}
+
+func TestNewKeysService(t *testing.T) {
+	t.Run("should return a not nil instance", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := apikey.NewKeysService(mocks.NewMockRepository(ctrl), newClock(ctrl))
+		assert.NotNil(t, service)
+		assert.IsType(t, &apikey.KeysService{}, service)
+	})
+}
+
+func TestKeysService_Create(t *testing.T) {
+	owner := "test@test.test"
+	ctx := context.TODO()
+	ctxMatcher := reflect.TypeOf((*context.Context)(nil)).Elem()
+
+	for name, test := range map[string]struct {
+		dto dtos.CreateKey
+		err error
+	}{
+		"should require a name":                        {dtos.CreateKey{Name: " "}, apikey.ErrInvalidName},
+		"should reject an unknown scope":               {dtos.CreateKey{Name: "ci", Scope: "admin"}, apikey.ErrInvalidScope},
+		"should reject an invalid expiration date":     {dtos.CreateKey{Name: "ci", ExpiresAt: "tomorrow"}, apikey.ErrInvalidExpiresAt},
+		"should reject an expiration date in the past": {dtos.CreateKey{Name: "ci", ExpiresAt: "2024-03-01 11:00:00"}, apikey.ErrExpiresAtInPast},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			service := apikey.NewKeysService(mocks.NewMockRepository(ctrl), newClock(ctrl))
+			response, err := service.Create(ctx, owner, test.dto)
+			assert.ErrorIs(t, err, test.err)
+			assert.Zero(t, response)
+		})
+	}
+
+	t.Run("should store the hash of a new read-only key by default", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		var stored models.Key
+		repository.
+			EXPECT().
+			Create(gomock.AssignableToTypeOf(ctxMatcher), gomock.AssignableToTypeOf(models.Key{})).
+			DoAndReturn(func(_ context.Context, key models.Key) (models.Key, error) {
+				stored = key
+				key.ID = "279f4a4e-48dc-4569-83df-8b30ce488599"
+				return key, nil
+			})
+
+		service := apikey.NewKeysService(repository, newClock(ctrl))
+		response, err := service.Create(ctx, owner, dtos.CreateKey{Name: "ci", ExpiresAt: "2024-04-01 00:00:00"})
+		assert.NoError(t, err)
+		assert.Equal(t, owner, stored.Owner)
+		assert.Equal(t, "ci", stored.Name)
+		assert.Equal(t, models.ScopeReadOnly, stored.Scope)
+		assert.Equal(t, now, stored.CreatedAt)
+		assert.Equal(t, time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC), *stored.ExpiresAt)
+		assert.NotEmpty(t, stored.Hash)
+
+		id, secret, found := strings.Cut(response.Secret, ".")
+		assert.True(t, found)
+		assert.Equal(t, "279f4a4e-48dc-4569-83df-8b30ce488599", id)
+		assert.NotContains(t, stored.Hash, secret)
+	})
+
+	t.Run("should return the repository error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.EXPECT().Create(gomock.Any(), gomock.Any()).Return(models.Key{}, apikey.ErrWhileCreating)
+
+		service := apikey.NewKeysService(repository, newClock(ctrl))
+		response, err := service.Create(ctx, owner, dtos.CreateKey{Name: "ci", Scope: "read-write"})
+		assert.ErrorIs(t, err, apikey.ErrWhileCreating)
+		assert.Zero(t, response)
+	})
+}
+
+func TestKeysService_GetAll(t *testing.T) {
+	t.Run("should return the keys of the owner", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.EXPECT().GetAll(gomock.Any(), gomock.Eq("test@test.test")).Return([]models.Key{{ID: "id"}}, nil)
+
+		service := apikey.NewKeysService(repository, newClock(ctrl))
+		response, err := service.GetAll(context.TODO(), "test@test.test")
+		assert.NoError(t, err)
+		assert.Len(t, response, 1)
+	})
+}
+
+func TestKeysService_Delete(t *testing.T) {
+	t.Run("should delete the key of the owner", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.EXPECT().Delete(gomock.Any(), gomock.Eq("test@test.test"), gomock.Eq("id")).Return(apikey.ErrKeyNotFound)
+
+		service := apikey.NewKeysService(repository, newClock(ctrl))
+		err := service.Delete(context.TODO(), "test@test.test", "id")
+		assert.ErrorIs(t, err, apikey.ErrKeyNotFound)
+	})
+}
+
+func TestKeysService_Authenticate(t *testing.T) {
+	ctx := context.TODO()
+
+	// issue creates a key through the service, so its hash matches the
+	// secret returned.
+	issue := func(t *testing.T, dto dtos.CreateKey) (*apikey.KeysService, models.IssuedKey) {
+		ctrl := gomock.NewController(t)
+		repository := apikey.NewMemoryRepository()
+		service := apikey.NewKeysService(repository, newClock(ctrl))
+		issued, err := service.Create(ctx, "test@test.test", dto)
+		assert.NoError(t, err)
+		return service, issued
+	}
+
+	t.Run("should return the key with the value given", func(t *testing.T) {
+		service, issued := issue(t, dtos.CreateKey{Name: "ci", Scope: "read-write"})
+
+		key, err := service.Authenticate(ctx, issued.Secret)
+		assert.NoError(t, err)
+		assert.Equal(t, issued.ID, key.ID)
+		assert.Equal(t, "test@test.test", key.Owner)
+		assert.Equal(t, models.ScopeReadWrite, key.Scope)
+	})
+
+	t.Run("should reject a wrong secret", func(t *testing.T) {
+		service, issued := issue(t, dtos.CreateKey{Name: "ci"})
+
+		_, err := service.Authenticate(ctx, issued.ID+".wrong")
+		assert.ErrorIs(t, err, apikey.ErrInvalidKey)
+	})
+
+	t.Run("should reject malformed and unknown keys", func(t *testing.T) {
+		service, _ := issue(t, dtos.CreateKey{Name: "ci"})
+
+		for _, value := range []string{"", "malformed", "invalid.secret", "279f4a4e-48dc-4569-83df-8b30ce488599.secret"} {
+			_, err := service.Authenticate(ctx, value)
+			assert.ErrorIs(t, err, apikey.ErrInvalidKey, value)
+		}
+	})
+
+	t.Run("should reject expired keys", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := apikey.NewMemoryRepository()
+		issued, err := apikey.NewKeysService(repository, newClock(ctrl)).
+			Create(ctx, "test@test.test", dtos.CreateKey{Name: "ci", ExpiresAt: "2024-03-02 00:00:00"})
+		assert.NoError(t, err)
+
+		later := todomocks.NewMockClock(ctrl)
+		later.EXPECT().Now().Return(now.Add(24 * time.Hour)).AnyTimes()
+		_, err = apikey.NewKeysService(repository, later).Authenticate(ctx, issued.Secret)
+		assert.ErrorIs(t, err, apikey.ErrKeyIsExpired)
+	})
+
+	t.Run("should return the repository error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.EXPECT().GetByID(gomock.Any(), gomock.Eq("id")).Return(models.Key{}, apikey.ErrWhileRetrieving)
+
+		service := apikey.NewKeysService(repository, newClock(ctrl))
+		_, err := service.Authenticate(ctx, "id.secret")
+		assert.ErrorIs(t, err, apikey.ErrWhileRetrieving)
+	})
+}
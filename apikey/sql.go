@@ -0,0 +1,26 @@
+package apikey
+
+import (
+	"database/sql"
+
+	"todo-app/apikey/models"
+	"todo-app/internal/storage"
+)
+
+// keyColumns are the columns scanKey reads, shared by the SQL repositories.
+const keyColumns = "id, owner, name, scope, hash, created_at, expires_at"
+
+func scanKey(row storage.Scanner) (models.Key, error) {
+	var key models.Key
+	var expiresAt sql.NullTime
+	err := row.Scan(&key.ID, &key.Owner, &key.Name, &key.Scope, &key.Hash, &key.CreatedAt, &expiresAt)
+	if err != nil {
+		return models.Key{}, err
+	}
+
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+
+	return key, nil
+}
@@ -0,0 +1,100 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"todo-app/apikey/models"
+	"todo-app/internal/storage"
+)
+
+type SQLiteRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteRepository(db *sql.DB) *SQLiteRepository {
+	return &SQLiteRepository{
+		db: db,
+	}
+}
+
+func (s *SQLiteRepository) Create(ctx context.Context, key models.Key) (models.Key, error) {
+	key.ID = uuid.NewString()
+	_, err := s.db.ExecContext(
+		ctx,
+		"INSERT INTO api_keys ("+keyColumns+") VALUES (?, ?, ?, ?, ?, ?, ?)",
+		key.ID, key.Owner, key.Name, key.Scope, key.Hash, key.CreatedAt.UTC(), storage.NullTime(key.ExpiresAt),
+	)
+	if err != nil {
+		return models.Key{}, ErrWhileCreating
+	}
+
+	return key, nil
+}
+
+// GetAll returns the keys of the owner from the oldest to the newest.
+func (s *SQLiteRepository) GetAll(ctx context.Context, owner string) ([]models.Key, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+keyColumns+" FROM api_keys WHERE owner = ? ORDER BY created_at, id", owner)
+	if err != nil {
+		return nil, ErrWhileRetrieving
+	}
+	defer rows.Close()
+
+	keys := make([]models.Key, 0)
+	for rows.Next() {
+		key, err := scanKey(rows)
+		if err != nil {
+			return nil, ErrWhileRetrieving
+		}
+
+		keys = append(keys, key)
+	}
+
+	if rows.Err() != nil {
+		return nil, ErrWhileRetrieving
+	}
+
+	return keys, nil
+}
+
+func (s *SQLiteRepository) GetByID(ctx context.Context, id string) (models.Key, error) {
+	if err := validateID(id); err != nil {
+		return models.Key{}, err
+	}
+
+	key, err := scanKey(s.db.QueryRowContext(ctx, "SELECT "+keyColumns+" FROM api_keys WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Key{}, ErrKeyNotFound
+	}
+
+	if err != nil {
+		return models.Key{}, ErrWhileRetrieving
+	}
+
+	return key, nil
+}
+
+func (s *SQLiteRepository) Delete(ctx context.Context, owner string, id string) error {
+	if err := validateID(id); err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM api_keys WHERE owner = ? AND id = ?", owner, id)
+	if err != nil {
+		return ErrWhileDeleting
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return ErrWhileDeleting
+	}
+
+	if affected == 0 {
+		return ErrKeyNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package apikey
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"todo-app/apikey/models"
+	"todo-app/internal/storage"
+	"todo-app/internal/storage/storagetest"
+)
+
+func TestSQLiteRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository {
+		return NewSQLiteRepository(storagetest.SQLiteDB(t))
+	})
+}
+
+func TestSQLiteRepository_Create(t *testing.T) {
+	t.Run("should store a key that never expires", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := NewSQLiteRepository(storagetest.SQLiteDB(t))
+		created, err := repository.Create(ctx, models.Key{Owner: "test@test.test", Name: "ci", Scope: models.ScopeReadOnly, Hash: "hash", CreatedAt: time.Now()})
+		assert.NoError(t, err)
+
+		stored, err := repository.GetByID(ctx, created.ID)
+		assert.NoError(t, err)
+		assert.Nil(t, stored.ExpiresAt)
+	})
+}
+
+func TestSQLiteRepository_Errors(t *testing.T) {
+	t.Run("should return an error if the keys can't be written or queried", func(t *testing.T) {
+		ctx := context.TODO()
+		db := storagetest.SQLiteDB(t)
+		_, err := db.ExecContext(ctx, "DROP TABLE api_keys")
+		assert.NoError(t, err)
+
+		repository := NewSQLiteRepository(db)
+		_, err = repository.Create(ctx, models.Key{Owner: "test@test.test", CreatedAt: time.Now()})
+		assert.ErrorIs(t, err, ErrWhileCreating)
+
+		_, err = repository.GetAll(ctx, "test@test.test")
+		assert.ErrorIs(t, err, ErrWhileRetrieving)
+
+		err = repository.Delete(ctx, "test@test.test", "279f4a4e-48dc-4569-83df-8b30ce488599")
+		assert.ErrorIs(t, err, ErrWhileDeleting)
+	})
+}
+
+func TestSQLiteRepository_NormalizeOwners(t *testing.T) {
+	t.Run("should find the keys written before the owners were normalized", func(t *testing.T) {
+		ctx := context.TODO()
+		db := storagetest.SQLiteDB(t)
+		id := "279f4a4e-48dc-4569-83df-8b30ce488599"
+		_, err := db.ExecContext(
+			ctx,
+			"INSERT INTO api_keys ("+keyColumns+") VALUES (?, ?, ?, ?, ?, ?, ?)",
+			id, " Bob@Example.COM", "ci", models.ScopeReadOnly, "hash", time.Now().UTC(), nil,
+		)
+		assert.NoError(t, err)
+
+		_, err = db.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = 12")
+		assert.NoError(t, err)
+		assert.NoError(t, storage.MigrateSQLite(ctx, db))
+
+		repository := NewSQLiteRepository(db)
+		keys, err := repository.GetAll(ctx, "bob@example.com")
+		assert.NoError(t, err)
+		if assert.Len(t, keys, 1) {
+			assert.Equal(t, id, keys[0].ID)
+			assert.Equal(t, "bob@example.com", keys[0].Owner)
+		}
+	})
+}
@@ -1,20 +1,42 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"time"
+
 	"go.uber.org/fx"
 
+	"todo-app/apikey"
 	"todo-app/config"
 	"todo-app/internal/http"
 	"todo-app/internal/storage"
 	"todo-app/todo"
+	"todo-app/user"
 )
 
 func main() {
+	configs, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	app := fx.New(
-		fx.Supply(config.AppConfig),
-		storage.Module,
+		fx.Supply(configs),
+		// Leaves the server the whole shutdown timeout to drain the requests
+		// before closing the storage clients.
+		fx.StopTimeout(configs.ShutdownTimeout+5*time.Second),
+		storage.Module(configs),
 		http.Module,
 		todo.Module,
+		apikey.Module,
+		user.Module,
 	)
 
 	app.Run()
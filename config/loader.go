@@ -0,0 +1,233 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	envPrefix  = "TODO_"
+	configFlag = "config"
+)
+
+// Load builds the Config from, in increasing precedence, the defaults, the
+// YAML or TOML file given by -config or TODO_CONFIG, the TODO_* environment
+// variables and the command line flags. The result is validated.
+func Load(args []string) (Config, error) {
+	return load(args, os.LookupEnv, os.Stderr)
+}
+
+func load(args []string, lookupEnv func(string) (string, bool), output io.Writer) (Config, error) {
+	configs := Default()
+	fields := configFields(&configs)
+
+	flags := flag.NewFlagSet("todo-app", flag.ContinueOnError)
+	flags.SetOutput(output)
+	path := flags.String(configFlag, "", "path of a YAML or TOML config file")
+	values := make(map[string]*flagValue, len(fields))
+	for _, field := range fields {
+		values[field.key] = &flagValue{value: field.String(), isBool: field.value.Kind() == reflect.Bool}
+		flags.Var(values[field.key], flagName(field.key), fmt.Sprintf("sets %s (env %s)", field.key, envName(field.key)))
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	if *path == "" {
+		*path, _ = lookupEnv(envPrefix + strings.ToUpper(configFlag))
+	}
+
+	if *path != "" {
+		if err := loadFile(*path, fields); err != nil {
+			return Config{}, err
+		}
+	}
+
+	for _, field := range fields {
+		value, ok := lookupEnv(envName(field.key))
+		if !ok {
+			continue
+		}
+
+		if err := field.set(value); err != nil {
+			return Config{}, fmt.Errorf("%s: %w", envName(field.key), err)
+		}
+	}
+
+	var err error
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == configFlag || err != nil {
+			return
+		}
+
+		key := strings.ReplaceAll(f.Name, "-", "_")
+		if setErr := fields[key].set(values[key].value); setErr != nil {
+			err = fmt.Errorf("-%s: %w", f.Name, setErr)
+		}
+	})
+	if err != nil {
+		return Config{}, err
+	}
+
+	if err = configs.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return configs, nil
+}
+
+func loadFile(path string, fields map[string]field) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]any)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &values)
+	case ".toml":
+		err = toml.NewDecoder(bytes.NewReader(content)).Decode(&values)
+	default:
+		return fmt.Errorf("unsupported config file %q, expected .yaml, .yml or .toml", path)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	for key, value := range values {
+		field, ok := fields[key]
+		if !ok {
+			return fmt.Errorf("%s: unknown key %q", path, key)
+		}
+
+		if err = field.set(fileValue(value)); err != nil {
+			return fmt.Errorf("%s: %s: %w", path, key, err)
+		}
+	}
+
+	return nil
+}
+
+// fileValue turns a decoded value back into the text form the env vars and
+// flags use, so every source goes through field.set.
+func fileValue(value any) string {
+	if list, ok := value.([]any); ok {
+		items := make([]string, 0, len(list))
+		for _, item := range list {
+			items = append(items, fmt.Sprint(item))
+		}
+
+		return strings.Join(items, ",")
+	}
+
+	return fmt.Sprint(value)
+}
+
+// flagValue holds the raw flag value until it's applied on top of the file and
+// the environment.
+type flagValue struct {
+	value  string
+	isBool bool
+}
+
+func (f *flagValue) String() string {
+	return f.value
+}
+
+func (f *flagValue) Set(value string) error {
+	f.value = value
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	return f.isBool
+}
+
+type field struct {
+	key   string
+	value reflect.Value
+}
+
+func configFields(configs *Config) map[string]field {
+	fields := make(map[string]field)
+	value := reflect.ValueOf(configs).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		key := value.Type().Field(i).Tag.Get("config")
+		if key == "" {
+			continue
+		}
+
+		fields[key] = field{key: key, value: value.Field(i)}
+	}
+
+	return fields
+}
+
+func (f field) String() string {
+	if items, ok := f.value.Interface().([]string); ok {
+		return strings.Join(items, ",")
+	}
+
+	return fmt.Sprint(f.value.Interface())
+}
+
+func (f field) set(raw string) error {
+	raw = strings.TrimSpace(raw)
+	switch f.value.Interface().(type) {
+	case string:
+		f.value.SetString(raw)
+	case int:
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", raw)
+		}
+		f.value.SetInt(int64(parsed))
+	case bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", raw)
+		}
+		f.value.SetBool(parsed)
+	case time.Duration:
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration", raw)
+		}
+		f.value.SetInt(int64(parsed))
+	case []string:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		f.value.Set(reflect.ValueOf(items))
+	default:
+		return errors.New("unsupported config type " + f.value.Type().String())
+	}
+
+	return nil
+}
+
+func envName(key string) string {
+	return envPrefix + strings.ToUpper(key)
+}
+
+func flagName(key string) string {
+	return strings.ReplaceAll(key, "_", "-")
+}
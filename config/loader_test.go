@@ -0,0 +1,294 @@
+package config
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	// HS256, the default algorithm, can't be used without a secret, nor the
+	// accounts without a mailer.
+	secretEnv := mapEnv(map[string]string{"TODO_JWT_SECRET": "secret", "TODO_MAIL_LOG": "true"})
+
+	t.Run("should return the defaults", func(t *testing.T) {
+		expected := Default()
+		expected.JWTSecret = "secret"
+		expected.MailLog = true
+
+		configs, err := load(nil, secretEnv, io.Discard)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, configs)
+	})
+
+	t.Run("should load a yaml file", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", "storage: memory\nport: \":9090\"\npostgres_max_conns: 20\nredis_addrs:\n  - node-1:6379\n  - node-2:6379\n")
+
+		configs, err := load([]string{"-config", path}, secretEnv, io.Discard)
+		assert.NoError(t, err)
+		assert.Equal(t, MemoryStorage, configs.Storage)
+		assert.Equal(t, ":9090", configs.Port)
+		assert.Equal(t, 20, configs.PostgresMaxConns)
+		assert.Equal(t, "localhost", configs.RedisHost)
+		assert.Equal(t, []string{"node-1:6379", "node-2:6379"}, configs.RedisAddrs)
+	})
+
+	t.Run("should load a toml file", func(t *testing.T) {
+		path := writeFile(t, "config.toml", "storage = \"sqlite\"\nsqlite_path = \"/tmp/todos.db\"\npostgres_max_conns = 20\n")
+
+		configs, err := load([]string{"-config", path}, secretEnv, io.Discard)
+		assert.NoError(t, err)
+		assert.Equal(t, SQLiteStorage, configs.Storage)
+		assert.Equal(t, "/tmp/todos.db", configs.SQLitePath)
+		assert.Equal(t, 20, configs.PostgresMaxConns)
+	})
+
+	t.Run("should read the file path from the environment", func(t *testing.T) {
+		path := writeFile(t, "config.yml", "storage: memory\n")
+		env := mapEnv(map[string]string{"TODO_CONFIG": path, "TODO_JWT_SECRET": "secret", "TODO_MAIL_LOG": "true"})
+
+		configs, err := load(nil, env, io.Discard)
+		assert.NoError(t, err)
+		assert.Equal(t, MemoryStorage, configs.Storage)
+	})
+
+	t.Run("should apply the file, env and flags in order of precedence", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", "port: \":1000\"\nredis_host: file\nredis_port: \"1000\"\n")
+		env := mapEnv(map[string]string{
+			"TODO_REDIS_HOST": "env",
+			"TODO_REDIS_PORT": "2000",
+			"TODO_JWT_SECRET": "secret",
+			"TODO_MAIL_LOG":   "true",
+		})
+
+		configs, err := load([]string{"-config", path, "-redis-port", "3000"}, env, io.Discard)
+		assert.NoError(t, err)
+		assert.Equal(t, ":1000", configs.Port)
+		assert.Equal(t, "env", configs.RedisHost)
+		assert.Equal(t, "3000", configs.RedisPort)
+	})
+
+	t.Run("should return an error for an unknown file key", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", "unknown: value\n")
+
+		_, err := load([]string{"-config", path}, secretEnv, io.Discard)
+		assert.ErrorContains(t, err, "unknown")
+	})
+
+	t.Run("should return an error for an unsupported file extension", func(t *testing.T) {
+		path := writeFile(t, "config.json", "{}")
+
+		_, err := load([]string{"-config", path}, secretEnv, io.Discard)
+		assert.Error(t, err)
+	})
+
+	t.Run("should parse durations and booleans", func(t *testing.T) {
+		path := writeFile(t, "config.toml", "redis_read_timeout = \"10s\"\nredis_tls = true\n")
+		env := mapEnv(map[string]string{"TODO_REDIS_DIAL_TIMEOUT": "1m", "TODO_JWT_SECRET": "secret", "TODO_MAIL_LOG": "true"})
+
+		configs, err := load([]string{"-config", path, "-redis-tls-insecure-skip-verify"}, env, io.Discard)
+		assert.NoError(t, err)
+		assert.Equal(t, 10*time.Second, configs.RedisReadTimeout)
+		assert.Equal(t, time.Minute, configs.RedisDialTimeout)
+		assert.True(t, configs.RedisTLS)
+		assert.True(t, configs.RedisTLSInsecureSkipVerify)
+	})
+
+	t.Run("should return an error for a value of the wrong type", func(t *testing.T) {
+		env := mapEnv(map[string]string{"TODO_POSTGRES_MAX_CONNS": "many"})
+
+		_, err := load(nil, env, io.Discard)
+		assert.ErrorContains(t, err, "TODO_POSTGRES_MAX_CONNS")
+	})
+
+	t.Run("should return an error for an unknown flag", func(t *testing.T) {
+		_, err := load([]string{"-unknown", "value"}, secretEnv, io.Discard)
+		assert.Error(t, err)
+	})
+
+	t.Run("should validate the result", func(t *testing.T) {
+		_, err := load([]string{"-storage", "unknown"}, secretEnv, io.Discard)
+		assert.ErrorContains(t, err, "unsupported storage")
+	})
+}
+
+func TestConfig_Validate(t *testing.T) {
+	// valid is the default config along with the secret HS256 requires and
+	// the mailer of the accounts.
+	valid := func() Config {
+		configs := Default()
+		configs.JWTSecret = "secret"
+		configs.MailLog = true
+		return configs
+	}
+
+	t.Run("should accept the defaults along with a jwt secret and a mailer", func(t *testing.T) {
+		assert.NoError(t, valid().Validate())
+	})
+
+	t.Run("should require the settings of the selected storage", func(t *testing.T) {
+		configs := valid()
+		configs.Storage = SQLiteStorage
+		configs.SQLitePath = ""
+		assert.Error(t, configs.Validate())
+
+		configs = valid()
+		configs.Storage = PostgresStorage
+		configs.PostgresDSN = ""
+		assert.Error(t, configs.Validate())
+
+		configs = valid()
+		configs.RedisHost = ""
+		assert.Error(t, configs.Validate())
+	})
+
+	t.Run("should validate the redis settings", func(t *testing.T) {
+		configs := valid()
+		configs.RedisURL = "http://localhost"
+		assert.Error(t, configs.Validate())
+
+		configs = valid()
+		configs.RedisHost = ""
+		configs.RedisURL = "rediss://localhost:6380/1"
+		assert.NoError(t, configs.Validate())
+
+		configs = valid()
+		configs.RedisDB = -1
+		assert.Error(t, configs.Validate())
+
+		configs = valid()
+		configs.RedisMode = "unknown"
+		assert.Error(t, configs.Validate())
+
+		configs = valid()
+		configs.RedisMode = RedisSentinel
+		configs.RedisAddrs = []string{"sentinel:26379"}
+		assert.Error(t, configs.Validate())
+		configs.RedisMasterName = "master"
+		assert.NoError(t, configs.Validate())
+
+		configs = valid()
+		configs.RedisMode = RedisCluster
+		configs.RedisAddrs = []string{"node-1:6379", "node-2:6379"}
+		assert.NoError(t, configs.Validate())
+		configs.RedisDB = 1
+		assert.Error(t, configs.Validate())
+
+		configs = valid()
+		configs.RedisMode = RedisCluster
+		configs.RedisAddrs = []string{"node-1:6379"}
+		configs.RedisURL = "redis://localhost:6379"
+		assert.Error(t, configs.Validate())
+
+		configs = valid()
+		configs.RedisTLSCert = "cert.pem"
+		assert.Error(t, configs.Validate())
+
+		configs = valid()
+		configs.RedisReadTimeout = -time.Second
+		assert.Error(t, configs.Validate())
+	})
+
+	t.Run("should require the smtp server and its sender unless the mails are logged", func(t *testing.T) {
+		configs := valid()
+		configs.MailLog = false
+		assert.ErrorContains(t, configs.Validate(), "smtp_addr is required")
+
+		configs.SMTPAddr = "smtp.example.com:587"
+		assert.ErrorContains(t, configs.Validate(), "smtp_from is required")
+
+		configs.SMTPFrom = "todos@example.com"
+		assert.NoError(t, configs.Validate())
+
+		configs.MailLog = true
+		assert.Error(t, configs.Validate())
+	})
+
+	t.Run("should reject inconsistent postgres pool sizes", func(t *testing.T) {
+		configs := valid()
+		configs.PostgresMinConns = 20
+		assert.Error(t, configs.Validate())
+	})
+
+	t.Run("should require the port", func(t *testing.T) {
+		configs := valid()
+		configs.Port = ""
+		assert.Error(t, configs.Validate())
+	})
+
+	t.Run("should reject a negative shutdown timeout", func(t *testing.T) {
+		configs := valid()
+		configs.ShutdownTimeout = -time.Second
+		assert.Error(t, configs.Validate())
+	})
+
+	t.Run("should reject a negative health check timeout", func(t *testing.T) {
+		configs := valid()
+		configs.HealthCheckTimeout = -time.Second
+		assert.Error(t, configs.Validate())
+	})
+
+	t.Run("should reject a negative trash retention", func(t *testing.T) {
+		configs := valid()
+		configs.TrashRetention = -time.Hour
+		assert.Error(t, configs.Validate())
+	})
+
+	t.Run("should reject a negative archive delay", func(t *testing.T) {
+		configs := valid()
+		configs.ArchiveAfter = -time.Hour
+		assert.Error(t, configs.Validate())
+	})
+
+	t.Run("should validate the jwt settings", func(t *testing.T) {
+		configs := valid()
+		configs.JWTAlgorithm = "none"
+		assert.Error(t, configs.Validate())
+
+		configs = valid()
+		configs.JWTPublicKey = "key.pem"
+		assert.Error(t, configs.Validate())
+
+		configs = Default()
+		assert.ErrorContains(t, configs.Validate(), "jwt_secret is required by HS256")
+
+		configs = Default()
+		configs.JWTAlgorithm = JWTRS256
+		configs.JWTSecret = "secret"
+		assert.Error(t, configs.Validate())
+
+		configs = Default()
+		configs.MailLog = true
+		configs.JWTAlgorithm = JWTRS256
+		configs.JWTPublicKey = "key.pem"
+		configs.JWTJWKSFile = "jwks.json"
+		assert.Error(t, configs.Validate())
+
+		configs.JWTJWKSFile = ""
+		assert.NoError(t, configs.Validate())
+
+		configs = valid()
+		configs.JWTTTL = 0
+		assert.Error(t, configs.Validate())
+	})
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func mapEnv(values map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := values[key]
+		return value, ok
+	}
+}
@@ -0,0 +1,28 @@
+package health
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+)
+
+// CheckersTag is the value group the readiness endpoint collects its
+// checkers from.
+const CheckersTag = `group:"health_checkers"`
+
+// Checker is a dependency the readiness endpoint checks, e.g. the storage
+// client. Modules contribute them with AsChecker.
+//
+//go:generate mockgen -destination mocks/checker_mock.go -package mocks . Checker
+type Checker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+func AsChecker(f any) any {
+	return fx.Annotate(
+		f,
+		fx.As(new(Checker)),
+		fx.ResultTags(CheckersTag),
+	)
+}
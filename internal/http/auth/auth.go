@@ -0,0 +1,294 @@
+// Package auth authenticates the requests with the bearer tokens issued to
+// the users, and hands the subject of the token to the handlers.
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+
+	"todo-app/apikey"
+	"todo-app/apikey/models"
+	"todo-app/config"
+)
+
+// APIKeyHeader is the header the API keys are sent in.
+const APIKeyHeader = "X-API-Key"
+
+var (
+	ErrMissingToken = fmt.Errorf("missing bearer token")
+	ErrInvalidToken = fmt.Errorf("invalid token")
+	ErrReadOnlyKey  = fmt.Errorf("the api key is read-only")
+	ErrCannotIssue  = fmt.Errorf("tokens can only be issued with HS256")
+)
+
+type subjectKey struct{}
+
+// Authenticator verifies the tokens signed with the algorithm and keys of the
+// config, and the API keys of the service if it has one. It issues HS256
+// tokens too.
+type Authenticator struct {
+	parser   *jwt.Parser
+	key      jwt.Keyfunc
+	keys     apikey.Service
+	secret   []byte
+	ttl      time.Duration
+	issuer   string
+	audience string
+}
+
+func NewAuthenticator(configs config.Config, keys apikey.Service) (*Authenticator, error) {
+	algorithm := config.JWTHS256
+	if configs.JWTAlgorithm == config.JWTRS256 {
+		algorithm = config.JWTRS256
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{algorithm}),
+		jwt.WithExpirationRequired(),
+	}
+	if configs.JWTIssuer != "" {
+		options = append(options, jwt.WithIssuer(configs.JWTIssuer))
+	}
+
+	if configs.JWTAudience != "" {
+		options = append(options, jwt.WithAudience(configs.JWTAudience))
+	}
+
+	key, err := newKeyfunc(algorithm, configs)
+	if err != nil {
+		return nil, err
+	}
+
+	var secret []byte
+	if algorithm == config.JWTHS256 {
+		secret = []byte(configs.JWTSecret)
+	}
+
+	return &Authenticator{
+		parser:   jwt.NewParser(options...),
+		key:      key,
+		keys:     keys,
+		secret:   secret,
+		ttl:      configs.JWTTTL,
+		issuer:   configs.JWTIssuer,
+		audience: configs.JWTAudience,
+	}, nil
+}
+
+// Authenticate returns the subject of the token.
+func (a *Authenticator) Authenticate(token string) (string, error) {
+	parsed, err := a.parser.Parse(token, a.key)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	subject, err := parsed.Claims.GetSubject()
+	if err != nil || subject == "" {
+		return "", fmt.Errorf("%w: the subject is missing", ErrInvalidToken)
+	}
+
+	return subject, nil
+}
+
+// Issue returns a token for the subject, along with its expiration date.
+func (a *Authenticator) Issue(subject string) (string, time.Time, error) {
+	if a.secret == nil {
+		return "", time.Time{}, ErrCannotIssue
+	}
+
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		Subject:   subject,
+		Issuer:    a.issuer,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(a.ttl)),
+	}
+	if a.audience != "" {
+		claims.Audience = jwt.ClaimStrings{a.audience}
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(a.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, claims.ExpiresAt.Time, nil
+}
+
+// Middleware authenticates the requests with an API key, if they have the
+// X-API-Key header, or else with a bearer token like TokenMiddleware.
+// Read-only keys are only allowed to GET, the rest of their requests are
+// rejected with 403.
+func (a *Authenticator) Middleware(ctx *gin.Context) {
+	value := ctx.GetHeader(APIKeyHeader)
+	if value == "" || a.keys == nil {
+		a.TokenMiddleware(ctx)
+		return
+	}
+
+	key, err := a.keys.Authenticate(ctx.Request.Context(), value)
+	if errors.Is(err, apikey.ErrInvalidKey) || errors.Is(err, apikey.ErrKeyIsExpired) {
+		unauthorized(ctx, err)
+		return
+	}
+
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if key.Scope != models.ScopeReadWrite && ctx.Request.Method != http.MethodGet && ctx.Request.Method != http.MethodHead {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": ErrReadOnlyKey.Error()})
+		return
+	}
+
+	ctx.Request = ctx.Request.WithContext(WithSubject(ctx.Request.Context(), key.Owner))
+	ctx.Next()
+}
+
+// TokenMiddleware rejects the requests without a valid bearer token with 401,
+// and puts the subject of the token in the context of the rest.
+func (a *Authenticator) TokenMiddleware(ctx *gin.Context) {
+	scheme, token, _ := strings.Cut(ctx.GetHeader("Authorization"), " ")
+	if !strings.EqualFold(scheme, "Bearer") || token == "" {
+		unauthorized(ctx, ErrMissingToken)
+		return
+	}
+
+	subject, err := a.Authenticate(token)
+	if err != nil {
+		unauthorized(ctx, ErrInvalidToken)
+		return
+	}
+
+	ctx.Request = ctx.Request.WithContext(WithSubject(ctx.Request.Context(), subject))
+	ctx.Next()
+}
+
+func WithSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+// Subject returns the subject of the token the request was authenticated
+// with, if any.
+func Subject(ctx context.Context) (string, bool) {
+	subject, ok := ctx.Value(subjectKey{}).(string)
+	return subject, ok
+}
+
+func unauthorized(ctx *gin.Context, err error) {
+	ctx.Header("WWW-Authenticate", "Bearer")
+	ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+}
+
+func newKeyfunc(algorithm string, configs config.Config) (jwt.Keyfunc, error) {
+	if algorithm == config.JWTHS256 {
+		if configs.JWTSecret == "" {
+			return nil, errors.New("jwt_secret is required by HS256")
+		}
+
+		secret := []byte(configs.JWTSecret)
+		return func(*jwt.Token) (any, error) {
+			return secret, nil
+		}, nil
+	}
+
+	if configs.JWTPublicKey != "" {
+		content, err := os.ReadFile(configs.JWTPublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("reading the jwt public key: %w", err)
+		}
+
+		key, err := jwt.ParseRSAPublicKeyFromPEM(content)
+		if err != nil {
+			return nil, fmt.Errorf("parsing the jwt public key: %w", err)
+		}
+
+		return func(*jwt.Token) (any, error) {
+			return key, nil
+		}, nil
+	}
+
+	if configs.JWTJWKSFile != "" {
+		keys, err := readJWKS(configs.JWTJWKSFile)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(token *jwt.Token) (any, error) {
+			kid, _ := token.Header["kid"].(string)
+			if key, ok := keys[kid]; ok {
+				return key, nil
+			}
+
+			return nil, fmt.Errorf("unknown key %q", kid)
+		}, nil
+	}
+
+	return nil, errors.New("jwt_public_key or jwt_jwks_file is required by RS256")
+}
+
+// jwk is the part of a JSON Web Key used to verify RS256 signatures.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// readJWKS reads the RSA signing keys of a JWKS file by kid. Keys of other
+// types or uses are skipped.
+func readJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading the jwks file: %w", err)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err = json.Unmarshal(content, &set); err != nil {
+		return nil, fmt.Errorf("parsing the jwks file: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, key := range set.Keys {
+		if key.Kty != "RSA" || (key.Use != "" && key.Use != "sig") {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			return nil, fmt.Errorf("parsing the key %q of the jwks file: %w", key.Kid, err)
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("parsing the key %q of the jwks file: invalid exponent", key.Kid)
+		}
+
+		keys[key.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("the jwks file has no RSA signing keys")
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,350 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"todo-app/apikey"
+	"todo-app/apikey/mocks"
+	"todo-app/apikey/models"
+	"todo-app/config"
+)
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, claims jwt.MapClaims, kid string) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub": "test@example.com",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func writeFile(t *testing.T, name string, content []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestNewAuthenticator(t *testing.T) {
+	t.Run("should require the secret for HS256", func(t *testing.T) {
+		_, err := NewAuthenticator(config.Config{JWTAlgorithm: config.JWTHS256}, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("should require a public key or a jwks file for RS256", func(t *testing.T) {
+		_, err := NewAuthenticator(config.Config{JWTAlgorithm: config.JWTRS256}, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if the public key can't be read", func(t *testing.T) {
+		_, err := NewAuthenticator(config.Config{
+			JWTAlgorithm: config.JWTRS256,
+			JWTPublicKey: filepath.Join(t.TempDir(), "missing.pem"),
+		}, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if the jwks file has no RSA keys", func(t *testing.T) {
+		_, err := NewAuthenticator(config.Config{
+			JWTAlgorithm: config.JWTRS256,
+			JWTJWKSFile:  writeFile(t, "jwks.json", []byte(`{"keys":[{"kty":"EC","kid":"ec"}]}`)),
+		}, nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestAuthenticator_Authenticate(t *testing.T) {
+	t.Run("should return the subject of a HS256 token", func(t *testing.T) {
+		authenticator, err := NewAuthenticator(config.Config{JWTSecret: "secret"}, nil)
+		assert.NoError(t, err)
+
+		subject, err := authenticator.Authenticate(sign(t, jwt.SigningMethodHS256, []byte("secret"), validClaims(), ""))
+		assert.NoError(t, err)
+		assert.Equal(t, "test@example.com", subject)
+	})
+
+	t.Run("should reject the tokens signed with another secret", func(t *testing.T) {
+		authenticator, _ := NewAuthenticator(config.Config{JWTSecret: "secret"}, nil)
+
+		_, err := authenticator.Authenticate(sign(t, jwt.SigningMethodHS256, []byte("other"), validClaims(), ""))
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("should reject the expired tokens", func(t *testing.T) {
+		authenticator, _ := NewAuthenticator(config.Config{JWTSecret: "secret"}, nil)
+		claims := validClaims()
+		claims["exp"] = time.Now().Add(-time.Minute).Unix()
+
+		_, err := authenticator.Authenticate(sign(t, jwt.SigningMethodHS256, []byte("secret"), claims, ""))
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("should reject the tokens without an expiration", func(t *testing.T) {
+		authenticator, _ := NewAuthenticator(config.Config{JWTSecret: "secret"}, nil)
+		claims := validClaims()
+		delete(claims, "exp")
+
+		_, err := authenticator.Authenticate(sign(t, jwt.SigningMethodHS256, []byte("secret"), claims, ""))
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("should reject the tokens without a subject", func(t *testing.T) {
+		authenticator, _ := NewAuthenticator(config.Config{JWTSecret: "secret"}, nil)
+		claims := validClaims()
+		delete(claims, "sub")
+
+		_, err := authenticator.Authenticate(sign(t, jwt.SigningMethodHS256, []byte("secret"), claims, ""))
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("should check the issuer and the audience if they're set", func(t *testing.T) {
+		authenticator, _ := NewAuthenticator(config.Config{
+			JWTSecret:   "secret",
+			JWTIssuer:   "issuer",
+			JWTAudience: "todo-app",
+		}, nil)
+
+		_, err := authenticator.Authenticate(sign(t, jwt.SigningMethodHS256, []byte("secret"), validClaims(), ""))
+		assert.ErrorIs(t, err, ErrInvalidToken)
+
+		claims := validClaims()
+		claims["iss"] = "issuer"
+		claims["aud"] = "todo-app"
+		_, err = authenticator.Authenticate(sign(t, jwt.SigningMethodHS256, []byte("secret"), claims, ""))
+		assert.NoError(t, err)
+	})
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should return the subject of a RS256 token verified with the public key", func(t *testing.T) {
+		der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		path := writeFile(t, "key.pem", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+		authenticator, err := NewAuthenticator(config.Config{JWTAlgorithm: config.JWTRS256, JWTPublicKey: path}, nil)
+		assert.NoError(t, err)
+
+		subject, err := authenticator.Authenticate(sign(t, jwt.SigningMethodRS256, key, validClaims(), ""))
+		assert.NoError(t, err)
+		assert.Equal(t, "test@example.com", subject)
+	})
+
+	t.Run("should verify RS256 tokens with the key of the jwks file with their kid", func(t *testing.T) {
+		jwks, _ := json.Marshal(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "key-1",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+		authenticator, err := NewAuthenticator(config.Config{
+			JWTAlgorithm: config.JWTRS256,
+			JWTJWKSFile:  writeFile(t, "jwks.json", jwks),
+		}, nil)
+		assert.NoError(t, err)
+
+		subject, err := authenticator.Authenticate(sign(t, jwt.SigningMethodRS256, key, validClaims(), "key-1"))
+		assert.NoError(t, err)
+		assert.Equal(t, "test@example.com", subject)
+
+		_, err = authenticator.Authenticate(sign(t, jwt.SigningMethodRS256, key, validClaims(), "key-2"))
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("should reject the tokens signed with another algorithm", func(t *testing.T) {
+		authenticator, _ := NewAuthenticator(config.Config{JWTSecret: "secret"}, nil)
+
+		_, err := authenticator.Authenticate(sign(t, jwt.SigningMethodRS256, key, validClaims(), ""))
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
+
+func TestAuthenticator_Issue(t *testing.T) {
+	t.Run("should issue tokens it authenticates", func(t *testing.T) {
+		authenticator, _ := NewAuthenticator(config.Config{
+			JWTSecret:   "secret",
+			JWTTTL:      time.Hour,
+			JWTIssuer:   "issuer",
+			JWTAudience: "todo-app",
+		}, nil)
+
+		token, expiresAt, err := authenticator.Issue("test@example.com")
+		assert.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Minute)
+
+		subject, err := authenticator.Authenticate(token)
+		assert.NoError(t, err)
+		assert.Equal(t, "test@example.com", subject)
+	})
+
+	t.Run("should not issue RS256 tokens", func(t *testing.T) {
+		key, _ := rsa.GenerateKey(rand.Reader, 2048)
+		der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		path := writeFile(t, "key.pem", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+		authenticator, _ := NewAuthenticator(config.Config{JWTAlgorithm: config.JWTRS256, JWTPublicKey: path, JWTTTL: time.Hour}, nil)
+
+		_, _, err := authenticator.Issue("test@example.com")
+		assert.ErrorIs(t, err, ErrCannotIssue)
+	})
+}
+
+func TestAuthenticator_Middleware(t *testing.T) {
+	authenticator, err := NewAuthenticator(config.Config{JWTSecret: "secret"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newEngine := func() *gin.Engine {
+		r := gin.New()
+		r.GET("/", authenticator.Middleware, func(ctx *gin.Context) {
+			subject, _ := Subject(ctx.Request.Context())
+			ctx.String(http.StatusOK, subject)
+		})
+		return r
+	}
+
+	t.Run("should return 401 without a bearer token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+		newEngine().ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+		assert.JSONEq(t, `{"error":"missing bearer token"}`, w.Body.String())
+	})
+
+	t.Run("should return 401 if the token is invalid", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer invalid")
+		newEngine().ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.JSONEq(t, `{"error":"invalid token"}`, w.Body.String())
+	})
+
+	t.Run("should put the subject in the context of the request", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+sign(t, jwt.SigningMethodHS256, []byte("secret"), validClaims(), ""))
+		newEngine().ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "test@example.com", w.Body.String())
+	})
+}
+
+func TestAuthenticator_Middleware_APIKeys(t *testing.T) {
+	newEngine := func(t *testing.T, key models.Key, err error) *gin.Engine {
+		ctrl := gomock.NewController(t)
+		keys := mocks.NewMockService(ctrl)
+		keys.EXPECT().Authenticate(gomock.Any(), gomock.Eq("id.secret")).Return(key, err).AnyTimes()
+
+		authenticator, _ := NewAuthenticator(config.Config{JWTSecret: "secret"}, keys)
+		r := gin.New()
+		handler := func(ctx *gin.Context) {
+			subject, _ := Subject(ctx.Request.Context())
+			ctx.String(http.StatusOK, subject)
+		}
+		r.GET("/", authenticator.Middleware, handler)
+		r.POST("/", authenticator.Middleware, handler)
+		r.GET("/token", authenticator.TokenMiddleware, handler)
+		return r
+	}
+
+	serve := func(engine *gin.Engine, method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set(APIKeyHeader, "id.secret")
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should put the owner of the key in the context of the request", func(t *testing.T) {
+		engine := newEngine(t, models.Key{Owner: "test@example.com", Scope: models.ScopeReadWrite}, nil)
+
+		w := serve(engine, http.MethodPost, "/")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "test@example.com", w.Body.String())
+	})
+
+	t.Run("should only let read-only keys GET", func(t *testing.T) {
+		engine := newEngine(t, models.Key{Owner: "test@example.com", Scope: models.ScopeReadOnly}, nil)
+
+		w := serve(engine, http.MethodGet, "/")
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = serve(engine, http.MethodPost, "/")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.JSONEq(t, `{"error":"the api key is read-only"}`, w.Body.String())
+	})
+
+	t.Run("should return 401 if the key is invalid or expired", func(t *testing.T) {
+		w := serve(newEngine(t, models.Key{}, apikey.ErrInvalidKey), http.MethodGet, "/")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		w = serve(newEngine(t, models.Key{}, apikey.ErrKeyIsExpired), http.MethodGet, "/")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("should return 500 if the key can't be checked", func(t *testing.T) {
+		w := serve(newEngine(t, models.Key{}, apikey.ErrWhileRetrieving), http.MethodGet, "/")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("should not accept keys where a token is required", func(t *testing.T) {
+		engine := newEngine(t, models.Key{Owner: "test@example.com", Scope: models.ScopeReadWrite}, nil)
+
+		w := serve(engine, http.MethodGet, "/token")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestSubject(t *testing.T) {
+	t.Run("should return false without a subject", func(t *testing.T) {
+		_, ok := Subject(context.TODO())
+		assert.False(t, ok)
+	})
+
+	t.Run("should return the subject of the context", func(t *testing.T) {
+		subject, ok := Subject(WithSubject(context.TODO(), "test@example.com"))
+		assert.True(t, ok)
+		assert.Equal(t, "test@example.com", subject)
+	})
+}
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"todo-app/apikey"
+	"todo-app/apikey/dtos"
+	"todo-app/internal/http/auth"
+)
+
+type KeysController struct {
+	service       apikey.Service
+	authenticator *auth.Authenticator
+}
+
+func NewKeysController(service apikey.Service, authenticator *auth.Authenticator) *KeysController {
+	return &KeysController{
+		service:       service,
+		authenticator: authenticator,
+	}
+}
+
+// CreateRoutes serves the API keys of the owner of the token. The keys can't
+// be managed with API keys themselves.
+func (k *KeysController) CreateRoutes(base *gin.RouterGroup) {
+	group := base.Group("/keys", k.authenticator.TokenMiddleware, resolveOwner)
+	group.POST("", k.Create)
+	group.GET("", k.GetAll)
+	group.DELETE("/:id", k.Delete)
+}
+
+func (k *KeysController) Create(ctx *gin.Context) {
+	var dto dtos.CreateKey
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	email := owner(ctx).String()
+	response, err := k.service.Create(ctx, email, dto)
+	if err != nil {
+		code := getStatusCode(err)
+		ctx.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": response})
+}
+
+func (k *KeysController) GetAll(ctx *gin.Context) {
+	email := owner(ctx).String()
+	response, err := k.service.GetAll(ctx, email)
+	if err != nil {
+		code := getStatusCode(err)
+		ctx.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (k *KeysController) Delete(ctx *gin.Context) {
+	email := owner(ctx).String()
+	id := ctx.Param("id")
+	if err := k.service.Delete(ctx, email, id); err != nil {
+		code := getStatusCode(err)
+		ctx.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
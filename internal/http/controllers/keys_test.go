@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"todo-app/apikey"
+	"todo-app/apikey/dtos"
+	"todo-app/apikey/mocks"
+	"todo-app/apikey/models"
+)
+
+func newKeysEngine(t *testing.T, service apikey.Service) *gin.Engine {
+	r := gin.Default()
+	NewKeysController(service, newAuthenticator(t)).CreateRoutes(r.Group("/api"))
+	return r
+}
+
+func serveKeys(t *testing.T, r *gin.Engine, method, path string, body []byte) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+signToken(t, testSecret, "test@example.com"))
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestNewKeysController(t *testing.T) {
+	t.Run("should return a not nil instance", func(t *testing.T) {
+		controller := NewKeysController(nil, newAuthenticator(t))
+		assert.NotNil(t, controller)
+	})
+}
+
+func TestKeysController_CreateRoutes(t *testing.T) {
+	t.Run("should create new routes", func(t *testing.T) {
+		engine := gin.Default()
+		NewKeysController(nil, newAuthenticator(t)).CreateRoutes(engine.Group("/api"))
+		assert.Len(t, engine.Routes(), 3)
+	})
+
+	t.Run("should return 401 without a token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		r := newKeysEngine(t, mocks.NewMockService(ctrl))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/keys", nil)
+		req.Header.Set("X-API-Key", "id.secret")
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestKeysController_Create(t *testing.T) {
+	t.Run("should return 400 if the body is invalid", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		r := newKeysEngine(t, mocks.NewMockService(ctrl))
+
+		w := serveKeys(t, r, http.MethodPost, "/api/keys", []byte("invalid"))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("should return the service error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Create(ctxMatcher, gomock.Eq("test@example.com"), gomock.AssignableToTypeOf(dtos.CreateKey{})).Return(models.IssuedKey{}, apikey.ErrInvalidScope)
+		r := newKeysEngine(t, service)
+
+		w := serveKeys(t, r, http.MethodPost, "/api/keys", []byte(`{"name":"ci","scope":"admin"}`))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("should return 201 with the secret of the key", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.
+			EXPECT().
+			Create(ctxMatcher, gomock.Eq("test@example.com"), gomock.Eq(dtos.CreateKey{Name: "ci", Scope: "read-write"})).
+			Return(models.IssuedKey{Key: models.Key{ID: "id", Hash: "hash"}, Secret: "id.secret"}, nil)
+		r := newKeysEngine(t, service)
+
+		w := serveKeys(t, r, http.MethodPost, "/api/keys", []byte(`{"name":"ci","scope":"read-write"}`))
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var response struct {
+			Data map[string]any `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "id.secret", response.Data["key"])
+		assert.NotContains(t, response.Data, "hash")
+	})
+}
+
+func TestKeysController_GetAll(t *testing.T) {
+	t.Run("should return the keys of the owner of the token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().GetAll(ctxMatcher, gomock.Eq("test@example.com")).Return([]models.Key{{ID: "id"}}, nil)
+		r := newKeysEngine(t, service)
+
+		w := serveKeys(t, r, http.MethodGet, "/api/keys", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("should return the service error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().GetAll(ctxMatcher, gomock.Eq("test@example.com")).Return(nil, apikey.ErrWhileRetrieving)
+		r := newKeysEngine(t, service)
+
+		w := serveKeys(t, r, http.MethodGet, "/api/keys", nil)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestKeysController_Delete(t *testing.T) {
+	t.Run("should return 204 if the key is revoked", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Delete(ctxMatcher, gomock.Eq("test@example.com"), idMatcher).Return(nil)
+		r := newKeysEngine(t, service)
+
+		w := serveKeys(t, r, http.MethodDelete, "/api/keys/279f4a4e-48dc-4569-83df-8b30ce488599", nil)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("should return 404 if the key isn't found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Delete(ctxMatcher, gomock.Eq("test@example.com"), idMatcher).Return(apikey.ErrKeyNotFound)
+		r := newKeysEngine(t, service)
+
+		w := serveKeys(t, r, http.MethodDelete, "/api/keys/279f4a4e-48dc-4569-83df-8b30ce488599", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -3,30 +3,68 @@ package controllers
 import (
 	"errors"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
+	"todo-app/apikey"
+	"todo-app/config"
+	"todo-app/internal/http/auth"
 	"todo-app/todo"
 	"todo-app/todo/dtos"
+	"todo-app/todo/models"
+	"todo-app/user"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	ownerKey              = "owner"
 )
 
 type TodosController struct {
-	service todo.Service
+	service       todo.Service
+	authenticator *auth.Authenticator
+	legacyRoutes  bool
 }
 
-func NewTodosController(service todo.Service) *TodosController {
+func NewTodosController(service todo.Service, authenticator *auth.Authenticator, configs config.Config) *TodosController {
 	return &TodosController{
-		service: service,
+		service:       service,
+		authenticator: authenticator,
+		legacyRoutes:  configs.LegacyRoutes,
 	}
 }
 
+// CreateRoutes serves the todos of the owner of the token under /todos/me
+// and, if the legacy routes are enabled, the ones of any email under
+// /todos/:email.
 func (t *TodosController) CreateRoutes(base *gin.RouterGroup) {
-	group := base.Group("/todos/:email")
+	t.createRoutes(base.Group("/todos/me", t.authenticator.Middleware, resolveOwner))
+	if t.legacyRoutes {
+		t.createRoutes(base.Group("/todos/:email", resolveOwner))
+	}
+}
+
+func (t *TodosController) createRoutes(group *gin.RouterGroup) {
 	group.POST("", t.Create)
 	group.GET("", t.GetAll)
+	group.GET("/search", t.Search)
+	group.POST("/batch", t.Batch)
+	group.GET("/trash", t.Trash)
 	group.GET("/:id", t.GetByID)
 	group.DELETE(":id", t.Delete)
 	group.PUT(":id", t.Update)
+	group.PATCH(":id", t.Patch)
+	group.POST("/:id/complete", t.Complete)
+	group.POST("/:id/reopen", t.Reopen)
+	group.POST("/:id/restore", t.Restore)
+	group.POST("/:id/archive", t.Archive)
+	group.POST("/:id/unarchive", t.Unarchive)
+	group.GET("/:id/shares", t.GetShares)
+	group.PUT("/:id/shares/:grantee", t.Share)
+	group.DELETE("/:id/shares/:grantee", t.Unshare)
 }
 
 func (t *TodosController) Create(ctx *gin.Context) {
@@ -36,7 +74,7 @@ func (t *TodosController) Create(ctx *gin.Context) {
 		return
 	}
 
-	email := ctx.Param("email")
+	email := owner(ctx)
 	response, err := t.service.Create(ctx, email, dto)
 	if err != nil {
 		code := getStatusCode(err)
@@ -44,23 +82,45 @@ func (t *TodosController) Create(ctx *gin.Context) {
 		return
 	}
 
+	setETag(ctx, response)
 	ctx.JSON(http.StatusCreated, gin.H{"data": response})
 }
 
 func (t *TodosController) GetAll(ctx *gin.Context) {
-	email := ctx.Param("email")
-	response, err := t.service.GetAll(ctx, email)
+	var dto dtos.ListTodos
+	if err := ctx.ShouldBindQuery(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	email := owner(ctx)
+	page := todo.PageRequest{
+		Limit:  dto.Limit,
+		Cursor: dto.Cursor,
+		Sort:   todo.SortField(dto.Sort),
+		Order:  todo.SortOrder(dto.Order),
+	}
+	filter := todo.TodoFilter{
+		Completed:       dto.Completed,
+		DueBefore:       dto.DueBefore,
+		DueAfter:        dto.DueAfter,
+		StartBefore:     dto.StartBefore,
+		StartAfter:      dto.StartAfter,
+		Overdue:         dto.Overdue,
+		IncludeArchived: dto.IncludeArchived,
+	}
+	response, err := t.service.GetAll(ctx, email, filter, page)
 	if err != nil {
 		code := getStatusCode(err)
 		ctx.JSON(code, gin.H{"error": err.Error()})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"data": response})
+	ctx.JSON(http.StatusOK, gin.H{"data": response.Todos, "next_cursor": response.NextCursor})
 }
 
 func (t *TodosController) GetByID(ctx *gin.Context) {
-	email := ctx.Param("email")
+	email := owner(ctx)
 	id := ctx.Param("id")
 	response, err := t.service.GetByID(ctx, email, id)
 	if err != nil {
@@ -69,14 +129,21 @@ func (t *TodosController) GetByID(ctx *gin.Context) {
 		return
 	}
 
+	setETag(ctx, response)
 	ctx.JSON(http.StatusOK, gin.H{"data": response})
 }
 
 func (t *TodosController) Delete(ctx *gin.Context) {
-	email := ctx.Param("email")
-	id := ctx.Param("id")
+	version, err := t.ifMatch(ctx)
+	if err != nil {
+		code := getStatusCode(err)
+		ctx.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
 
-	if err := t.service.Delete(ctx, email, id); err != nil {
+	email := owner(ctx)
+	id := ctx.Param("id")
+	if err = t.service.Delete(ctx, email, id, version); err != nil {
 		code := getStatusCode(err)
 		ctx.JSON(code, gin.H{"error": err.Error()})
 		return
@@ -86,36 +153,393 @@ func (t *TodosController) Delete(ctx *gin.Context) {
 }
 
 func (t *TodosController) Update(ctx *gin.Context) {
+	version, err := t.ifMatch(ctx)
+	if err != nil {
+		code := getStatusCode(err)
+		ctx.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
 	var dto dtos.UpdateTodo
 	if err := ctx.ShouldBindJSON(&dto); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
 		return
 	}
 
-	email := ctx.Param("email")
+	email := owner(ctx)
 	id := ctx.Param("id")
-	response, err := t.service.Update(ctx, email, id, dto)
+	response, err := t.service.Update(ctx, email, id, version, dto)
 	if err != nil {
 		code := getStatusCode(err)
 		ctx.JSON(code, gin.H{"error": err.Error()})
 		return
 	}
 
+	setETag(ctx, response)
 	ctx.JSON(http.StatusOK, gin.H{"data": response})
 }
 
-func getStatusCode(err error) int {
-	if errors.Is(err, todo.ErrInvalidID) || errors.Is(err, todo.ErrInvalidDueDate) || errors.Is(err, todo.ErrInvalidStartDate) || errors.Is(err, todo.ErrStartDateMustBeGTDueDate) {
-		return http.StatusBadRequest
+func (t *TodosController) Patch(ctx *gin.Context) {
+	if ctx.ContentType() != mergePatchContentType {
+		ctx.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "content type must be " + mergePatchContentType})
+		return
 	}
 
-	if errors.Is(err, todo.ErrTodoIsCompleted) {
-		return http.StatusConflict
+	version, err := t.ifMatch(ctx)
+	if err != nil {
+		code := getStatusCode(err)
+		ctx.JSON(code, gin.H{"error": err.Error()})
+		return
 	}
 
-	if errors.Is(err, todo.ErrTodoNotFound) {
-		return http.StatusNotFound
+	var dto dtos.PatchTodo
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	email := owner(ctx)
+	id := ctx.Param("id")
+	response, err := t.service.Patch(ctx, email, id, version, dto)
+	if err != nil {
+		code := getStatusCode(err)
+		ctx.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
+	setETag(ctx, response)
+	ctx.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (t *TodosController) Complete(ctx *gin.Context) {
+	email := owner(ctx)
+	id := ctx.Param("id")
+	response, err := t.service.Complete(ctx, email, id)
+	if err != nil {
+		code := getStatusCode(err)
+		ctx.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
+	setETag(ctx, response)
+	ctx.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (t *TodosController) Reopen(ctx *gin.Context) {
+	email := owner(ctx)
+	id := ctx.Param("id")
+	response, err := t.service.Reopen(ctx, email, id)
+	if err != nil {
+		code := getStatusCode(err)
+		ctx.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
+	setETag(ctx, response)
+	ctx.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (t *TodosController) Archive(ctx *gin.Context) {
+	email := owner(ctx)
+	id := ctx.Param("id")
+	response, err := t.service.Archive(ctx, email, id)
+	if err != nil {
+		code := getStatusCode(err)
+		ctx.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
+	setETag(ctx, response)
+	ctx.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (t *TodosController) Unarchive(ctx *gin.Context) {
+	email := owner(ctx)
+	id := ctx.Param("id")
+	response, err := t.service.Unarchive(ctx, email, id)
+	if err != nil {
+		code := getStatusCode(err)
+		ctx.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
+	setETag(ctx, response)
+	ctx.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (t *TodosController) Search(ctx *gin.Context) {
+	var dto dtos.SearchTodos
+	if err := ctx.ShouldBindQuery(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	email := owner(ctx)
+	response, err := t.service.Search(ctx, email, dto.Query, dto.Limit)
+	if err != nil {
+		code := getStatusCode(err)
+		ctx.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (t *TodosController) Trash(ctx *gin.Context) {
+	var dto dtos.ListTrash
+	if err := ctx.ShouldBindQuery(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	email := owner(ctx)
+	page := todo.PageRequest{
+		Limit:  dto.Limit,
+		Cursor: dto.Cursor,
+		Sort:   todo.SortField(dto.Sort),
+		Order:  todo.SortOrder(dto.Order),
 	}
+	response, err := t.service.Trash(ctx, email, page)
+	if err != nil {
+		code := getStatusCode(err)
+		ctx.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": response.Todos, "next_cursor": response.NextCursor})
+}
+
+func (t *TodosController) Restore(ctx *gin.Context) {
+	email := owner(ctx)
+	id := ctx.Param("id")
+	response, err := t.service.Restore(ctx, email, id)
+	if err != nil {
+		code := getStatusCode(err)
+		ctx.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
+	setETag(ctx, response)
+	ctx.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+// Share grants the user in the path the role in the body on the todo, or
+// changes the one it had.
+func (t *TodosController) Share(ctx *gin.Context) {
+	var dto dtos.ShareTodo
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	grantee, err := todo.NewOwner(ctx.Param("grantee"))
+	if err != nil {
+		ctx.JSON(getStatusCode(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	email := owner(ctx)
+	id := ctx.Param("id")
+	response, err := t.service.Share(ctx, email, id, grantee, models.Role(dto.Role))
+	if err != nil {
+		code := getStatusCode(err)
+		ctx.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (t *TodosController) Unshare(ctx *gin.Context) {
+	grantee, err := todo.NewOwner(ctx.Param("grantee"))
+	if err != nil {
+		ctx.JSON(getStatusCode(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	email := owner(ctx)
+	id := ctx.Param("id")
+	if err = t.service.Unshare(ctx, email, id, grantee); err != nil {
+		code := getStatusCode(err)
+		ctx.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (t *TodosController) GetShares(ctx *gin.Context) {
+	email := owner(ctx)
+	id := ctx.Param("id")
+	response, err := t.service.GetShares(ctx, email, id)
+	if err != nil {
+		code := getStatusCode(err)
+		ctx.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+// Batch answers 200 with the result of every operation, in order, each with
+// the status code it would have had on its own.
+func (t *TodosController) Batch(ctx *gin.Context) {
+	var dto dtos.BatchTodos
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	email := owner(ctx)
+	response, err := t.service.Batch(ctx, email, dto.Operations)
+	if err != nil {
+		code := getStatusCode(err)
+		ctx.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
+	results := make([]gin.H, len(response))
+	for i, result := range response {
+		switch {
+		case result.Err != nil:
+			results[i] = gin.H{"status": getStatusCode(result.Err), "error": result.Err.Error()}
+		case todo.BatchOp(dto.Operations[i].Op) == todo.BatchCreate:
+			results[i] = gin.H{"status": http.StatusCreated, "data": result.Todo}
+		case todo.BatchOp(dto.Operations[i].Op) == todo.BatchDelete:
+			results[i] = gin.H{"status": http.StatusNoContent}
+		default:
+			results[i] = gin.H{"status": http.StatusOK, "data": result.Todo}
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": results})
+}
+
+// setETag tags the response with the version of the todo, to be sent back
+// in If-Match.
+func setETag(ctx *gin.Context, todo models.Todo) {
+	ctx.Header("ETag", strconv.Quote(strconv.FormatInt(todo.Version, 10)))
+}
+
+// ifMatch reads the version the request expects from If-Match. Without the
+// header, or with *, any version is accepted and 0 is returned. Tags that
+// aren't a version, weak ones included, can never match. When the header
+// lists several versions, the current one is returned if it's among them,
+// and the service still checks it hasn't changed since.
+func (t *TodosController) ifMatch(ctx *gin.Context) (int64, error) {
+	header := ctx.GetHeader("If-Match")
+	if header == "" {
+		return 0, nil
+	}
+
+	var versions []int64
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return 0, nil
+		}
+
+		unquoted, err := strconv.Unquote(tag)
+		if err != nil || tag[0] != '"' {
+			continue
+		}
 
-	return http.StatusInternalServerError
+		version, err := strconv.ParseInt(unquoted, 10, 64)
+		if err == nil && version > 0 {
+			versions = append(versions, version)
+		}
+	}
+
+	switch len(versions) {
+	case 0:
+		return 0, todo.ErrVersionMismatch
+	case 1:
+		return versions[0], nil
+	}
+
+	current, err := t.service.GetByID(ctx, owner(ctx), ctx.Param("id"))
+	if err != nil {
+		return 0, err
+	}
+
+	if !slices.Contains(versions, current.Version) {
+		return 0, todo.ErrVersionMismatch
+	}
+
+	return current.Version, nil
+}
+
+// resolveOwner validates the owner of the request, the subject of the token
+// or, on the legacy routes, the email in the path, and keeps it for owner.
+func resolveOwner(ctx *gin.Context) {
+	email, ok := auth.Subject(ctx.Request.Context())
+	if !ok {
+		email = ctx.Param("email")
+	}
+
+	parsed, err := todo.NewOwner(email)
+	if err != nil {
+		ctx.AbortWithStatusJSON(getStatusCode(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Set(ownerKey, parsed)
+	ctx.Next()
+}
+
+// owner is the owner resolved by resolveOwner.
+func owner(ctx *gin.Context) todo.Owner {
+	return ctx.MustGet(ownerKey).(todo.Owner)
+}
+
+func getStatusCode(err error) int {
+	switch {
+	case errors.Is(err, todo.ErrInvalidOwner),
+		errors.Is(err, todo.ErrInvalidID),
+		errors.Is(err, todo.ErrInvalidDueDate),
+		errors.Is(err, todo.ErrInvalidStartDate),
+		errors.Is(err, todo.ErrStartDateMustBeGTDueDate),
+		errors.Is(err, todo.ErrInvalidPatch),
+		errors.Is(err, todo.ErrInvalidLimit),
+		errors.Is(err, todo.ErrInvalidCursor),
+		errors.Is(err, todo.ErrInvalidSort),
+		errors.Is(err, todo.ErrInvalidOrder),
+		errors.Is(err, todo.ErrInvalidFilter),
+		errors.Is(err, todo.ErrInvalidQuery),
+		errors.Is(err, todo.ErrInvalidBatch),
+		errors.Is(err, todo.ErrInvalidOperation),
+		errors.Is(err, todo.ErrInvalidRole),
+		errors.Is(err, todo.ErrInvalidGrantee),
+		errors.Is(err, apikey.ErrInvalidID),
+		errors.Is(err, apikey.ErrInvalidName),
+		errors.Is(err, apikey.ErrInvalidScope),
+		errors.Is(err, apikey.ErrInvalidExpiresAt),
+		errors.Is(err, apikey.ErrExpiresAtInPast),
+		errors.Is(err, user.ErrInvalidEmail),
+		errors.Is(err, user.ErrInvalidPassword),
+		errors.Is(err, user.ErrInvalidVerification):
+		return http.StatusBadRequest
+	case errors.Is(err, user.ErrInvalidCredentials):
+		return http.StatusUnauthorized
+	case errors.Is(err, todo.ErrForbidden),
+		errors.Is(err, user.ErrEmailNotVerified):
+		return http.StatusForbidden
+	case errors.Is(err, todo.ErrTodoIsCompleted),
+		errors.Is(err, todo.ErrTodoIsNotCompleted),
+		errors.Is(err, todo.ErrTodoIsNotDeleted),
+		errors.Is(err, todo.ErrTodoIsArchived),
+		errors.Is(err, todo.ErrTodoIsNotArchived),
+		errors.Is(err, todo.ErrTodoIsOpen),
+		errors.Is(err, user.ErrEmailTaken):
+		return http.StatusConflict
+	case errors.Is(err, todo.ErrTodoNotFound),
+		errors.Is(err, todo.ErrShareNotFound),
+		errors.Is(err, apikey.ErrKeyNotFound),
+		errors.Is(err, user.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, todo.ErrVersionMismatch):
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusInternalServerError
+	}
 }
@@ -12,9 +12,12 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 
+	"todo-app/config"
+	"todo-app/internal/http/auth"
 	"todo-app/todo"
 	"todo-app/todo/dtos"
 	"todo-app/todo/mocks"
@@ -23,15 +26,44 @@ import (
 
 var (
 	ctxMatcher   = gomock.AssignableToTypeOf(reflect.TypeOf((*context.Context)(nil)).Elem())
-	emailMatcher = gomock.Eq("test@example.com")
+	emailMatcher = gomock.Eq(todo.Owner("test@example.com"))
 	idMatcher    = gomock.Eq("279f4a4e-48dc-4569-83df-8b30ce488599")
 )
 
+const testSecret = "secret"
+
+func newAuthenticator(t *testing.T) *auth.Authenticator {
+	authenticator, err := auth.NewAuthenticator(config.Config{JWTSecret: testSecret}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return authenticator
+}
+
+// newController serves the legacy routes, so the email is taken from the
+// path.
+func newController(t *testing.T, service todo.Service) *TodosController {
+	return NewTodosController(service, newAuthenticator(t), config.Config{LegacyRoutes: true})
+}
+
+func signToken(t *testing.T, secret string, subject string) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   subject,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
 func TestNewTodosController(t *testing.T) {
 	t.Run("should create new controller", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		service := mocks.NewMockService(ctrl)
-		controller := NewTodosController(service)
+		controller := NewTodosController(service, newAuthenticator(t), config.Config{})
 		assert.NotNil(t, controller)
 	})
 }
@@ -41,11 +73,134 @@ func TestTodosController_CreateRoutes(t *testing.T) {
 	t.Run("should create new routes", func(t *testing.T) {
 		engine := gin.Default()
 		group := engine.Group("/api")
-		controller := NewTodosController(nil)
+		controller := NewTodosController(nil, newAuthenticator(t), config.Config{})
+		controller.CreateRoutes(group)
+
+		routes := engine.Routes()
+		assert.Len(t, routes, 17)
+	})
+
+	t.Run("should create the legacy routes too if they're enabled", func(t *testing.T) {
+		engine := gin.Default()
+		group := engine.Group("/api")
+		controller := NewTodosController(nil, newAuthenticator(t), config.Config{LegacyRoutes: true})
 		controller.CreateRoutes(group)
 
 		routes := engine.Routes()
-		assert.Len(t, routes, 5)
+		assert.Len(t, routes, 34)
+	})
+}
+
+func TestTodosController_Owner(t *testing.T) {
+	t.Run("should return 400 if the email isn't valid", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+
+		r := gin.Default()
+		newController(t, service).CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/todos/not-an-email", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{"error":"the owner must be a valid email address"}`, w.Body.String())
+	})
+
+	t.Run("should normalize the email", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().GetByID(ctxMatcher, emailMatcher, idMatcher).Return(models.Todo{ID: "id"}, nil)
+
+		r := gin.Default()
+		newController(t, service).CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/todos/Test@Example.com/279f4a4e-48dc-4569-83df-8b30ce488599", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("should return 400 if the subject of the token isn't an email", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+
+		r := gin.Default()
+		NewTodosController(service, newAuthenticator(t), config.Config{}).CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/todos/me", nil)
+		req.Header.Set("Authorization", "Bearer "+signToken(t, testSecret, "user-1"))
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestTodosController_Authentication(t *testing.T) {
+	t.Run("should return 401 without a token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+
+		r := gin.Default()
+		controller := NewTodosController(service, newAuthenticator(t), config.Config{})
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/todos/me", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+	})
+
+	t.Run("should return 401 if the token is invalid", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+
+		r := gin.Default()
+		controller := NewTodosController(service, newAuthenticator(t), config.Config{})
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/todos/me", nil)
+		req.Header.Set("Authorization", "Bearer "+signToken(t, "other-secret", "test@example.com"))
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("should serve the todos of the subject of the token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().GetByID(ctxMatcher, emailMatcher, idMatcher).Return(models.Todo{ID: "id"}, nil)
+
+		r := gin.Default()
+		controller := NewTodosController(service, newAuthenticator(t), config.Config{})
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/todos/me/279f4a4e-48dc-4569-83df-8b30ce488599", nil)
+		req.Header.Set("Authorization", "Bearer "+signToken(t, testSecret, "test@example.com"))
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("should not serve the legacy routes unless they're enabled", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+
+		r := gin.Default()
+		controller := NewTodosController(service, newAuthenticator(t), config.Config{})
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/todos/test@example.com", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
 	})
 }
 
@@ -69,7 +224,7 @@ func TestTodosController_Create(t *testing.T) {
 
 		r := gin.Default()
 
-		controller := NewTodosController(service)
+		controller := newController(t, service)
 		controller.CreateRoutes(r.Group("/api"))
 
 		w := httptest.NewRecorder()
@@ -86,7 +241,7 @@ func TestTodosController_Create(t *testing.T) {
 
 		r := gin.Default()
 
-		controller := NewTodosController(service)
+		controller := newController(t, service)
 		controller.CreateRoutes(r.Group("/api"))
 
 		w := httptest.NewRecorder()
@@ -103,7 +258,7 @@ func TestTodosController_Create(t *testing.T) {
 
 		r := gin.Default()
 
-		controller := NewTodosController(service)
+		controller := newController(t, service)
 		controller.CreateRoutes(r.Group("/api"))
 
 		w := httptest.NewRecorder()
@@ -118,10 +273,10 @@ func TestTodosController_GetAll(t *testing.T) {
 	t.Run("should return the service error", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		service := mocks.NewMockService(ctrl)
-		service.EXPECT().GetAll(ctxMatcher, emailMatcher).Return(nil, fmt.Errorf("error"))
+		service.EXPECT().GetAll(ctxMatcher, emailMatcher, gomock.Any(), gomock.Any()).Return(todo.Page{}, fmt.Errorf("error"))
 
 		r := gin.Default()
-		controller := NewTodosController(service)
+		controller := newController(t, service)
 		controller.CreateRoutes(r.Group("/api"))
 
 		w := httptest.NewRecorder()
@@ -131,20 +286,89 @@ func TestTodosController_GetAll(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, w.Code)
 	})
 
-	t.Run("should return 200", func(t *testing.T) {
+	t.Run("should return 400 if the limit is not a number", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		service := mocks.NewMockService(ctrl)
-		service.EXPECT().GetAll(ctxMatcher, emailMatcher).Return([]models.Todo{{ID: "279f4a4e-48dc-4569-83df-8b30ce488599"}}, nil)
 
 		r := gin.Default()
-		controller := NewTodosController(service)
+		controller := newController(t, service)
 		controller.CreateRoutes(r.Group("/api"))
 
 		w := httptest.NewRecorder()
-		req, _ := http.NewRequest(http.MethodGet, "/api/todos/test@example.com", nil)
+		req, _ := http.NewRequest(http.MethodGet, "/api/todos/test@example.com?limit=ten", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("should return 400 if a date filter can't be parsed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/todos/test@example.com?due_before=tomorrow", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("should pass the filters to the service", func(t *testing.T) {
+		completed := false
+		dueBefore := time.Date(2024, time.March, 8, 0, 0, 0, 0, time.UTC)
+		startAfter := time.Date(2024, time.March, 1, 9, 30, 0, 0, time.UTC)
+		filter := todo.TodoFilter{
+			Completed:       &completed,
+			DueBefore:       &dueBefore,
+			StartAfter:      &startAfter,
+			Overdue:         true,
+			IncludeArchived: true,
+		}
+
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().
+			GetAll(ctxMatcher, emailMatcher, gomock.Eq(filter), gomock.Any()).
+			Return(todo.Page{}, nil)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/todos/test@example.com?completed=false&due_before=2024-03-08+00:00:00&start_after=2024-03-01+09:30:00&overdue=true&include_archived=true", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("should return 200 with the next cursor", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().
+			GetAll(ctxMatcher, emailMatcher, gomock.Eq(todo.TodoFilter{}), gomock.Eq(todo.PageRequest{Limit: 1, Cursor: "cursor", Sort: todo.SortByDueDate, Order: todo.Descending})).
+			Return(todo.Page{Todos: []models.Todo{{ID: "279f4a4e-48dc-4569-83df-8b30ce488599"}}, NextCursor: "next"}, nil)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/todos/test@example.com?limit=1&cursor=cursor&sort=due_date&order=desc", nil)
 		r.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data       []models.Todo `json:"data"`
+			NextCursor string        `json:"next_cursor"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Len(t, response.Data, 1)
+		assert.Equal(t, "next", response.NextCursor)
 	})
 }
 
@@ -155,7 +379,7 @@ func TestTodosController_GetByID(t *testing.T) {
 		service.EXPECT().GetByID(ctxMatcher, emailMatcher, idMatcher).Return(models.Todo{}, fmt.Errorf("error"))
 
 		r := gin.Default()
-		controller := NewTodosController(service)
+		controller := newController(t, service)
 		controller.CreateRoutes(r.Group("/api"))
 
 		w := httptest.NewRecorder()
@@ -168,10 +392,10 @@ func TestTodosController_GetByID(t *testing.T) {
 	t.Run("should return 200", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		service := mocks.NewMockService(ctrl)
-		service.EXPECT().GetByID(ctxMatcher, emailMatcher, idMatcher).Return(models.Todo{ID: "id"}, nil)
+		service.EXPECT().GetByID(ctxMatcher, emailMatcher, idMatcher).Return(models.Todo{ID: "id", Version: 3}, nil)
 
 		r := gin.Default()
-		controller := NewTodosController(service)
+		controller := newController(t, service)
 		controller.CreateRoutes(r.Group("/api"))
 
 		w := httptest.NewRecorder()
@@ -179,6 +403,7 @@ func TestTodosController_GetByID(t *testing.T) {
 		r.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"3"`, w.Header().Get("ETag"))
 	})
 }
 
@@ -194,7 +419,7 @@ func TestTodosController_Update(t *testing.T) {
 		service := mocks.NewMockService(ctrl)
 
 		r := gin.Default()
-		controller := NewTodosController(service)
+		controller := newController(t, service)
 		controller.CreateRoutes(r.Group("/api"))
 
 		w := httptest.NewRecorder()
@@ -207,10 +432,10 @@ func TestTodosController_Update(t *testing.T) {
 	t.Run("should return the service error", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		service := mocks.NewMockService(ctrl)
-		service.EXPECT().Update(ctxMatcher, emailMatcher, idMatcher, dtoMatcher).Return(models.Todo{}, fmt.Errorf("error"))
+		service.EXPECT().Update(ctxMatcher, emailMatcher, idMatcher, gomock.Eq(int64(0)), dtoMatcher).Return(models.Todo{}, fmt.Errorf("error"))
 
 		r := gin.Default()
-		controller := NewTodosController(service)
+		controller := newController(t, service)
 		controller.CreateRoutes(r.Group("/api"))
 
 		w := httptest.NewRecorder()
@@ -223,28 +448,134 @@ func TestTodosController_Update(t *testing.T) {
 	t.Run("should return 200", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		service := mocks.NewMockService(ctrl)
-		service.EXPECT().Update(ctxMatcher, emailMatcher, idMatcher, dtoMatcher).Return(models.Todo{}, nil)
+		service.EXPECT().Update(ctxMatcher, emailMatcher, idMatcher, gomock.Eq(int64(0)), dtoMatcher).Return(models.Todo{}, nil)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, "/api/todos/test@example.com/279f4a4e-48dc-4569-83df-8b30ce488599", bytes.NewReader(dto))
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("should pass the If-Match version and return the new ETag", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Update(ctxMatcher, emailMatcher, idMatcher, gomock.Eq(int64(3)), dtoMatcher).Return(models.Todo{Version: 4}, nil)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, "/api/todos/test@example.com/279f4a4e-48dc-4569-83df-8b30ce488599", bytes.NewReader(dto))
+		req.Header.Set("If-Match", `"3"`)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+	})
+
+	t.Run("should return 412 if the version doesn't match", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Update(ctxMatcher, emailMatcher, idMatcher, gomock.Eq(int64(2)), dtoMatcher).Return(models.Todo{}, todo.ErrVersionMismatch)
 
 		r := gin.Default()
-		controller := NewTodosController(service)
+		controller := newController(t, service)
 		controller.CreateRoutes(r.Group("/api"))
 
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest(http.MethodPut, "/api/todos/test@example.com/279f4a4e-48dc-4569-83df-8b30ce488599", bytes.NewReader(dto))
+		req.Header.Set("If-Match", `"2"`)
 		r.ServeHTTP(w, req)
 
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	})
+}
+
+func TestTodosController_Patch(t *testing.T) {
+	newRequest := func(contentType, body string) *http.Request {
+		req, _ := http.NewRequest(http.MethodPatch, "/api/todos/test@example.com/279f4a4e-48dc-4569-83df-8b30ce488599", bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", contentType)
+		return req
+	}
+
+	t.Run("should return 415 if the body is not a merge patch", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest("application/json", `{"name":"name"}`))
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	})
+
+	t.Run("should return 400 if the patch is not an object", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest(mergePatchContentType, `["name"]`))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("should pass the present and removed fields to the service", func(t *testing.T) {
+		expected := dtos.PatchTodo{
+			Name:        dtos.PatchField[string]{Set: true, Value: "name"},
+			Description: dtos.PatchField[string]{Set: true, Null: true},
+		}
+
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Patch(ctxMatcher, emailMatcher, idMatcher, gomock.Eq(int64(0)), gomock.Eq(expected)).Return(models.Todo{}, nil)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest(mergePatchContentType+"; charset=utf-8", `{"name":"name","description":null}`))
+
 		assert.Equal(t, http.StatusOK, w.Code)
 	})
+
+	t.Run("should return the service error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Patch(ctxMatcher, emailMatcher, idMatcher, gomock.Eq(int64(0)), gomock.Any()).Return(models.Todo{}, todo.ErrTodoNotFound)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest(mergePatchContentType, `{}`))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
 }
 
 func TestTodosController_Delete(t *testing.T) {
 	t.Run("should return the service error", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		service := mocks.NewMockService(ctrl)
-		service.EXPECT().Delete(ctxMatcher, emailMatcher, idMatcher).Return(fmt.Errorf("error"))
+		service.EXPECT().Delete(ctxMatcher, emailMatcher, idMatcher, gomock.Eq(int64(0))).Return(fmt.Errorf("error"))
 
 		r := gin.Default()
-		controller := NewTodosController(service)
+		controller := newController(t, service)
 		controller.CreateRoutes(r.Group("/api"))
 
 		w := httptest.NewRecorder()
@@ -257,10 +588,10 @@ func TestTodosController_Delete(t *testing.T) {
 	t.Run("should return 204", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		service := mocks.NewMockService(ctrl)
-		service.EXPECT().Delete(ctxMatcher, emailMatcher, idMatcher).Return(nil)
+		service.EXPECT().Delete(ctxMatcher, emailMatcher, idMatcher, gomock.Eq(int64(0))).Return(nil)
 
 		r := gin.Default()
-		controller := NewTodosController(service)
+		controller := newController(t, service)
 		controller.CreateRoutes(r.Group("/api"))
 
 		w := httptest.NewRecorder()
@@ -269,26 +600,572 @@ func TestTodosController_Delete(t *testing.T) {
 
 		assert.Equal(t, http.StatusNoContent, w.Code)
 	})
-}
 
-func Test_GetStatusCode(t *testing.T) {
-	t.Run("should return 400 for user errors", func(t *testing.T) {
-		userErrors := []error{
-			todo.ErrInvalidID,
-			todo.ErrInvalidDueDate,
-			todo.ErrInvalidStartDate,
-			todo.ErrStartDateMustBeGTDueDate,
+	t.Run("should return 412 if If-Match isn't a version", func(t *testing.T) {
+		for _, header := range []string{`W/"1"`, "1", `"one"`} {
+			ctrl := gomock.NewController(t)
+			service := mocks.NewMockService(ctrl)
+
+			r := gin.Default()
+			controller := newController(t, service)
+			controller.CreateRoutes(r.Group("/api"))
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodDelete, "/api/todos/test@example.com/279f4a4e-48dc-4569-83df-8b30ce488599", nil)
+			req.Header.Set("If-Match", header)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusPreconditionFailed, w.Code, header)
 		}
+	})
 
-		for _, err := range userErrors {
-			code := getStatusCode(err)
-			assert.Equal(t, http.StatusBadRequest, code)
+	t.Run("should match the current version against a list of tags", func(t *testing.T) {
+		for header, code := range map[string]int{
+			`"1", "3"`:        http.StatusNoContent,
+			`W/"3", "1", "2"`: http.StatusPreconditionFailed,
+		} {
+			ctrl := gomock.NewController(t)
+			service := mocks.NewMockService(ctrl)
+			service.EXPECT().GetByID(ctxMatcher, emailMatcher, idMatcher).Return(models.Todo{Version: 3}, nil)
+			if code == http.StatusNoContent {
+				service.EXPECT().Delete(ctxMatcher, emailMatcher, idMatcher, gomock.Eq(int64(3))).Return(nil)
+			}
+
+			r := gin.Default()
+			controller := newController(t, service)
+			controller.CreateRoutes(r.Group("/api"))
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodDelete, "/api/todos/test@example.com/279f4a4e-48dc-4569-83df-8b30ce488599", nil)
+			req.Header.Set("If-Match", header)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, code, w.Code, header)
 		}
 	})
 
-	t.Run("should return 409", func(t *testing.T) {
-		code := getStatusCode(todo.ErrTodoIsCompleted)
-		assert.Equal(t, http.StatusConflict, code)
+	t.Run("should use the only version of a list of tags", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Delete(ctxMatcher, emailMatcher, idMatcher, gomock.Eq(int64(2))).Return(nil)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/api/todos/test@example.com/279f4a4e-48dc-4569-83df-8b30ce488599", nil)
+		req.Header.Set("If-Match", `W/"1", "2"`)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("should accept any version with If-Match *", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Delete(ctxMatcher, emailMatcher, idMatcher, gomock.Eq(int64(0))).Return(nil)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/api/todos/test@example.com/279f4a4e-48dc-4569-83df-8b30ce488599", nil)
+		req.Header.Set("If-Match", "*")
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+}
+
+func TestTodosController_Complete(t *testing.T) {
+	t.Run("should return the service error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Complete(ctxMatcher, emailMatcher, idMatcher).Return(models.Todo{}, todo.ErrTodoIsCompleted)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/todos/test@example.com/279f4a4e-48dc-4569-83df-8b30ce488599/complete", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("should return 200", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Complete(ctxMatcher, emailMatcher, idMatcher).Return(models.Todo{ID: "id", Completed: true}, nil)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/todos/test@example.com/279f4a4e-48dc-4569-83df-8b30ce488599/complete", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestTodosController_Reopen(t *testing.T) {
+	t.Run("should return the service error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Reopen(ctxMatcher, emailMatcher, idMatcher).Return(models.Todo{}, todo.ErrTodoIsNotCompleted)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/todos/test@example.com/279f4a4e-48dc-4569-83df-8b30ce488599/reopen", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("should return 200", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Reopen(ctxMatcher, emailMatcher, idMatcher).Return(models.Todo{ID: "id"}, nil)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/todos/test@example.com/279f4a4e-48dc-4569-83df-8b30ce488599/reopen", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestTodosController_Trash(t *testing.T) {
+	t.Run("should return the service error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Trash(ctxMatcher, emailMatcher, gomock.Any()).Return(todo.Page{}, todo.ErrInvalidLimit)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/todos/test@example.com/trash", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("should return 200 with the next cursor", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().
+			Trash(ctxMatcher, emailMatcher, gomock.Eq(todo.PageRequest{Limit: 1, Cursor: "cursor", Sort: todo.SortByName, Order: todo.Descending})).
+			Return(todo.Page{Todos: []models.Todo{{ID: "279f4a4e-48dc-4569-83df-8b30ce488599"}}, NextCursor: "next"}, nil)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/todos/test@example.com/trash?limit=1&cursor=cursor&sort=name&order=desc", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data       []models.Todo `json:"data"`
+			NextCursor string        `json:"next_cursor"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Len(t, response.Data, 1)
+		assert.Equal(t, "next", response.NextCursor)
+	})
+}
+
+func TestTodosController_Restore(t *testing.T) {
+	t.Run("should return the service error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Restore(ctxMatcher, emailMatcher, idMatcher).Return(models.Todo{}, todo.ErrTodoIsNotDeleted)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/todos/test@example.com/279f4a4e-48dc-4569-83df-8b30ce488599/restore", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("should return 200 with the ETag", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Restore(ctxMatcher, emailMatcher, idMatcher).Return(models.Todo{ID: "id", Version: 4}, nil)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/todos/test@example.com/279f4a4e-48dc-4569-83df-8b30ce488599/restore", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+	})
+}
+
+func TestTodosController_Archive(t *testing.T) {
+	t.Run("should return the service error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Archive(ctxMatcher, emailMatcher, idMatcher).Return(models.Todo{}, todo.ErrTodoIsOpen)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/todos/test@example.com/279f4a4e-48dc-4569-83df-8b30ce488599/archive", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("should return 200 with the ETag", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Archive(ctxMatcher, emailMatcher, idMatcher).Return(models.Todo{ID: "id", Version: 3}, nil)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/todos/test@example.com/279f4a4e-48dc-4569-83df-8b30ce488599/archive", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+	})
+}
+
+func TestTodosController_Unarchive(t *testing.T) {
+	t.Run("should return the service error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Unarchive(ctxMatcher, emailMatcher, idMatcher).Return(models.Todo{}, todo.ErrTodoIsNotArchived)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/todos/test@example.com/279f4a4e-48dc-4569-83df-8b30ce488599/unarchive", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("should return 200", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Unarchive(ctxMatcher, emailMatcher, idMatcher).Return(models.Todo{ID: "id", Version: 4}, nil)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/todos/test@example.com/279f4a4e-48dc-4569-83df-8b30ce488599/unarchive", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestTodosController_Search(t *testing.T) {
+	t.Run("should return the service error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Search(ctxMatcher, emailMatcher, gomock.Eq(""), gomock.Eq(0)).Return(nil, todo.ErrInvalidQuery)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/todos/test@example.com/search", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("should return 200", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().
+			Search(ctxMatcher, emailMatcher, gomock.Eq("buy milk"), gomock.Eq(5)).
+			Return([]models.Todo{{ID: "279f4a4e-48dc-4569-83df-8b30ce488599"}}, nil)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/todos/test@example.com/search?q=buy+milk&limit=5", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestTodosController_Batch(t *testing.T) {
+	t.Run("should return 400 if the request is invalid", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/todos/test@example.com/batch", bytes.NewReader([]byte(`[]`)))
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("should return the service error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Batch(ctxMatcher, emailMatcher, gomock.Len(0)).Return(nil, todo.ErrInvalidBatch)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/todos/test@example.com/batch", bytes.NewReader([]byte(`{"operations":[]}`)))
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("should return 200 with the status of every operation", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Batch(ctxMatcher, emailMatcher, gomock.Len(4)).Return([]todo.BatchResult{
+			{Todo: models.Todo{ID: "created"}},
+			{Todo: models.Todo{ID: "updated"}},
+			{},
+			{Err: todo.ErrVersionMismatch},
+		}, nil)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		body := `{"operations":[{"op":"create"},{"op":"update"},{"op":"delete"},{"op":"delete"}]}`
+		req, _ := http.NewRequest(http.MethodPost, "/api/todos/test@example.com/batch", bytes.NewReader([]byte(body)))
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data []struct {
+				Status int          `json:"status"`
+				Data   *models.Todo `json:"data"`
+				Error  string       `json:"error"`
+			} `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		if assert.Len(t, response.Data, 4) {
+			assert.Equal(t, http.StatusCreated, response.Data[0].Status)
+			assert.Equal(t, "created", response.Data[0].Data.ID)
+			assert.Equal(t, http.StatusOK, response.Data[1].Status)
+			assert.Equal(t, "updated", response.Data[1].Data.ID)
+			assert.Equal(t, http.StatusNoContent, response.Data[2].Status)
+			assert.Nil(t, response.Data[2].Data)
+			assert.Equal(t, http.StatusPreconditionFailed, response.Data[3].Status)
+			assert.Equal(t, todo.ErrVersionMismatch.Error(), response.Data[3].Error)
+		}
+	})
+}
+
+func TestTodosController_Share(t *testing.T) {
+	granteeMatcher := gomock.Eq(todo.Owner("grantee@example.com"))
+
+	t.Run("should return 200 with the share", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.
+			EXPECT().
+			Share(ctxMatcher, emailMatcher, idMatcher, granteeMatcher, gomock.Eq(models.RoleEditor)).
+			Return(models.Share{TodoID: "279f4a4e-48dc-4569-83df-8b30ce488599", Owner: "test@example.com", Grantee: "grantee@example.com", Role: models.RoleEditor}, nil)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, "/api/todos/test@example.com/279f4a4e-48dc-4569-83df-8b30ce488599/shares/Grantee@Example.com", bytes.NewBufferString(`{"role":"editor"}`))
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Data models.Share `json:"data"`
+		}
+		if assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response)) {
+			assert.Equal(t, "grantee@example.com", response.Data.Grantee)
+			assert.Equal(t, models.RoleEditor, response.Data.Role)
+		}
+	})
+
+	t.Run("should return 400 if the grantee is not an email", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, "/api/todos/test@example.com/279f4a4e-48dc-4569-83df-8b30ce488599/shares/grantee", bytes.NewBufferString(`{"role":"editor"}`))
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("should return 403 if the todo isn't the user's", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Share(ctxMatcher, emailMatcher, idMatcher, granteeMatcher, gomock.Eq(models.RoleViewer)).Return(models.Share{}, todo.ErrForbidden)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, "/api/todos/test@example.com/279f4a4e-48dc-4569-83df-8b30ce488599/shares/grantee@example.com", bytes.NewBufferString(`{"role":"viewer"}`))
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestTodosController_Unshare(t *testing.T) {
+	for name, test := range map[string]struct {
+		err  error
+		code int
+	}{
+		"should return 204 if the share is revoked":    {nil, http.StatusNoContent},
+		"should return 404 if the share doesn't exist": {todo.ErrShareNotFound, http.StatusNotFound},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			service := mocks.NewMockService(ctrl)
+			service.EXPECT().Unshare(ctxMatcher, emailMatcher, idMatcher, gomock.Eq(todo.Owner("grantee@example.com"))).Return(test.err)
+
+			r := gin.Default()
+			controller := newController(t, service)
+			controller.CreateRoutes(r.Group("/api"))
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodDelete, "/api/todos/test@example.com/279f4a4e-48dc-4569-83df-8b30ce488599/shares/grantee@example.com", nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.code, w.Code)
+		})
+	}
+}
+
+func TestTodosController_GetShares(t *testing.T) {
+	t.Run("should return 200 with the shares of the todo", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().GetShares(ctxMatcher, emailMatcher, idMatcher).Return([]models.Share{{Grantee: "grantee@example.com"}}, nil)
+
+		r := gin.Default()
+		controller := newController(t, service)
+		controller.CreateRoutes(r.Group("/api"))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/todos/test@example.com/279f4a4e-48dc-4569-83df-8b30ce488599/shares", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "grantee@example.com")
+	})
+}
+
+func Test_GetStatusCode(t *testing.T) {
+	t.Run("should return 400 for user errors", func(t *testing.T) {
+		userErrors := []error{
+			todo.ErrInvalidOwner,
+			todo.ErrInvalidID,
+			todo.ErrInvalidDueDate,
+			todo.ErrInvalidStartDate,
+			todo.ErrStartDateMustBeGTDueDate,
+			todo.ErrInvalidPatch,
+			todo.ErrInvalidLimit,
+			todo.ErrInvalidCursor,
+			todo.ErrInvalidSort,
+			todo.ErrInvalidOrder,
+			todo.ErrInvalidFilter,
+			todo.ErrInvalidQuery,
+			todo.ErrInvalidBatch,
+			todo.ErrInvalidOperation,
+			todo.ErrInvalidRole,
+			todo.ErrInvalidGrantee,
+		}
+
+		for _, err := range userErrors {
+			code := getStatusCode(err)
+			assert.Equal(t, http.StatusBadRequest, code)
+		}
+	})
+
+	t.Run("should return 409", func(t *testing.T) {
+		code := getStatusCode(todo.ErrTodoIsCompleted)
+		assert.Equal(t, http.StatusConflict, code)
+
+		code = getStatusCode(todo.ErrTodoIsNotCompleted)
+		assert.Equal(t, http.StatusConflict, code)
+
+		code = getStatusCode(todo.ErrTodoIsNotDeleted)
+		assert.Equal(t, http.StatusConflict, code)
+
+		code = getStatusCode(todo.ErrTodoIsArchived)
+		assert.Equal(t, http.StatusConflict, code)
+
+		code = getStatusCode(todo.ErrTodoIsNotArchived)
+		assert.Equal(t, http.StatusConflict, code)
+
+		code = getStatusCode(todo.ErrTodoIsOpen)
+		assert.Equal(t, http.StatusConflict, code)
+	})
+
+	t.Run("should return 403 if the role granted doesn't allow it", func(t *testing.T) {
+		code := getStatusCode(todo.ErrForbidden)
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+
+	t.Run("should return 412 for a version mismatch", func(t *testing.T) {
+		code := getStatusCode(todo.ErrVersionMismatch)
+		assert.Equal(t, http.StatusPreconditionFailed, code)
 	})
 
 	t.Run("should return 500 for any other error", func(t *testing.T) {
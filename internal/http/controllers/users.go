@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"todo-app/internal/http/auth"
+	"todo-app/user"
+	"todo-app/user/dtos"
+)
+
+type UsersController struct {
+	service       user.Service
+	authenticator *auth.Authenticator
+}
+
+func NewUsersController(service user.Service, authenticator *auth.Authenticator) *UsersController {
+	return &UsersController{
+		service:       service,
+		authenticator: authenticator,
+	}
+}
+
+// CreateRoutes serves the registration, the verification of the email and
+// the login of the local accounts, and the change of the password of the
+// owner of the token.
+func (u *UsersController) CreateRoutes(base *gin.RouterGroup) {
+	group := base.Group("/users")
+	group.POST("", u.Register)
+	group.POST("/verify", u.Verify)
+	group.POST("/login", u.Login)
+	group.PUT("/me/password", u.authenticator.TokenMiddleware, resolveOwner, u.ChangePassword)
+}
+
+func (u *UsersController) Register(ctx *gin.Context) {
+	var dto dtos.Register
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	response, err := u.service.Register(ctx, dto)
+	if err != nil {
+		code := getStatusCode(err)
+		ctx.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": response})
+}
+
+func (u *UsersController) Verify(ctx *gin.Context) {
+	var dto dtos.Verify
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	if err := u.service.Verify(ctx, dto); err != nil {
+		code := getStatusCode(err)
+		ctx.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (u *UsersController) Login(ctx *gin.Context) {
+	var dto dtos.Login
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	response, err := u.service.Login(ctx, dto)
+	if err != nil {
+		code := getStatusCode(err)
+		ctx.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (u *UsersController) ChangePassword(ctx *gin.Context) {
+	var dto dtos.ChangePassword
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	email := owner(ctx).String()
+	if err := u.service.ChangePassword(ctx, email, dto); err != nil {
+		code := getStatusCode(err)
+		ctx.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
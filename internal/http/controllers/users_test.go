@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"todo-app/user"
+	"todo-app/user/dtos"
+	"todo-app/user/mocks"
+	"todo-app/user/models"
+)
+
+func newUsersEngine(t *testing.T, service user.Service) *gin.Engine {
+	r := gin.Default()
+	NewUsersController(service, newAuthenticator(t)).CreateRoutes(r.Group("/api"))
+	return r
+}
+
+func TestNewUsersController(t *testing.T) {
+	t.Run("should return a not nil instance", func(t *testing.T) {
+		controller := NewUsersController(nil, newAuthenticator(t))
+		assert.NotNil(t, controller)
+	})
+}
+
+func TestUsersController_CreateRoutes(t *testing.T) {
+	t.Run("should create new routes", func(t *testing.T) {
+		engine := gin.Default()
+		NewUsersController(nil, newAuthenticator(t)).CreateRoutes(engine.Group("/api"))
+		assert.Len(t, engine.Routes(), 4)
+	})
+}
+
+func TestUsersController_Register(t *testing.T) {
+	t.Run("should return 400 if the body is invalid", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		r := newUsersEngine(t, mocks.NewMockService(ctrl))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/users", bytes.NewBufferString("invalid"))
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("should return 409 if the email is taken", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Register(ctxMatcher, gomock.Eq(dtos.Register{Email: "test@example.com", Password: "password"})).Return(models.User{}, user.ErrEmailTaken)
+		r := newUsersEngine(t, service)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/users", bytes.NewBufferString(`{"email":"test@example.com","password":"password"}`))
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("should return 201 without the password hash", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Register(ctxMatcher, gomock.Any()).Return(models.User{Email: "test@example.com", PasswordHash: "hash"}, nil)
+		r := newUsersEngine(t, service)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/users", bytes.NewBufferString(`{"email":"test@example.com","password":"password"}`))
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.NotContains(t, w.Body.String(), "hash")
+	})
+}
+
+func TestUsersController_Verify(t *testing.T) {
+	t.Run("should return 400 if the token is invalid", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Verify(ctxMatcher, gomock.Eq(dtos.Verify{Email: "test@example.com", Token: "token"})).Return(user.ErrInvalidVerification)
+		r := newUsersEngine(t, service)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/users/verify", bytes.NewBufferString(`{"email":"test@example.com","token":"token"}`))
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("should return 204 if the email is verified", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Verify(ctxMatcher, gomock.Any()).Return(nil)
+		r := newUsersEngine(t, service)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/users/verify", bytes.NewBufferString(`{"email":"test@example.com","token":"token"}`))
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+}
+
+func TestUsersController_Login(t *testing.T) {
+	t.Run("should return 401 for invalid credentials", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().Login(ctxMatcher, gomock.Any()).Return(models.Session{}, user.ErrInvalidCredentials)
+		r := newUsersEngine(t, service)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/users/login", bytes.NewBufferString(`{"email":"test@example.com","password":"wrong"}`))
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("should return 200 with the token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.
+			EXPECT().
+			Login(ctxMatcher, gomock.Eq(dtos.Login{Email: "test@example.com", Password: "password"})).
+			Return(models.Session{Token: "token", ExpiresAt: time.Now().Add(time.Hour)}, nil)
+		r := newUsersEngine(t, service)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/users/login", bytes.NewBufferString(`{"email":"test@example.com","password":"password"}`))
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"token":"token"`)
+	})
+}
+
+func TestUsersController_ChangePassword(t *testing.T) {
+	body := `{"current_password":"password","new_password":"new password"}`
+
+	t.Run("should return 401 without a token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		r := newUsersEngine(t, mocks.NewMockService(ctrl))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, "/api/users/me/password", bytes.NewBufferString(body))
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("should change the password of the owner of the token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.
+			EXPECT().
+			ChangePassword(ctxMatcher, gomock.Eq("test@example.com"), gomock.Eq(dtos.ChangePassword{CurrentPassword: "password", NewPassword: "new password"})).
+			Return(nil)
+		r := newUsersEngine(t, service)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, "/api/users/me/password", bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+signToken(t, testSecret, "test@example.com"))
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("should return 401 if the current password is wrong", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := mocks.NewMockService(ctrl)
+		service.EXPECT().ChangePassword(ctxMatcher, gomock.Eq("test@example.com"), gomock.Any()).Return(user.ErrInvalidCredentials)
+		r := newUsersEngine(t, service)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, "/api/users/me/password", bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+signToken(t, testSecret, "test@example.com"))
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
@@ -0,0 +1,92 @@
+package http
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"todo-app/config"
+	"todo-app/internal/health"
+)
+
+// checkReport leaves the error of the check out, it's logged instead since
+// it may hold addresses and credentials.
+type checkReport struct {
+	Status   string `json:"status"`
+	Duration string `json:"duration"`
+}
+
+type healthHandler struct {
+	checkers []health.Checker
+	timeout  time.Duration
+}
+
+// StartHealthRoutes registers /healthz, which answers as long as the process
+// is serving, and /readyz, which runs every checker concurrently. The checks
+// time out after the default timeout if none is configured.
+func StartHealthRoutes(engine *gin.Engine, checkers []health.Checker, configs config.Config) {
+	handler := &healthHandler{
+		checkers: checkers,
+		timeout:  configs.HealthCheckTimeout,
+	}
+	if handler.timeout == 0 {
+		handler.timeout = config.Default().HealthCheckTimeout
+	}
+
+	engine.GET("/healthz", handler.liveness)
+	engine.GET("/readyz", handler.readiness)
+}
+
+func (h *healthHandler) liveness(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (h *healthHandler) readiness(ctx *gin.Context) {
+	reports := make(map[string]checkReport, len(h.checkers))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, checker := range h.checkers {
+		wg.Add(1)
+		go func(checker health.Checker) {
+			defer wg.Done()
+			report := h.check(ctx.Request.Context(), checker)
+
+			mu.Lock()
+			defer mu.Unlock()
+			reports[checker.Name()] = report
+		}(checker)
+	}
+	wg.Wait()
+
+	status, code := "ok", http.StatusOK
+	for _, report := range reports {
+		if report.Status != "ok" {
+			status, code = "unavailable", http.StatusServiceUnavailable
+		}
+	}
+
+	ctx.JSON(code, gin.H{"status": status, "checks": reports})
+}
+
+func (h *healthHandler) check(ctx context.Context, checker health.Checker) checkReport {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := checker.Check(ctx)
+	report := checkReport{
+		Status:   "ok",
+		Duration: time.Since(start).String(),
+	}
+
+	if err != nil {
+		log.Printf("readiness check %s failed: %v", checker.Name(), err)
+		report.Status = "unavailable"
+	}
+
+	return report
+}
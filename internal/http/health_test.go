@@ -0,0 +1,123 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"todo-app/config"
+	"todo-app/internal/health"
+	"todo-app/internal/health/mocks"
+)
+
+type healthResponse struct {
+	Status string                    `json:"status"`
+	Checks map[string]map[string]any `json:"checks"`
+}
+
+func TestStartHealthRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newEngine := func(checkers ...health.Checker) *gin.Engine {
+		configs := config.Default()
+		configs.HealthCheckTimeout = 50 * time.Millisecond
+
+		engine := gin.New()
+		StartHealthRoutes(engine, checkers, configs)
+		return engine
+	}
+
+	newChecker := func(ctrl *gomock.Controller, name string, check func(ctx context.Context) error) health.Checker {
+		checker := mocks.NewMockChecker(ctrl)
+		checker.EXPECT().Name().Return(name).AnyTimes()
+		checker.EXPECT().Check(gomock.Any()).DoAndReturn(check)
+		return checker
+	}
+
+	serve := func(engine *gin.Engine, path string) (int, healthResponse) {
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+		var response healthResponse
+		_ = json.Unmarshal(recorder.Body.Bytes(), &response)
+		return recorder.Code, response
+	}
+
+	t.Run("should report liveness without running the checks", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		checker := mocks.NewMockChecker(ctrl)
+		code, response := serve(newEngine(checker), "/healthz")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "ok", response.Status)
+	})
+
+	t.Run("should be ready when every check passes", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		ok := func(context.Context) error { return nil }
+
+		code, response := serve(newEngine(newChecker(ctrl, "redis", ok), newChecker(ctrl, "queue", ok)), "/readyz")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "ok", response.Status)
+		assert.Len(t, response.Checks, 2)
+		assert.Equal(t, "ok", response.Checks["redis"]["status"])
+	})
+
+	t.Run("should be unavailable when a check fails", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		failing := newChecker(ctrl, "redis", func(context.Context) error { return errors.New("connection refused") })
+		passing := newChecker(ctrl, "queue", func(context.Context) error { return nil })
+
+		code, response := serve(newEngine(failing, passing), "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "unavailable", response.Status)
+		assert.Equal(t, "unavailable", response.Checks["redis"]["status"])
+		assert.NotContains(t, response.Checks["redis"], "error")
+		assert.Equal(t, "ok", response.Checks["queue"]["status"])
+	})
+
+	t.Run("should time out a slow check", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		slow := newChecker(ctrl, "redis", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		code, response := serve(newEngine(slow), "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "unavailable", response.Checks["redis"]["status"])
+	})
+
+	t.Run("should time out the checks without a configured timeout", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		checker := newChecker(ctrl, "redis", func(ctx context.Context) error {
+			_, ok := ctx.Deadline()
+			assert.True(t, ok)
+			return nil
+		})
+
+		engine := gin.New()
+		configs := config.Default()
+		configs.HealthCheckTimeout = 0
+		StartHealthRoutes(engine, []health.Checker{checker}, configs)
+
+		code, _ := serve(engine, "/readyz")
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("should be ready without checkers", func(t *testing.T) {
+		code, response := serve(newEngine(), "/readyz")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "ok", response.Status)
+	})
+}
@@ -2,12 +2,19 @@ package http
 
 import (
 	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/fx"
 
 	"todo-app/config"
+	"todo-app/internal/health"
+	"todo-app/internal/http/auth"
 	"todo-app/internal/http/controllers"
+	"todo-app/user"
 )
 
 const (
@@ -20,23 +27,51 @@ var Module = fx.Module(
 	fx.Provide(
 		fx.Annotate(StartServer, fx.ResultTags(engineTag)),
 		fx.Annotate(StartRoutes, fx.ParamTags(engineTag, controllersTag)),
+		auth.NewAuthenticator,
+		func(authenticator *auth.Authenticator) user.TokenIssuer {
+			return authenticator
+		},
 		AsController(controllers.NewTodosController),
+		AsController(controllers.NewKeysController),
+		AsController(controllers.NewUsersController),
 	),
 	fx.Invoke(func(*gin.RouterGroup) {}),
+	fx.Invoke(fx.Annotate(StartHealthRoutes, fx.ParamTags(engineTag, health.CheckersTag))),
 )
 
+// StartServer binds the port when the app starts, so a port already in use
+// fails the startup, and drains the in-flight requests when it stops.
 func StartServer(configs config.Config, lc fx.Lifecycle) *gin.Engine {
 	engine := gin.Default()
+	server := &http.Server{
+		Addr:    configs.Port,
+		Handler: engine,
+	}
+
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
+			listener, err := net.Listen("tcp", server.Addr)
+			if err != nil {
+				return err
+			}
+
 			go func() {
-				if err := engine.Run(configs.Port); err != nil {
-					panic(err)
+				if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					log.Printf("http server stopped: %v", err)
 				}
 			}()
 
 			return nil
 		},
+		OnStop: func(ctx context.Context) error {
+			if configs.ShutdownTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, configs.ShutdownTimeout)
+				defer cancel()
+			}
+
+			return server.Shutdown(ctx)
+		},
 	})
 
 	return engine
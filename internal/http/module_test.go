@@ -1,17 +1,25 @@
 package http
 
 import (
-	"github.com/gin-gonic/gin"
+	"context"
+	"net"
+	"net/http"
 	"testing"
-	"todo-app/config"
+	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxtest"
 	"go.uber.org/mock/gomock"
 
+	"todo-app/apikey"
+	keymocks "todo-app/apikey/mocks"
+	"todo-app/config"
 	"todo-app/todo"
 	"todo-app/todo/mocks"
+	"todo-app/user"
+	usermocks "todo-app/user/mocks"
 )
 
 func TestAsController(t *testing.T) {
@@ -26,7 +34,7 @@ func TestModule(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		app := fxtest.New(
 			t,
-			fx.Supply(config.Config{}),
+			fx.Supply(config.Config{JWTSecret: "secret"}),
 			fx.Provide(
 				fx.Annotate(
 					func() todo.Service {
@@ -34,6 +42,18 @@ func TestModule(t *testing.T) {
 					},
 					fx.As(new(todo.Service)),
 				),
+				fx.Annotate(
+					func() apikey.Service {
+						return keymocks.NewMockService(ctrl)
+					},
+					fx.As(new(apikey.Service)),
+				),
+				fx.Annotate(
+					func() user.Service {
+						return usermocks.NewMockService(ctrl)
+					},
+					fx.As(new(user.Service)),
+				),
 				fx.Annotate(
 					func(engine *gin.Engine) bool {
 						return engine != nil
@@ -49,3 +69,63 @@ func TestModule(t *testing.T) {
 		defer app.RequireStart().RequireStop()
 	})
 }
+
+func TestStartServer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should fail to start if the port is in use", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		defer listener.Close()
+
+		lc := fxtest.NewLifecycle(t)
+		engine := StartServer(config.Config{Port: listener.Addr().String()}, lc)
+		assert.NotNil(t, engine)
+
+		err = lc.Start(context.TODO())
+		assert.Error(t, err)
+	})
+
+	t.Run("should drain the in-flight requests on stop", func(t *testing.T) {
+		address := freeAddress(t)
+		lc := fxtest.NewLifecycle(t)
+		engine := StartServer(config.Config{Port: address, ShutdownTimeout: 5 * time.Second}, lc)
+
+		started := make(chan struct{})
+		engine.GET("/slow", func(ctx *gin.Context) {
+			close(started)
+			time.Sleep(200 * time.Millisecond)
+			ctx.Status(http.StatusOK)
+		})
+
+		lc.RequireStart()
+
+		responses := make(chan int, 1)
+		go func() {
+			response, err := http.Get("http://" + address + "/slow")
+			if err != nil {
+				responses <- 0
+				return
+			}
+			defer response.Body.Close()
+			responses <- response.StatusCode
+		}()
+
+		<-started
+		lc.RequireStop()
+		assert.Equal(t, http.StatusOK, <-responses)
+
+		_, err := http.Get("http://" + address + "/slow")
+		assert.Error(t, err)
+	})
+}
+
+func freeAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	return listener.Addr().String()
+}
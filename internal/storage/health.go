@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+type RedisHealthChecker struct {
+	client redis.UniversalClient
+}
+
+func NewRedisHealthChecker(client redis.UniversalClient) *RedisHealthChecker {
+	return &RedisHealthChecker{
+		client: client,
+	}
+}
+
+func (r *RedisHealthChecker) Name() string {
+	return "redis"
+}
+
+func (r *RedisHealthChecker) Check(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+type SQLiteHealthChecker struct {
+	db *sql.DB
+}
+
+func NewSQLiteHealthChecker(db *sql.DB) *SQLiteHealthChecker {
+	return &SQLiteHealthChecker{
+		db: db,
+	}
+}
+
+func (s *SQLiteHealthChecker) Name() string {
+	return "sqlite"
+}
+
+func (s *SQLiteHealthChecker) Check(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+type PostgresHealthChecker struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresHealthChecker(pool *pgxpool.Pool) *PostgresHealthChecker {
+	return &PostgresHealthChecker{
+		pool: pool,
+	}
+}
+
+func (p *PostgresHealthChecker) Name() string {
+	return "postgres"
+}
+
+func (p *PostgresHealthChecker) Check(ctx context.Context) error {
+	return p.pool.Ping(ctx)
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisHealthChecker(t *testing.T) {
+	t.Run("should fail if redis is unreachable", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		address := listener.Addr().String()
+		assert.NoError(t, listener.Close())
+
+		client := redis.NewClient(&redis.Options{Addr: address, DialTimeout: time.Second})
+		defer client.Close()
+
+		checker := NewRedisHealthChecker(client)
+		assert.Equal(t, "redis", checker.Name())
+		assert.Error(t, checker.Check(context.TODO()))
+	})
+}
+
+func TestSQLiteHealthChecker(t *testing.T) {
+	t.Run("should pass while the database is open", func(t *testing.T) {
+		db, err := OpenSQLite(filepath.Join(t.TempDir(), "todos.db"))
+		assert.NoError(t, err)
+
+		checker := NewSQLiteHealthChecker(db)
+		assert.Equal(t, "sqlite", checker.Name())
+		assert.NoError(t, checker.Check(context.TODO()))
+
+		assert.NoError(t, db.Close())
+		assert.Error(t, checker.Check(context.TODO()))
+	})
+}
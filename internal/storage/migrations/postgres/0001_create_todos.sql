@@ -0,0 +1,12 @@
+CREATE TABLE todos (
+	id UUID PRIMARY KEY,
+	owner TEXT NOT NULL,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL,
+	start_date TIMESTAMPTZ NOT NULL,
+	due_date TIMESTAMPTZ NOT NULL,
+	completed BOOLEAN NOT NULL DEFAULT FALSE,
+	completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX todos_owner_due_date_completed_idx ON todos (owner, due_date, completed);
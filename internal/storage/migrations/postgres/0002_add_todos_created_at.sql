@@ -0,0 +1,3 @@
+ALTER TABLE todos ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX todos_owner_created_at_idx ON todos (owner, created_at, id);
@@ -0,0 +1,3 @@
+ALTER TABLE todos ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+UPDATE todos SET updated_at = created_at;
@@ -0,0 +1,3 @@
+ALTER TABLE todos ADD COLUMN archived_at TIMESTAMPTZ;
+
+CREATE INDEX todos_completed_at_idx ON todos (completed_at) WHERE archived_at IS NULL AND deleted_at IS NULL;
@@ -0,0 +1,7 @@
+-- Indexes the words of the todos written before the search index existed,
+-- split like the repositories do on anything but letters and digits.
+INSERT INTO todo_terms (todo_id, owner, term)
+SELECT DISTINCT todos.id, todos.owner, words.term
+FROM todos, regexp_split_to_table(lower(todos.name || ' ' || todos.description), '[^[:alnum:]]+') AS words (term)
+WHERE words.term <> ''
+	AND NOT EXISTS (SELECT 1 FROM todo_terms WHERE todo_terms.todo_id = todos.id);
@@ -0,0 +1,11 @@
+CREATE TABLE api_keys (
+	id UUID PRIMARY KEY,
+	owner TEXT NOT NULL,
+	name TEXT NOT NULL,
+	scope TEXT NOT NULL,
+	hash TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	expires_at TIMESTAMPTZ
+);
+
+CREATE INDEX api_keys_owner_created_at_idx ON api_keys (owner, created_at, id);
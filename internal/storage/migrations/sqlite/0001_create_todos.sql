@@ -0,0 +1,12 @@
+CREATE TABLE todos (
+	id TEXT PRIMARY KEY,
+	owner TEXT NOT NULL,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL,
+	start_date TIMESTAMP NOT NULL,
+	due_date TIMESTAMP NOT NULL,
+	completed BOOLEAN NOT NULL DEFAULT FALSE,
+	completed_at TIMESTAMP
+);
+
+CREATE INDEX todos_owner_idx ON todos (owner);
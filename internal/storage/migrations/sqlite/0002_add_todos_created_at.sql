@@ -0,0 +1,3 @@
+ALTER TABLE todos ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT '1970-01-01 00:00:00+00:00';
+
+CREATE INDEX todos_owner_created_at_idx ON todos (owner, created_at, id);
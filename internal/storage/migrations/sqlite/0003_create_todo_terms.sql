@@ -0,0 +1,8 @@
+CREATE TABLE todo_terms (
+	todo_id TEXT NOT NULL REFERENCES todos (id) ON DELETE CASCADE,
+	owner TEXT NOT NULL,
+	term TEXT NOT NULL,
+	PRIMARY KEY (todo_id, term)
+);
+
+CREATE INDEX todo_terms_owner_term_idx ON todo_terms (owner, term);
@@ -0,0 +1,3 @@
+ALTER TABLE todos ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT '1970-01-01 00:00:00+00:00';
+
+UPDATE todos SET updated_at = created_at;
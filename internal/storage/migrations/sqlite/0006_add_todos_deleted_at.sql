@@ -0,0 +1,3 @@
+ALTER TABLE todos ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX todos_deleted_at_idx ON todos (deleted_at);
@@ -0,0 +1,3 @@
+ALTER TABLE todos ADD COLUMN archived_at TIMESTAMP;
+
+CREATE INDEX todos_completed_at_idx ON todos (completed_at) WHERE archived_at IS NULL;
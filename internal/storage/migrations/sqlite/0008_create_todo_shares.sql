@@ -0,0 +1,9 @@
+CREATE TABLE todo_shares (
+	todo_id TEXT NOT NULL REFERENCES todos (id) ON DELETE CASCADE,
+	owner TEXT NOT NULL,
+	grantee TEXT NOT NULL,
+	role TEXT NOT NULL,
+	PRIMARY KEY (todo_id, grantee)
+);
+
+CREATE INDEX todo_shares_grantee_idx ON todo_shares (grantee);
@@ -0,0 +1,20 @@
+-- Indexes the words of the todos written before the search index existed.
+-- SQLite has no regular expressions, so the text is split a character at a
+-- time on anything but ASCII letters and digits and non-ASCII characters,
+-- which lower leaves as they are.
+WITH RECURSIVE split (todo_id, owner, rest, word, term) AS (
+	SELECT id, owner, lower(name || ' ' || description) || ' ', '', NULL
+	FROM todos
+	WHERE NOT EXISTS (SELECT 1 FROM todo_terms WHERE todo_terms.todo_id = todos.id)
+	UNION ALL
+	SELECT
+		todo_id,
+		owner,
+		substr(rest, 2),
+		CASE WHEN substr(rest, 1, 1) GLOB '[0-9a-z]' OR unicode(substr(rest, 1, 1)) > 127 THEN word || substr(rest, 1, 1) ELSE '' END,
+		CASE WHEN substr(rest, 1, 1) GLOB '[0-9a-z]' OR unicode(substr(rest, 1, 1)) > 127 THEN NULL ELSE word END
+	FROM split
+	WHERE rest <> ''
+)
+INSERT INTO todo_terms (todo_id, owner, term)
+SELECT DISTINCT todo_id, owner, term FROM split WHERE term <> '';
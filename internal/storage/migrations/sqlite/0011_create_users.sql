@@ -0,0 +1,8 @@
+CREATE TABLE users (
+	email TEXT PRIMARY KEY,
+	password_hash TEXT NOT NULL,
+	verification_hash TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	verified_at TIMESTAMP
+);
@@ -0,0 +1,44 @@
+-- Trims and lowercases the owners written before they were normalized, so
+-- Bob@x.com finds the todos, keys and account stored for bob@x.com. A todo
+-- shared with several spellings of the same grantee keeps the normalized
+-- share, or else the first one, and an email registered with several
+-- spellings keeps the normalized account, or else the oldest one.
+DELETE FROM todo_shares
+WHERE grantee <> lower(trim(grantee))
+	AND EXISTS (
+		SELECT 1 FROM todo_shares AS other
+		WHERE other.todo_id = todo_shares.todo_id
+			AND other.grantee = lower(trim(todo_shares.grantee))
+	);
+
+DELETE FROM todo_shares
+WHERE grantee <> lower(trim(grantee))
+	AND EXISTS (
+		SELECT 1 FROM todo_shares AS other
+		WHERE other.todo_id = todo_shares.todo_id
+			AND lower(trim(other.grantee)) = lower(trim(todo_shares.grantee))
+			AND other.grantee < todo_shares.grantee
+	);
+
+UPDATE todo_shares SET grantee = lower(trim(grantee)) WHERE grantee <> lower(trim(grantee));
+UPDATE todo_shares SET owner = lower(trim(owner)) WHERE owner <> lower(trim(owner));
+UPDATE todo_terms SET owner = lower(trim(owner)) WHERE owner <> lower(trim(owner));
+UPDATE todos SET owner = lower(trim(owner)) WHERE owner <> lower(trim(owner));
+UPDATE api_keys SET owner = lower(trim(owner)) WHERE owner <> lower(trim(owner));
+
+DELETE FROM users
+WHERE email <> lower(trim(email))
+	AND EXISTS (
+		SELECT 1 FROM users AS other
+		WHERE other.email = lower(trim(users.email))
+	);
+
+DELETE FROM users
+WHERE email <> lower(trim(email))
+	AND EXISTS (
+		SELECT 1 FROM users AS other
+		WHERE lower(trim(other.email)) = lower(trim(users.email))
+			AND (other.created_at < users.created_at OR (other.created_at = users.created_at AND other.email < users.email))
+	);
+
+UPDATE users SET email = lower(trim(email)) WHERE email <> lower(trim(email));
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	t.Run("should load the migrations sorted by version", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"migrations/0002_add_index.sql":    {Data: []byte("CREATE INDEX items_name_idx ON items (name);")},
+			"migrations/0001_create_items.sql": {Data: []byte("CREATE TABLE items (name TEXT);")},
+			"migrations/README.md":             {Data: []byte("ignored")},
+		}
+
+		migrations, err := LoadMigrations(fsys, "migrations")
+		assert.NoError(t, err)
+		assert.Len(t, migrations, 2)
+		assert.Equal(t, 1, migrations[0].Version)
+		assert.Equal(t, "create_items", migrations[0].Name)
+		assert.Equal(t, 2, migrations[1].Version)
+	})
+
+	t.Run("should return an error if the file name has no version", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"migrations/create_items.sql": {Data: []byte("CREATE TABLE items (name TEXT);")},
+		}
+
+		migrations, err := LoadMigrations(fsys, "migrations")
+		assert.Error(t, err)
+		assert.Nil(t, migrations)
+	})
+
+	t.Run("should return an error if two migrations share a version", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"migrations/0001_create_items.sql": {Data: []byte("CREATE TABLE items (name TEXT);")},
+			"migrations/0001_create_users.sql": {Data: []byte("CREATE TABLE users (name TEXT);")},
+		}
+
+		migrations, err := LoadMigrations(fsys, "migrations")
+		assert.Error(t, err)
+		assert.Nil(t, migrations)
+	})
+
+	t.Run("should load the embedded sqlite migrations", func(t *testing.T) {
+		migrations, err := LoadMigrations(sqliteMigrations, "migrations/sqlite")
+		assert.NoError(t, err)
+		assert.NotEmpty(t, migrations)
+	})
+
+	t.Run("should load the embedded postgres migrations", func(t *testing.T) {
+		migrations, err := LoadMigrations(postgresMigrations, "migrations/postgres")
+		assert.NoError(t, err)
+		assert.NotEmpty(t, migrations)
+	})
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.TODO()
+	migrations := []Migration{
+		{Version: 1, Name: "create_items", SQL: "CREATE TABLE items (name TEXT);"},
+		{Version: 2, Name: "add_index", SQL: "CREATE INDEX items_name_idx ON items (name);"},
+	}
+
+	t.Run("should apply the migrations once", func(t *testing.T) {
+		db, err := OpenSQLite(filepath.Join(t.TempDir(), "test.db"))
+		assert.NoError(t, err)
+		defer db.Close()
+
+		assert.NoError(t, Migrate(ctx, db, migrations))
+		assert.NoError(t, Migrate(ctx, db, migrations))
+
+		var count int
+		err = db.QueryRowContext(ctx, "SELECT COUNT(*) FROM schema_migrations").Scan(&count)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("should apply only the new migrations", func(t *testing.T) {
+		db, err := OpenSQLite(filepath.Join(t.TempDir(), "test.db"))
+		assert.NoError(t, err)
+		defer db.Close()
+
+		assert.NoError(t, Migrate(ctx, db, migrations[:1]))
+		assert.NoError(t, Migrate(ctx, db, migrations))
+
+		var version int
+		err = db.QueryRowContext(ctx, "SELECT MAX(version) FROM schema_migrations").Scan(&version)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, version)
+	})
+
+	t.Run("should roll back a failing migration", func(t *testing.T) {
+		db, err := OpenSQLite(filepath.Join(t.TempDir(), "test.db"))
+		assert.NoError(t, err)
+		defer db.Close()
+
+		err = Migrate(ctx, db, []Migration{
+			{Version: 1, Name: "create_items", SQL: "CREATE TABLE items (name TEXT); INVALID SQL;"},
+		})
+		assert.Error(t, err)
+
+		var count int
+		err = db.QueryRowContext(ctx, "SELECT COUNT(*) FROM schema_migrations").Scan(&count)
+		assert.NoError(t, err)
+		assert.Zero(t, count)
+	})
+}
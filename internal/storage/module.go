@@ -1,8 +1,24 @@
 package storage
 
-import "go.uber.org/fx"
+import (
+	"go.uber.org/fx"
 
-var Module = fx.Module(
-	"storage-module",
-	fx.Provide(NewRedisClient),
+	"todo-app/config"
+	"todo-app/internal/health"
 )
+
+// Module only provides the client of the configured storage, so the other
+// backends are never opened, along with its readiness check.
+func Module(configs config.Config) fx.Option {
+	var options []fx.Option
+	switch configs.Storage {
+	case config.RedisStorage:
+		options = append(options, fx.Provide(NewRedisClient, health.AsChecker(NewRedisHealthChecker)))
+	case config.SQLiteStorage:
+		options = append(options, fx.Provide(NewSQLiteDB, health.AsChecker(NewSQLiteHealthChecker)))
+	case config.PostgresStorage:
+		options = append(options, fx.Provide(NewPostgresPool, health.AsChecker(NewPostgresHealthChecker)))
+	}
+
+	return fx.Module("storage-module", options...)
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+
+	"todo-app/config"
+	"todo-app/internal/health"
+)
+
+func TestModule(t *testing.T) {
+	t.Run("should provide the redis client", func(t *testing.T) {
+		configs := config.Config{Storage: config.RedisStorage, RedisMode: config.RedisStandalone}
+		app := fxtest.New(
+			t,
+			fx.Supply(configs),
+			Module(configs),
+			fx.Invoke(func(client redis.UniversalClient) {
+				assert.NotNil(t, client)
+			}),
+		)
+		assert.NoError(t, app.Err())
+	})
+
+	t.Run("should provide the sqlite database", func(t *testing.T) {
+		configs := config.Config{
+			Storage:    config.SQLiteStorage,
+			SQLitePath: filepath.Join(t.TempDir(), "todos.db"),
+		}
+		app := fxtest.New(
+			t,
+			fx.Supply(configs),
+			Module(configs),
+			fx.Invoke(func(params struct {
+				fx.In
+				DB       *sql.DB
+				Checkers []health.Checker `group:"health_checkers"`
+			}) {
+				assert.NotNil(t, params.DB)
+				if assert.Len(t, params.Checkers, 1) {
+					assert.Equal(t, "sqlite", params.Checkers[0].Name())
+				}
+			}),
+		)
+		defer app.RequireStart().RequireStop()
+	})
+
+	t.Run("should not provide any client for the memory storage", func(t *testing.T) {
+		configs := config.Config{Storage: config.MemoryStorage}
+		app := fxtest.New(
+			t,
+			fx.Supply(configs),
+			Module(configs),
+			fx.Invoke(func(params struct {
+				fx.In
+				Redis  redis.UniversalClient `optional:"true"`
+				SQLite *sql.DB               `optional:"true"`
+			}) {
+				assert.Nil(t, params.Redis)
+				assert.Nil(t, params.SQLite)
+			}),
+		)
+		defer app.RequireStart().RequireStop()
+	})
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"embed"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"go.uber.org/fx"
+
+	"todo-app/config"
+)
+
+//go:embed migrations/postgres/*.sql
+var postgresMigrations embed.FS
+
+func NewPostgresPool(configs config.Config, lc fx.Lifecycle) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(configs.PostgresDSN)
+	if err != nil {
+		return nil, err
+	}
+
+	if configs.PostgresMaxConns > 0 {
+		poolConfig.MaxConns = int32(configs.PostgresMaxConns)
+	}
+	poolConfig.MinConns = int32(configs.PostgresMinConns)
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			return MigratePostgres(ctx, pool)
+		},
+		OnStop: func(ctx context.Context) error {
+			pool.Close()
+			return nil
+		},
+	})
+
+	return pool, nil
+}
+
+func MigratePostgres(ctx context.Context, pool *pgxpool.Pool) error {
+	migrations, err := LoadMigrations(postgresMigrations, "migrations/postgres")
+	if err != nil {
+		return err
+	}
+
+	// Closing this *sql.DB leaves the pool open.
+	db := stdlib.OpenDBFromPool(pool)
+	defer db.Close()
+
+	return Migrate(ctx, db, migrations)
+}
@@ -1,19 +1,179 @@
 package storage
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"os"
+	"sync"
 
 	"github.com/redis/go-redis/v9"
+	"go.uber.org/fx"
 
 	"todo-app/config"
 )
 
-func NewRedisClient(config config.Config) *redis.Client {
-	client := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%s", config.RedisHost, config.RedisPort),
-		Password: "",
-		DB:       0,
+// NewRedisClient connects to a single server, a Sentinel managed master or a
+// Cluster depending on the redis mode. The server is pinged when the app
+// starts and the client closed when it stops.
+func NewRedisClient(configs config.Config, lc fx.Lifecycle) (redis.UniversalClient, error) {
+	client, err := newRedisClient(configs)
+	if err != nil {
+		return nil, err
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			if err := client.Ping(ctx).Err(); err != nil {
+				return fmt.Errorf("connecting to redis: %w", err)
+			}
+
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return client.Close()
+		},
 	})
 
-	return client
+	return client, nil
+}
+
+func newRedisClient(configs config.Config) (redis.UniversalClient, error) {
+	options, err := redisOptions(configs)
+	if err != nil {
+		return nil, err
+	}
+
+	switch configs.RedisMode {
+	case config.RedisSentinel:
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       configs.RedisMasterName,
+			SentinelAddrs:    configs.RedisAddrs,
+			SentinelUsername: configs.RedisSentinelUsername,
+			SentinelPassword: configs.RedisSentinelPassword,
+			Username:         options.Username,
+			Password:         options.Password,
+			DB:               options.DB,
+			DialTimeout:      options.DialTimeout,
+			ReadTimeout:      options.ReadTimeout,
+			WriteTimeout:     options.WriteTimeout,
+			PoolSize:         options.PoolSize,
+			TLSConfig:        options.TLSConfig,
+		}), nil
+	case config.RedisCluster:
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        configs.RedisAddrs,
+			Username:     options.Username,
+			Password:     options.Password,
+			DialTimeout:  options.DialTimeout,
+			ReadTimeout:  options.ReadTimeout,
+			WriteTimeout: options.WriteTimeout,
+			PoolSize:     options.PoolSize,
+			TLSConfig:    options.TLSConfig,
+		}), nil
+	}
+
+	return redis.NewClient(options), nil
+}
+
+func redisOptions(configs config.Config) (*redis.Options, error) {
+	options := &redis.Options{
+		Addr:     fmt.Sprintf("%s:%s", configs.RedisHost, configs.RedisPort),
+		Username: configs.RedisUsername,
+		Password: configs.RedisPassword,
+		DB:       configs.RedisDB,
+	}
+
+	if configs.RedisURL != "" {
+		var err error
+		options, err = redis.ParseURL(configs.RedisURL)
+		if err != nil {
+			return nil, fmt.Errorf("parsing the redis url: %w", err)
+		}
+	}
+
+	options.DialTimeout = configs.RedisDialTimeout
+	options.ReadTimeout = configs.RedisReadTimeout
+	options.WriteTimeout = configs.RedisWriteTimeout
+	options.PoolSize = configs.RedisPoolSize
+
+	if configs.RedisTLS || options.TLSConfig != nil {
+		tlsConfig, err := redisTLSConfig(configs, options.TLSConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		options.TLSConfig = tlsConfig
+	}
+
+	return options, nil
+}
+
+// redisTLSConfig extends base, which is set when the url uses rediss://, with
+// the configured certificates.
+func redisTLSConfig(configs config.Config, base *tls.Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if base != nil {
+		tlsConfig = base.Clone()
+	}
+
+	if configs.RedisTLSServerName != "" {
+		tlsConfig.ServerName = configs.RedisTLSServerName
+	}
+	tlsConfig.InsecureSkipVerify = configs.RedisTLSInsecureSkipVerify
+
+	if configs.RedisTLSCACert != "" {
+		caCert, err := os.ReadFile(configs.RedisTLSCACert)
+		if err != nil {
+			return nil, fmt.Errorf("reading the redis ca certificate: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, errors.New("the redis ca certificate has no valid PEM certificate")
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if configs.RedisTLSCert != "" {
+		certificate, err := tls.LoadX509KeyPair(configs.RedisTLSCert, configs.RedisTLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("loading the redis client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
+
+// redisScanCount is the number of keys SCAN looks at on each call.
+const redisScanCount = 100
+
+// ScanRedisKeys returns the keys of the type that match the pattern, looking
+// in every master of a cluster.
+func ScanRedisKeys(ctx context.Context, client redis.UniversalClient, pattern string, keyType string) ([]string, error) {
+	var mu sync.Mutex
+	var keys []string
+	scan := func(ctx context.Context, node redis.Cmdable) error {
+		iter := node.ScanType(ctx, 0, pattern, redisScanCount, keyType).Iterator()
+		for iter.Next(ctx) {
+			mu.Lock()
+			keys = append(keys, iter.Val())
+			mu.Unlock()
+		}
+		return iter.Err()
+	}
+
+	if cluster, ok := client.(*redis.ClusterClient); ok {
+		err := cluster.ForEachMaster(ctx, func(ctx context.Context, master *redis.Client) error {
+			return scan(ctx, master)
+		})
+		return keys, err
+	}
+
+	return keys, scan(ctx, client)
 }
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/fx"
+
+	"todo-app/config"
+)
+
+// Clients holds the storage clients. Only the one of the configured storage
+// is provided by the module, the rest are left nil.
+type Clients struct {
+	fx.In
+
+	Config   config.Config
+	Redis    redis.UniversalClient `optional:"true"`
+	SQLite   *sql.DB               `optional:"true"`
+	Postgres *pgxpool.Pool         `optional:"true"`
+}
+
+// Repositories builds a repository on each storage.
+type Repositories[R any] struct {
+	Redis    func(client redis.UniversalClient) R
+	SQLite   func(db *sql.DB) R
+	Postgres func(pool *pgxpool.Pool) R
+	Memory   func() R
+}
+
+// NewRepository builds the repository of the configured storage.
+func NewRepository[R any](clients Clients, repositories Repositories[R]) (R, error) {
+	var repository R
+	switch clients.Config.Storage {
+	case config.RedisStorage:
+		if clients.Redis != nil {
+			return repositories.Redis(clients.Redis), nil
+		}
+	case config.SQLiteStorage:
+		if clients.SQLite != nil {
+			return repositories.SQLite(clients.SQLite), nil
+		}
+	case config.PostgresStorage:
+		if clients.Postgres != nil {
+			return repositories.Postgres(clients.Postgres), nil
+		}
+	case config.MemoryStorage:
+		return repositories.Memory(), nil
+	default:
+		return repository, fmt.Errorf("unsupported storage %q", clients.Config.Storage)
+	}
+
+	return repository, fmt.Errorf("no client provided for the %q storage", clients.Config.Storage)
+}
+
+// Migrator is implemented by the repositories that upgrade the data written
+// by older versions.
+type Migrator interface {
+	Migrate(ctx context.Context) error
+}
+
+// MigrateOnStart migrates the repository when the app starts if it's a
+// Migrator, so a failed migration fails the startup.
+func MigrateOnStart(repository any, lc fx.Lifecycle) {
+	if migrator, ok := repository.(Migrator); ok {
+		lc.Append(fx.Hook{OnStart: migrator.Migrate})
+	}
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"todo-app/config"
+)
+
+func TestNewRepository(t *testing.T) {
+	repositories := Repositories[string]{
+		Redis:    func(redis.UniversalClient) string { return "redis" },
+		SQLite:   func(*sql.DB) string { return "sqlite" },
+		Postgres: func(*pgxpool.Pool) string { return "postgres" },
+		Memory:   func() string { return "memory" },
+	}
+
+	t.Run("should return the repository of the configured storage", func(t *testing.T) {
+		for _, clients := range []Clients{
+			{Config: config.Config{Storage: config.RedisStorage}, Redis: redis.NewClient(&redis.Options{})},
+			{Config: config.Config{Storage: config.SQLiteStorage}, SQLite: &sql.DB{}},
+			{Config: config.Config{Storage: config.PostgresStorage}, Postgres: &pgxpool.Pool{}},
+			{Config: config.Config{Storage: config.MemoryStorage}},
+		} {
+			repository, err := NewRepository(clients, repositories)
+			assert.NoError(t, err)
+			assert.Equal(t, clients.Config.Storage, repository)
+		}
+	})
+
+	t.Run("should return an error if the storage client is missing", func(t *testing.T) {
+		repository, err := NewRepository(Clients{Config: config.Config{Storage: config.SQLiteStorage}}, repositories)
+		assert.Error(t, err)
+		assert.Empty(t, repository)
+	})
+
+	t.Run("should return an error for an unsupported storage", func(t *testing.T) {
+		repository, err := NewRepository(Clients{Config: config.Config{Storage: "unknown"}}, repositories)
+		assert.Error(t, err)
+		assert.Empty(t, repository)
+	})
+}
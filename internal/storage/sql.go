@@ -0,0 +1,20 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Scanner is implemented by *sql.Row, *sql.Rows and their pgx counterparts.
+type Scanner interface {
+	Scan(dest ...any) error
+}
+
+// NullTime stores an optional time in UTC.
+func NullTime(value *time.Time) sql.NullTime {
+	if value == nil {
+		return sql.NullTime{}
+	}
+
+	return sql.NullTime{Time: value.UTC(), Valid: true}
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+	"go.uber.org/fx"
+
+	"todo-app/config"
+)
+
+//go:embed migrations/sqlite/*.sql
+var sqliteMigrations embed.FS
+
+func NewSQLiteDB(configs config.Config, lc fx.Lifecycle) (*sql.DB, error) {
+	db, err := OpenSQLite(configs.SQLitePath)
+	if err != nil {
+		return nil, err
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			return MigrateSQLite(ctx, db)
+		},
+		OnStop: func(ctx context.Context) error {
+			return db.Close()
+		},
+	})
+
+	return db, nil
+}
+
+func OpenSQLite(path string) (*sql.DB, error) {
+	dsn := fmt.Sprintf("file:%s?_journal_mode=WAL&_busy_timeout=5000&_foreign_keys=on", path)
+	return sql.Open("sqlite3", dsn)
+}
+
+func MigrateSQLite(ctx context.Context, db *sql.DB) error {
+	migrations, err := LoadMigrations(sqliteMigrations, "migrations/sqlite")
+	if err != nil {
+		return err
+	}
+
+	return Migrate(ctx, db, migrations)
+}
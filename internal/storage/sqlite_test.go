@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/fx/fxtest"
+
+	"todo-app/config"
+)
+
+func TestNewSQLiteDB(t *testing.T) {
+	t.Run("should migrate the database on start", func(t *testing.T) {
+		lc := fxtest.NewLifecycle(t)
+		db, err := NewSQLiteDB(config.Config{
+			SQLitePath: filepath.Join(t.TempDir(), "todos.db"),
+		}, lc)
+		assert.NoError(t, err)
+		assert.NotNil(t, db)
+
+		lc.RequireStart()
+		defer lc.RequireStop()
+
+		var count int
+		err = db.QueryRowContext(context.TODO(), "SELECT COUNT(*) FROM todos").Scan(&count)
+		assert.NoError(t, err)
+		assert.Zero(t, count)
+	})
+}
@@ -0,0 +1,173 @@
+// Package storagetest provides the migrated storages the repository tests
+// run against.
+package storagetest
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"todo-app/internal/storage"
+)
+
+// PostgresDSNEnv names the variable pointing the tests to a local server
+// instead of a container.
+const PostgresDSNEnv = "TEST_POSTGRES_DSN"
+
+func SQLiteDB(t *testing.T) *sql.DB {
+	db, err := storage.OpenSQLite(filepath.Join(t.TempDir(), "todos.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = storage.MigrateSQLite(context.TODO(), db); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	return db
+}
+
+// PostgresPool connects to the server of PostgresDSNEnv if it's set, in a
+// schema of its own that's dropped afterwards, or else to a container. The
+// test is skipped if there's neither.
+func PostgresPool(t *testing.T) *pgxpool.Pool {
+	dsn, local := os.LookupEnv(PostgresDSNEnv)
+	if !local {
+		dsn = startPostgres(t)
+	}
+
+	config, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if local {
+		config.ConnConfig.RuntimeParams["search_path"] = createSchema(t, dsn)
+	}
+
+	ctx := context.TODO()
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(pool.Close)
+
+	if err = storage.MigratePostgres(ctx, pool); err != nil {
+		t.Fatal(err)
+	}
+
+	return pool
+}
+
+func startPostgres(t *testing.T) string {
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
+	req := testcontainers.ContainerRequest{
+		Image:        "postgres:16-alpine",
+		ExposedPorts: []string{"5432/tcp"},
+		Env: map[string]string{
+			"POSTGRES_USER":     "postgres",
+			"POSTGRES_PASSWORD": "postgres",
+			"POSTGRES_DB":       "todos",
+		},
+		WaitingFor: wait.ForLog("database system is ready to accept connections").WithOccurrence(2),
+	}
+
+	ctx := context.TODO()
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		container.Terminate(ctx)
+	})
+
+	postgresHost, err := container.Endpoint(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return fmt.Sprintf("postgres://postgres:postgres@%s/todos?sslmode=disable", postgresHost)
+}
+
+// createSchema creates a schema for the test, so the ones sharing the
+// server don't see each other's tables.
+func createSchema(t *testing.T, dsn string) string {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		t.Fatal(err)
+	}
+	schema := "test_" + hex.EncodeToString(suffix)
+
+	ctx := context.TODO()
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		conn.Exec(ctx, "DROP SCHEMA "+schema+" CASCADE")
+		conn.Close(ctx)
+	})
+
+	if _, err = conn.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatal(err)
+	}
+
+	return schema
+}
+
+// RedisClient starts a Redis container, the test is skipped without Docker.
+func RedisClient(t *testing.T) *redis.Client {
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
+	req := testcontainers.ContainerRequest{
+		Image:        "redis:latest",
+		ExposedPorts: []string{"6379/tcp"},
+		WaitingFor:   wait.ForLog("Ready to accept connections"),
+	}
+
+	ctx := context.TODO()
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	redisHost, err := container.Endpoint(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr: redisHost,
+	})
+
+	t.Cleanup(func() {
+		container.Terminate(ctx)
+	})
+
+	return client
+}
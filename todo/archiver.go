@@ -0,0 +1,47 @@
+package todo
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/fx"
+
+	"todo-app/config"
+	"todo-app/todo/models"
+)
+
+// Archiver archives the todos that were completed longer than the delay ago.
+type Archiver struct {
+	repository Repository
+	clock      Clock
+	after      time.Duration
+}
+
+func NewArchiver(repository Repository, clock Clock, configs config.Config) *Archiver {
+	return &Archiver{
+		repository: repository,
+		clock:      clock,
+		after:      configs.ArchiveAfter,
+	}
+}
+
+func (a *Archiver) Archive(ctx context.Context) (int, error) {
+	now := a.clock.Now()
+	return a.repository.ArchiveCompleted(ctx, now.Add(-a.after), now)
+}
+
+// StartArchiver runs the archiver every ArchiveInterval while the app is
+// running, unless the delay or the interval is 0.
+func StartArchiver(archiver *Archiver, configs config.Config, lc fx.Lifecycle) {
+	if configs.ArchiveAfter == 0 || configs.ArchiveInterval == 0 {
+		return
+	}
+
+	startJob(lc, "archiving completed todos", configs.ArchiveInterval, archiver.Archive)
+}
+
+// awaitsArchive tells whether the todo will be archived once it has been
+// completed for long enough.
+func awaitsArchive(todo models.Todo) bool {
+	return todo.Completed && todo.CompletedAt != nil && todo.ArchivedAt == nil && todo.DeletedAt == nil
+}
@@ -0,0 +1,50 @@
+package todo_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/mock/gomock"
+
+	"todo-app/config"
+	"todo-app/todo"
+	"todo-app/todo/mocks"
+)
+
+func TestArchiver_Archive(t *testing.T) {
+	ctxMatcher := reflect.TypeOf((*context.Context)(nil)).Elem()
+
+	t.Run("should archive the todos completed before the delay", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			ArchiveCompleted(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(now.Add(-72*time.Hour)), gomock.Eq(now)).
+			Return(2, nil)
+
+		archiver := todo.NewArchiver(repository, newClock(ctrl), config.Config{ArchiveAfter: 72 * time.Hour})
+		archived, err := archiver.Archive(context.TODO())
+		assert.NoError(t, err)
+		assert.Equal(t, 2, archived)
+	})
+}
+
+func TestStartArchiver(t *testing.T) {
+	t.Run("should not archive if the delay is 0", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+
+		configs := config.Config{ArchiveInterval: time.Millisecond}
+		archiver := todo.NewArchiver(repository, newClock(ctrl), configs)
+		lc := fxtest.NewLifecycle(t)
+		todo.StartArchiver(archiver, configs, lc)
+
+		lc.RequireStart()
+		time.Sleep(10 * time.Millisecond)
+		lc.RequireStop()
+	})
+}
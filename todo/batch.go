@@ -0,0 +1,130 @@
+package todo
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/google/uuid"
+
+	"todo-app/todo/models"
+)
+
+// MaxBatchSize is the most operations a batch can have.
+const MaxBatchSize = 100
+
+var (
+	ErrInvalidBatch     = fmt.Errorf("a batch must have between 1 and %d operations", MaxBatchSize)
+	ErrInvalidOperation = fmt.Errorf("the operation must be create, update or delete")
+)
+
+type BatchOp string
+
+const (
+	BatchCreate BatchOp = "create"
+	BatchUpdate BatchOp = "update"
+	BatchDelete BatchOp = "delete"
+)
+
+// BatchWrite is a write of a batch. Creates store Todo. Updates and deletes
+// apply to the todo with the ID if it's at Version, or at any version if it's
+// 0, and updates replace it with the result of Apply on the stored todo.
+type BatchWrite struct {
+	Op      BatchOp
+	ID      string
+	Version int64
+	Todo    models.Todo
+	Apply   func(stored models.Todo) (models.Todo, error)
+}
+
+// BatchResult is the outcome of a write of a batch, the todo written or the
+// error that made the write be skipped.
+type BatchResult struct {
+	Todo models.Todo
+	Err  error
+}
+
+// batchChange is a successful write of a batch to persist. before is nil for
+// creates and after is nil for deletes.
+type batchChange struct {
+	id     string
+	before *models.Todo
+	after  *models.Todo
+}
+
+// batchIDs returns the ids of the todos the writes need to be read, without
+// duplicates or invalid ones.
+func batchIDs(writes []BatchWrite) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, write := range writes {
+		if write.Op == BatchCreate || seen[write.ID] || validateID(write.ID) != nil {
+			continue
+		}
+
+		seen[write.ID] = true
+		ids = append(ids, write.ID)
+	}
+
+	return ids
+}
+
+// planBatch runs the writes in order against the stored todos, the existing
+// ones among batchIDs. It returns the result of every write along with the
+// changes to persist, in the order they must be applied.
+func planBatch(writes []BatchWrite, stored map[string]models.Todo) ([]BatchResult, []batchChange) {
+	current := maps.Clone(stored)
+	results := make([]BatchResult, len(writes))
+	var changes []batchChange
+	for i, write := range writes {
+		if write.Op == BatchCreate {
+			todo := write.Todo
+			todo.ID = uuid.NewString()
+			todo.Version = 1
+			results[i].Todo = todo
+			changes = append(changes, batchChange{id: todo.ID, after: &todo})
+			continue
+		}
+
+		if write.Op != BatchUpdate && write.Op != BatchDelete {
+			results[i].Err = ErrInvalidOperation
+			continue
+		}
+
+		if err := validateID(write.ID); err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		before, ok := current[write.ID]
+		if !ok {
+			results[i].Err = ErrTodoNotFound
+			continue
+		}
+
+		if write.Version != 0 && before.Version != write.Version {
+			results[i].Err = ErrVersionMismatch
+			continue
+		}
+
+		if write.Op == BatchDelete {
+			delete(current, write.ID)
+			changes = append(changes, batchChange{id: write.ID, before: &before})
+			continue
+		}
+
+		after, err := write.Apply(before)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		after.ID = write.ID
+		after.CreatedAt = before.CreatedAt
+		after.Version = before.Version + 1
+		current[write.ID] = after
+		results[i].Todo = after
+		changes = append(changes, batchChange{id: write.ID, before: &before, after: &after})
+	}
+
+	return results, changes
+}
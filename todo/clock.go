@@ -0,0 +1,20 @@
+package todo
+
+import "time"
+
+// Clock tells the time the service stamps on the todos.
+//
+//go:generate mockgen -destination mocks/clock_mock.go -package mocks . Clock
+type Clock interface {
+	Now() time.Time
+}
+
+type SystemClock struct{}
+
+func NewSystemClock() SystemClock {
+	return SystemClock{}
+}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
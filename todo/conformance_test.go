@@ -0,0 +1,33 @@
+package todo_test
+
+import (
+	"testing"
+
+	"todo-app/internal/storage/storagetest"
+	"todo-app/todo"
+	"todo-app/todo/repotest"
+)
+
+func TestMemoryRepository_Conformance(t *testing.T) {
+	repotest.RunConformance(t, func(t *testing.T) todo.Repository {
+		return todo.NewMemoryRepository()
+	})
+}
+
+func TestRedisRepository_Conformance(t *testing.T) {
+	repotest.RunConformance(t, func(t *testing.T) todo.Repository {
+		return todo.NewRedisRepository(storagetest.RedisClient(t))
+	})
+}
+
+func TestSQLiteRepository_Conformance(t *testing.T) {
+	repotest.RunConformance(t, func(t *testing.T) todo.Repository {
+		return todo.NewSQLiteRepository(storagetest.SQLiteDB(t))
+	})
+}
+
+func TestPostgresRepository_Conformance(t *testing.T) {
+	repotest.RunConformance(t, func(t *testing.T) todo.Repository {
+		return todo.NewPostgresRepository(storagetest.PostgresPool(t))
+	})
+}
@@ -0,0 +1,18 @@
+package dtos
+
+type BatchTodos struct {
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchOperation is a create, update or delete. Creates and updates take the
+// fields of the todo, updates and deletes its id and, optionally, the version
+// it must be at.
+type BatchOperation struct {
+	Op          string `json:"op"`
+	ID          string `json:"id"`
+	Version     int64  `json:"version"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	DueDate     string `json:"due_date"`
+	StartDate   string `json:"start_date"`
+}
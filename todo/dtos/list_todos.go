@@ -0,0 +1,17 @@
+package dtos
+
+import "time"
+
+type ListTodos struct {
+	Limit           int        `form:"limit"`
+	Cursor          string     `form:"cursor"`
+	Sort            string     `form:"sort"`
+	Order           string     `form:"order"`
+	Completed       *bool      `form:"completed"`
+	DueBefore       *time.Time `form:"due_before" time_format:"2006-01-02 15:04:05" time_utc:"1"`
+	DueAfter        *time.Time `form:"due_after" time_format:"2006-01-02 15:04:05" time_utc:"1"`
+	StartBefore     *time.Time `form:"start_before" time_format:"2006-01-02 15:04:05" time_utc:"1"`
+	StartAfter      *time.Time `form:"start_after" time_format:"2006-01-02 15:04:05" time_utc:"1"`
+	Overdue         bool       `form:"overdue"`
+	IncludeArchived bool       `form:"include_archived"`
+}
@@ -0,0 +1,8 @@
+package dtos
+
+type ListTrash struct {
+	Limit  int    `form:"limit"`
+	Cursor string `form:"cursor"`
+	Sort   string `form:"sort"`
+	Order  string `form:"order"`
+}
@@ -0,0 +1,30 @@
+package dtos
+
+import "encoding/json"
+
+// PatchTodo is a JSON merge patch of a todo, only the fields present in the
+// document change.
+type PatchTodo struct {
+	Name        PatchField[string] `json:"name"`
+	Description PatchField[string] `json:"description"`
+	DueDate     PatchField[string] `json:"due_date"`
+	StartDate   PatchField[string] `json:"start_date"`
+}
+
+// PatchField is a member of a merge patch. Set tells whether it was in the
+// document and Null whether it was set to null, i.e. removed.
+type PatchField[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+func (p *PatchField[T]) UnmarshalJSON(data []byte) error {
+	p.Set = true
+	if string(data) == "null" {
+		p.Null = true
+		return nil
+	}
+
+	return json.Unmarshal(data, &p.Value)
+}
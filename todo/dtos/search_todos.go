@@ -0,0 +1,6 @@
+package dtos
+
+type SearchTodos struct {
+	Query string `form:"q"`
+	Limit int    `form:"limit"`
+}
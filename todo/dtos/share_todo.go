@@ -0,0 +1,5 @@
+package dtos
+
+type ShareTodo struct {
+	Role string `json:"role"`
+}
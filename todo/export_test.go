@@ -0,0 +1,3 @@
+package todo
+
+var ValidateDates = validateDates
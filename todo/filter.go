@@ -0,0 +1,78 @@
+package todo
+
+import (
+	"fmt"
+	"time"
+
+	"todo-app/todo/models"
+)
+
+var ErrInvalidFilter = fmt.Errorf("overdue todos can't be completed")
+
+// TodoFilter narrows the todos listed by GetAll, nil fields don't filter.
+// The bounds are exclusive. Overdue is resolved by the service into
+// Completed and DueBefore, so the repositories don't depend on the clock.
+// Deleted lists the todos in the trash instead of the rest, and
+// IncludeArchived lists the archived todos along with the others.
+type TodoFilter struct {
+	Completed       *bool
+	DueBefore       *time.Time
+	DueAfter        *time.Time
+	StartBefore     *time.Time
+	StartAfter      *time.Time
+	Overdue         bool
+	Deleted         bool
+	IncludeArchived bool
+}
+
+// resolve replaces Overdue with the open todos due before now.
+func (f TodoFilter) resolve(now time.Time) (TodoFilter, error) {
+	if !f.Overdue {
+		return f, nil
+	}
+
+	if f.Completed != nil && *f.Completed {
+		return TodoFilter{}, ErrInvalidFilter
+	}
+
+	completed := false
+	f.Completed = &completed
+	if f.DueBefore == nil || now.Before(*f.DueBefore) {
+		f.DueBefore = &now
+	}
+
+	f.Overdue = false
+	return f, nil
+}
+
+func (f TodoFilter) matches(todo models.Todo) bool {
+	if (todo.DeletedAt != nil) != f.Deleted {
+		return false
+	}
+
+	if todo.ArchivedAt != nil && !f.IncludeArchived {
+		return false
+	}
+
+	if f.Completed != nil && todo.Completed != *f.Completed {
+		return false
+	}
+
+	if f.DueBefore != nil && !todo.DueDate.Before(*f.DueBefore) {
+		return false
+	}
+
+	if f.DueAfter != nil && !todo.DueDate.After(*f.DueAfter) {
+		return false
+	}
+
+	if f.StartBefore != nil && !todo.StartDate.Before(*f.StartBefore) {
+		return false
+	}
+
+	if f.StartAfter != nil && !todo.StartDate.After(*f.StartAfter) {
+		return false
+	}
+
+	return true
+}
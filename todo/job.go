@@ -0,0 +1,47 @@
+package todo
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.uber.org/fx"
+)
+
+// startJob calls run every interval while the app is running. Errors are
+// logged and the job runs again at the next tick.
+func startJob(lc fx.Lifecycle, name string, interval time.Duration, run func(ctx context.Context) (int, error)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+
+				ticker := time.NewTicker(interval)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+						if _, err := run(ctx); err != nil {
+							log.Printf("%s: %v", name, err)
+						}
+					}
+				}
+			}()
+
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
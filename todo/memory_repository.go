@@ -0,0 +1,402 @@
+package todo
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"todo-app/todo/models"
+)
+
+// MemoryRepository keeps the role of every grantee of a todo by owner and id
+// in shares, and the shares granted to every grantee by id in shared.
+type MemoryRepository struct {
+	mu     sync.RWMutex
+	todos  map[Owner]map[string]models.Todo
+	shares map[Owner]map[string]map[Owner]models.Role
+	shared map[Owner]map[string]models.Share
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		todos:  make(map[Owner]map[string]models.Todo),
+		shares: make(map[Owner]map[string]map[Owner]models.Role),
+		shared: make(map[Owner]map[string]models.Share),
+	}
+}
+
+func (m *MemoryRepository) Create(ctx context.Context, email Owner, todo models.Todo) (models.Todo, error) {
+	if ctx.Err() != nil {
+		return models.Todo{}, ErrWhileCreating
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	todo.ID = uuid.NewString()
+	todo.Version = 1
+	userTodos, ok := m.todos[email]
+	if !ok {
+		userTodos = make(map[string]models.Todo)
+		m.todos[email] = userTodos
+	}
+
+	userTodos[todo.ID] = cloneTodo(todo)
+	return todo, nil
+}
+
+func (m *MemoryRepository) GetAll(ctx context.Context, email Owner, filter TodoFilter, page PageRequest) (Page, error) {
+	after, err := page.after()
+	if err != nil {
+		return Page{}, err
+	}
+
+	if ctx.Err() != nil {
+		return Page{}, ErrWhileRetrieving
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	field, order := page.sort(), page.order()
+	var todos []models.Todo
+	for _, todo := range m.todos[email] {
+		if filter.matches(todo) && (after == nil || isAfter(sortKey(todo, field), after.key(), order)) {
+			todos = append(todos, todo)
+		}
+	}
+
+	sort.Slice(todos, func(i, j int) bool {
+		return isAfter(sortKey(todos[j], field), sortKey(todos[i], field), order)
+	})
+
+	limit := page.limit()
+	if len(todos) > limit+1 {
+		todos = todos[:limit+1]
+	}
+
+	for i := range todos {
+		todos[i] = cloneTodo(todos[i])
+	}
+
+	return newPage(todos, page), nil
+}
+
+func (m *MemoryRepository) GetByID(ctx context.Context, email Owner, id string) (models.Todo, error) {
+	if err := validateID(id); err != nil {
+		return models.Todo{}, err
+	}
+
+	if ctx.Err() != nil {
+		return models.Todo{}, ErrWhileRetrieving
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	todo, ok := m.todos[email][id]
+	if !ok {
+		return models.Todo{}, ErrTodoNotFound
+	}
+
+	return cloneTodo(todo), nil
+}
+
+func (m *MemoryRepository) GetByIDs(ctx context.Context, email Owner, ids []string) ([]models.Todo, error) {
+	if err := validateIDs(ids); err != nil {
+		return nil, err
+	}
+
+	if ctx.Err() != nil {
+		return nil, ErrWhileRetrieving
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	todos := make([]models.Todo, 0, len(ids))
+	for _, id := range ids {
+		if todo, ok := m.todos[email][id]; ok {
+			todos = append(todos, cloneTodo(todo))
+		}
+	}
+
+	return todos, nil
+}
+
+func (m *MemoryRepository) Update(ctx context.Context, email Owner, id string, todo models.Todo) (models.Todo, error) {
+	if err := validateID(id); err != nil {
+		return models.Todo{}, err
+	}
+
+	if ctx.Err() != nil {
+		return models.Todo{}, ErrWhileUpdating
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.todos[email][id]
+	if !ok {
+		return models.Todo{}, ErrTodoNotFound
+	}
+
+	if stored.Version != todo.Version {
+		return models.Todo{}, ErrVersionMismatch
+	}
+
+	todo.ID = id
+	todo.CreatedAt = stored.CreatedAt
+	todo.Version = stored.Version + 1
+	m.todos[email][id] = cloneTodo(todo)
+	return todo, nil
+}
+
+func (m *MemoryRepository) Batch(ctx context.Context, email Owner, writes []BatchWrite) ([]BatchResult, error) {
+	if ctx.Err() != nil {
+		return nil, ErrWhileWriting
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := make(map[string]models.Todo)
+	for _, id := range batchIDs(writes) {
+		if todo, ok := m.todos[email][id]; ok {
+			stored[id] = cloneTodo(todo)
+		}
+	}
+
+	results, changes := planBatch(writes, stored)
+	userTodos, ok := m.todos[email]
+	if !ok {
+		userTodos = make(map[string]models.Todo)
+		m.todos[email] = userTodos
+	}
+
+	for _, change := range changes {
+		if change.after == nil {
+			delete(userTodos, change.id)
+			m.unshareAll(email, change.id)
+			continue
+		}
+
+		userTodos[change.id] = cloneTodo(*change.after)
+	}
+
+	return results, nil
+}
+
+// Search ranks every todo of the email, they're already in memory.
+func (m *MemoryRepository) Search(ctx context.Context, email Owner, terms []string, limit int) ([]models.Todo, error) {
+	if ctx.Err() != nil {
+		return nil, ErrWhileRetrieving
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	todos := make([]models.Todo, 0, len(m.todos[email]))
+	for _, todo := range m.todos[email] {
+		if todo.DeletedAt == nil && todo.ArchivedAt == nil {
+			todos = append(todos, cloneTodo(todo))
+		}
+	}
+
+	return rank(todos, terms, limit), nil
+}
+
+func (m *MemoryRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	if ctx.Err() != nil {
+		return 0, ErrWhilePurging
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var purged int
+	for email, userTodos := range m.todos {
+		for id, todo := range userTodos {
+			if todo.DeletedAt != nil && todo.DeletedAt.Before(before) {
+				delete(userTodos, id)
+				m.unshareAll(email, id)
+				purged++
+			}
+		}
+	}
+
+	return purged, nil
+}
+
+func (m *MemoryRepository) ArchiveCompleted(ctx context.Context, before time.Time, at time.Time) (int, error) {
+	if ctx.Err() != nil {
+		return 0, ErrWhileArchiving
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var archived int
+	for _, userTodos := range m.todos {
+		for id, todo := range userTodos {
+			if !awaitsArchive(todo) || !todo.CompletedAt.Before(before) {
+				continue
+			}
+
+			archivedAt := at
+			todo.ArchivedAt = &archivedAt
+			todo.UpdatedAt = at
+			todo.Version++
+			userTodos[id] = todo
+			archived++
+		}
+	}
+
+	return archived, nil
+}
+
+func (m *MemoryRepository) Share(ctx context.Context, email Owner, id string, grantee Owner, role models.Role) error {
+	if err := validateID(id); err != nil {
+		return err
+	}
+
+	if ctx.Err() != nil {
+		return ErrWhileSharing
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.todos[email][id]; !ok {
+		return ErrTodoNotFound
+	}
+
+	if m.shares[email] == nil {
+		m.shares[email] = make(map[string]map[Owner]models.Role)
+	}
+
+	if m.shares[email][id] == nil {
+		m.shares[email][id] = make(map[Owner]models.Role)
+	}
+
+	if m.shared[grantee] == nil {
+		m.shared[grantee] = make(map[string]models.Share)
+	}
+
+	m.shares[email][id][grantee] = role
+	m.shared[grantee][id] = models.Share{TodoID: id, Owner: email.String(), Grantee: grantee.String(), Role: role}
+	return nil
+}
+
+func (m *MemoryRepository) Unshare(ctx context.Context, email Owner, id string, grantee Owner) error {
+	if err := validateID(id); err != nil {
+		return err
+	}
+
+	if ctx.Err() != nil {
+		return ErrWhileSharing
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.shares[email][id][grantee]; !ok {
+		return ErrShareNotFound
+	}
+
+	delete(m.shares[email][id], grantee)
+	delete(m.shared[grantee], id)
+	return nil
+}
+
+func (m *MemoryRepository) GetShares(ctx context.Context, email Owner, id string) ([]models.Share, error) {
+	if err := validateID(id); err != nil {
+		return nil, err
+	}
+
+	if ctx.Err() != nil {
+		return nil, ErrWhileRetrieving
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	shares := make([]models.Share, 0, len(m.shares[email][id]))
+	for grantee, role := range m.shares[email][id] {
+		shares = append(shares, models.Share{TodoID: id, Owner: email.String(), Grantee: grantee.String(), Role: role})
+	}
+
+	sortShares(shares)
+	return shares, nil
+}
+
+func (m *MemoryRepository) GetSharedWith(ctx context.Context, grantee Owner) ([]models.Share, error) {
+	if ctx.Err() != nil {
+		return nil, ErrWhileRetrieving
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	shares := make([]models.Share, 0, len(m.shared[grantee]))
+	for _, share := range m.shared[grantee] {
+		shares = append(shares, share)
+	}
+
+	sortShares(shares)
+	return shares, nil
+}
+
+func (m *MemoryRepository) GetShare(ctx context.Context, grantee Owner, id string) (models.Share, error) {
+	if err := validateID(id); err != nil {
+		return models.Share{}, err
+	}
+
+	if ctx.Err() != nil {
+		return models.Share{}, ErrWhileRetrieving
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	share, ok := m.shared[grantee][id]
+	if !ok {
+		return models.Share{}, ErrShareNotFound
+	}
+
+	return share, nil
+}
+
+// unshareAll revokes every share of a todo deleted for good. It must be
+// called with the lock held.
+func (m *MemoryRepository) unshareAll(email Owner, id string) {
+	for grantee := range m.shares[email][id] {
+		delete(m.shared[grantee], id)
+	}
+
+	delete(m.shares[email], id)
+}
+
+// cloneTodo copies the pointer fields of a todo so the stored value can't be
+// modified through the one handed to the caller.
+func cloneTodo(todo models.Todo) models.Todo {
+	if todo.CompletedAt != nil {
+		completedAt := *todo.CompletedAt
+		todo.CompletedAt = &completedAt
+	}
+
+	if todo.DeletedAt != nil {
+		deletedAt := *todo.DeletedAt
+		todo.DeletedAt = &deletedAt
+	}
+
+	if todo.ArchivedAt != nil {
+		archivedAt := *todo.ArchivedAt
+		todo.ArchivedAt = &archivedAt
+	}
+
+	return todo
+}
@@ -0,0 +1,144 @@
+package todo
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"todo-app/todo/models"
+)
+
+func TestNewMemoryRepository(t *testing.T) {
+	t.Run("should return a not nil instance", func(t *testing.T) {
+		repository := NewMemoryRepository()
+		assert.NotNil(t, repository)
+		assert.IsType(t, &MemoryRepository{}, repository)
+	})
+}
+
+func TestMemoryRepository_Create(t *testing.T) {
+	t.Run("should return a nil error and a todo with a uuid", func(t *testing.T) {
+		repository := NewMemoryRepository()
+		response, err := repository.Create(context.TODO(), "test@test.test", models.Todo{Name: "name"})
+		assert.NoError(t, err)
+		assert.NotEmpty(t, response.ID)
+	})
+
+	t.Run("should return an error if the context is canceled", func(t *testing.T) {
+		canceled, cancel := context.WithCancel(context.TODO())
+		cancel()
+
+		repository := NewMemoryRepository()
+		response, err := repository.Create(canceled, "test@test.test", models.Todo{Name: "name"})
+		assert.ErrorIs(t, err, ErrWhileCreating)
+		assert.Zero(t, response)
+	})
+
+	t.Run("should be safe for concurrent use", func(t *testing.T) {
+		repository := NewMemoryRepository()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := repository.Create(context.TODO(), "test@test.test", models.Todo{Name: "name"})
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		response, err := repository.GetAll(context.TODO(), "test@test.test", TodoFilter{}, PageRequest{Limit: MaxPageLimit})
+		assert.NoError(t, err)
+		assert.Len(t, response.Todos, 50)
+	})
+}
+
+func TestMemoryRepository_GetAll(t *testing.T) {
+	t.Run("should return an error if the context is canceled", func(t *testing.T) {
+		canceled, cancel := context.WithCancel(context.TODO())
+		cancel()
+
+		repository := NewMemoryRepository()
+		response, err := repository.GetAll(canceled, "test@test.test", TodoFilter{}, PageRequest{})
+		assert.ErrorIs(t, err, ErrWhileRetrieving)
+		assert.Zero(t, response)
+	})
+
+	t.Run("should only return the todos of the given email", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := NewMemoryRepository()
+		_, err := repository.Create(ctx, "test@test.test", models.Todo{Name: "name"})
+		assert.NoError(t, err)
+		_, err = repository.Create(ctx, "other@test.test", models.Todo{Name: "name"})
+		assert.NoError(t, err)
+
+		response, err := repository.GetAll(ctx, "test@test.test", TodoFilter{}, PageRequest{})
+		assert.NoError(t, err)
+		assert.Len(t, response.Todos, 1)
+	})
+}
+
+func TestMemoryRepository_GetByID(t *testing.T) {
+	t.Run("should return an error if the id is invalid", func(t *testing.T) {
+		repository := NewMemoryRepository()
+		response, err := repository.GetByID(context.TODO(), "test@test.test", "invalidid")
+		assert.ErrorIs(t, err, ErrInvalidID)
+		assert.Zero(t, response)
+	})
+
+	t.Run("should return an error if the todo doesn't exist", func(t *testing.T) {
+		repository := NewMemoryRepository()
+		response, err := repository.GetByID(context.TODO(), "test@test.test", "279f4a4e-48dc-4569-83df-8b30ce488599")
+		assert.ErrorIs(t, err, ErrTodoNotFound)
+		assert.Zero(t, response)
+	})
+
+	t.Run("should return a copy of the saved todo", func(t *testing.T) {
+		ctx := context.TODO()
+		completedAt := time.Now()
+		repository := NewMemoryRepository()
+		created, err := repository.Create(ctx, "test@test.test", models.Todo{Name: "name", CompletedAt: &completedAt})
+		assert.NoError(t, err)
+
+		response, err := repository.GetByID(ctx, "test@test.test", created.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, created.ID, response.ID)
+
+		*response.CompletedAt = time.Time{}
+		response, err = repository.GetByID(ctx, "test@test.test", created.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, completedAt, *response.CompletedAt)
+	})
+}
+
+func TestMemoryRepository_Update(t *testing.T) {
+	t.Run("should return an error if the id is invalid", func(t *testing.T) {
+		repository := NewMemoryRepository()
+		response, err := repository.Update(context.TODO(), "test@test.test", "invalidid", models.Todo{})
+		assert.ErrorIs(t, err, ErrInvalidID)
+		assert.Zero(t, response)
+	})
+
+	t.Run("should return an error if the todo doesn't exist", func(t *testing.T) {
+		repository := NewMemoryRepository()
+		response, err := repository.Update(context.TODO(), "test@test.test", "279f4a4e-48dc-4569-83df-8b30ce488599", models.Todo{})
+		assert.ErrorIs(t, err, ErrTodoNotFound)
+		assert.Zero(t, response)
+	})
+
+	t.Run("should return the todo if no error happens", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := NewMemoryRepository()
+		created, err := repository.Create(ctx, "test@test.test", models.Todo{Name: "name"})
+		assert.NoError(t, err)
+
+		response, err := repository.Update(ctx, "test@test.test", created.ID, models.Todo{Name: "updated", Version: created.Version})
+		assert.NoError(t, err)
+		assert.Equal(t, created.ID, response.ID)
+		assert.Equal(t, "updated", response.Name)
+	})
+}
@@ -12,6 +12,8 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
+	todo "todo-app/todo"
 	models "todo-app/todo/models"
 
 	gomock "go.uber.org/mock/gomock"
@@ -40,52 +42,68 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
-// Create mocks base method.
-func (m *MockRepository) Create(arg0 context.Context, arg1 string, arg2 models.Todo) (models.Todo, error) {
+// ArchiveCompleted mocks base method.
+func (m *MockRepository) ArchiveCompleted(arg0 context.Context, arg1, arg2 time.Time) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", arg0, arg1, arg2)
-	ret0, _ := ret[0].(models.Todo)
+	ret := m.ctrl.Call(m, "ArchiveCompleted", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// Create indicates an expected call of Create.
-func (mr *MockRepositoryMockRecorder) Create(arg0, arg1, arg2 any) *gomock.Call {
+// ArchiveCompleted indicates an expected call of ArchiveCompleted.
+func (mr *MockRepositoryMockRecorder) ArchiveCompleted(arg0, arg1, arg2 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRepository)(nil).Create), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchiveCompleted", reflect.TypeOf((*MockRepository)(nil).ArchiveCompleted), arg0, arg1, arg2)
 }
 
-// Delete mocks base method.
-func (m *MockRepository) Delete(arg0 context.Context, arg1, arg2 string) error {
+// Batch mocks base method.
+func (m *MockRepository) Batch(arg0 context.Context, arg1 todo.Owner, arg2 []todo.BatchWrite) ([]todo.BatchResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", arg0, arg1, arg2)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "Batch", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]todo.BatchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// Delete indicates an expected call of Delete.
-func (mr *MockRepositoryMockRecorder) Delete(arg0, arg1, arg2 any) *gomock.Call {
+// Batch indicates an expected call of Batch.
+func (mr *MockRepositoryMockRecorder) Batch(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Batch", reflect.TypeOf((*MockRepository)(nil).Batch), arg0, arg1, arg2)
+}
+
+// Create mocks base method.
+func (m *MockRepository) Create(arg0 context.Context, arg1 todo.Owner, arg2 models.Todo) (models.Todo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.Todo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockRepositoryMockRecorder) Create(arg0, arg1, arg2 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRepository)(nil).Delete), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRepository)(nil).Create), arg0, arg1, arg2)
 }
 
 // GetAll mocks base method.
-func (m *MockRepository) GetAll(arg0 context.Context, arg1 string) ([]models.Todo, error) {
+func (m *MockRepository) GetAll(arg0 context.Context, arg1 todo.Owner, arg2 todo.TodoFilter, arg3 todo.PageRequest) (todo.Page, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAll", arg0, arg1)
-	ret0, _ := ret[0].([]models.Todo)
+	ret := m.ctrl.Call(m, "GetAll", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(todo.Page)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAll indicates an expected call of GetAll.
-func (mr *MockRepositoryMockRecorder) GetAll(arg0, arg1 any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetAll(arg0, arg1, arg2, arg3 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockRepository)(nil).GetAll), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockRepository)(nil).GetAll), arg0, arg1, arg2, arg3)
 }
 
 // GetByID mocks base method.
-func (m *MockRepository) GetByID(arg0 context.Context, arg1, arg2 string) (models.Todo, error) {
+func (m *MockRepository) GetByID(arg0 context.Context, arg1 todo.Owner, arg2 string) (models.Todo, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetByID", arg0, arg1, arg2)
 	ret0, _ := ret[0].(models.Todo)
@@ -99,8 +117,126 @@ func (mr *MockRepositoryMockRecorder) GetByID(arg0, arg1, arg2 any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockRepository)(nil).GetByID), arg0, arg1, arg2)
 }
 
+// GetByIDs mocks base method.
+func (m *MockRepository) GetByIDs(arg0 context.Context, arg1 todo.Owner, arg2 []string) ([]models.Todo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDs", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.Todo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDs indicates an expected call of GetByIDs.
+func (mr *MockRepositoryMockRecorder) GetByIDs(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDs", reflect.TypeOf((*MockRepository)(nil).GetByIDs), arg0, arg1, arg2)
+}
+
+// GetShare mocks base method.
+func (m *MockRepository) GetShare(arg0 context.Context, arg1 todo.Owner, arg2 string) (models.Share, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetShare", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.Share)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetShare indicates an expected call of GetShare.
+func (mr *MockRepositoryMockRecorder) GetShare(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShare", reflect.TypeOf((*MockRepository)(nil).GetShare), arg0, arg1, arg2)
+}
+
+// GetSharedWith mocks base method.
+func (m *MockRepository) GetSharedWith(arg0 context.Context, arg1 todo.Owner) ([]models.Share, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSharedWith", arg0, arg1)
+	ret0, _ := ret[0].([]models.Share)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSharedWith indicates an expected call of GetSharedWith.
+func (mr *MockRepositoryMockRecorder) GetSharedWith(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSharedWith", reflect.TypeOf((*MockRepository)(nil).GetSharedWith), arg0, arg1)
+}
+
+// GetShares mocks base method.
+func (m *MockRepository) GetShares(arg0 context.Context, arg1 todo.Owner, arg2 string) ([]models.Share, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetShares", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.Share)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetShares indicates an expected call of GetShares.
+func (mr *MockRepositoryMockRecorder) GetShares(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShares", reflect.TypeOf((*MockRepository)(nil).GetShares), arg0, arg1, arg2)
+}
+
+// Purge mocks base method.
+func (m *MockRepository) Purge(arg0 context.Context, arg1 time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockRepositoryMockRecorder) Purge(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockRepository)(nil).Purge), arg0, arg1)
+}
+
+// Search mocks base method.
+func (m *MockRepository) Search(arg0 context.Context, arg1 todo.Owner, arg2 []string, arg3 int) ([]models.Todo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]models.Todo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockRepositoryMockRecorder) Search(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockRepository)(nil).Search), arg0, arg1, arg2, arg3)
+}
+
+// Share mocks base method.
+func (m *MockRepository) Share(arg0 context.Context, arg1 todo.Owner, arg2 string, arg3 todo.Owner, arg4 models.Role) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Share", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Share indicates an expected call of Share.
+func (mr *MockRepositoryMockRecorder) Share(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Share", reflect.TypeOf((*MockRepository)(nil).Share), arg0, arg1, arg2, arg3, arg4)
+}
+
+// Unshare mocks base method.
+func (m *MockRepository) Unshare(arg0 context.Context, arg1 todo.Owner, arg2 string, arg3 todo.Owner) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unshare", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unshare indicates an expected call of Unshare.
+func (mr *MockRepositoryMockRecorder) Unshare(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unshare", reflect.TypeOf((*MockRepository)(nil).Unshare), arg0, arg1, arg2, arg3)
+}
+
 // Update mocks base method.
-func (m *MockRepository) Update(arg0 context.Context, arg1, arg2 string, arg3 models.Todo) (models.Todo, error) {
+func (m *MockRepository) Update(arg0 context.Context, arg1 todo.Owner, arg2 string, arg3 models.Todo) (models.Todo, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Update", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(models.Todo)
@@ -12,6 +12,8 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+
+	todo "todo-app/todo"
 	dtos "todo-app/todo/dtos"
 	models "todo-app/todo/models"
 
@@ -41,8 +43,53 @@ func (m *MockService) EXPECT() *MockServiceMockRecorder {
 	return m.recorder
 }
 
+// Archive mocks base method.
+func (m *MockService) Archive(arg0 context.Context, arg1 todo.Owner, arg2 string) (models.Todo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Archive", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.Todo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Archive indicates an expected call of Archive.
+func (mr *MockServiceMockRecorder) Archive(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Archive", reflect.TypeOf((*MockService)(nil).Archive), arg0, arg1, arg2)
+}
+
+// Batch mocks base method.
+func (m *MockService) Batch(arg0 context.Context, arg1 todo.Owner, arg2 []dtos.BatchOperation) ([]todo.BatchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Batch", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]todo.BatchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Batch indicates an expected call of Batch.
+func (mr *MockServiceMockRecorder) Batch(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Batch", reflect.TypeOf((*MockService)(nil).Batch), arg0, arg1, arg2)
+}
+
+// Complete mocks base method.
+func (m *MockService) Complete(arg0 context.Context, arg1 todo.Owner, arg2 string) (models.Todo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Complete", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.Todo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Complete indicates an expected call of Complete.
+func (mr *MockServiceMockRecorder) Complete(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Complete", reflect.TypeOf((*MockService)(nil).Complete), arg0, arg1, arg2)
+}
+
 // Create mocks base method.
-func (m *MockService) Create(arg0 context.Context, arg1 string, arg2 dtos.CreateTodo) (models.Todo, error) {
+func (m *MockService) Create(arg0 context.Context, arg1 todo.Owner, arg2 dtos.CreateTodo) (models.Todo, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Create", arg0, arg1, arg2)
 	ret0, _ := ret[0].(models.Todo)
@@ -57,36 +104,36 @@ func (mr *MockServiceMockRecorder) Create(arg0, arg1, arg2 any) *gomock.Call {
 }
 
 // Delete mocks base method.
-func (m *MockService) Delete(arg0 context.Context, arg1, arg2 string) error {
+func (m *MockService) Delete(arg0 context.Context, arg1 todo.Owner, arg2 string, arg3 int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockServiceMockRecorder) Delete(arg0, arg1, arg2 any) *gomock.Call {
+func (mr *MockServiceMockRecorder) Delete(arg0, arg1, arg2, arg3 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockService)(nil).Delete), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockService)(nil).Delete), arg0, arg1, arg2, arg3)
 }
 
 // GetAll mocks base method.
-func (m *MockService) GetAll(arg0 context.Context, arg1 string) ([]models.Todo, error) {
+func (m *MockService) GetAll(arg0 context.Context, arg1 todo.Owner, arg2 todo.TodoFilter, arg3 todo.PageRequest) (todo.Page, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAll", arg0, arg1)
-	ret0, _ := ret[0].([]models.Todo)
+	ret := m.ctrl.Call(m, "GetAll", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(todo.Page)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAll indicates an expected call of GetAll.
-func (mr *MockServiceMockRecorder) GetAll(arg0, arg1 any) *gomock.Call {
+func (mr *MockServiceMockRecorder) GetAll(arg0, arg1, arg2, arg3 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockService)(nil).GetAll), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockService)(nil).GetAll), arg0, arg1, arg2, arg3)
 }
 
 // GetByID mocks base method.
-func (m *MockService) GetByID(arg0 context.Context, arg1, arg2 string) (models.Todo, error) {
+func (m *MockService) GetByID(arg0 context.Context, arg1 todo.Owner, arg2 string) (models.Todo, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetByID", arg0, arg1, arg2)
 	ret0, _ := ret[0].(models.Todo)
@@ -100,17 +147,151 @@ func (mr *MockServiceMockRecorder) GetByID(arg0, arg1, arg2 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockService)(nil).GetByID), arg0, arg1, arg2)
 }
 
+// GetShares mocks base method.
+func (m *MockService) GetShares(arg0 context.Context, arg1 todo.Owner, arg2 string) ([]models.Share, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetShares", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.Share)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetShares indicates an expected call of GetShares.
+func (mr *MockServiceMockRecorder) GetShares(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShares", reflect.TypeOf((*MockService)(nil).GetShares), arg0, arg1, arg2)
+}
+
+// Patch mocks base method.
+func (m *MockService) Patch(arg0 context.Context, arg1 todo.Owner, arg2 string, arg3 int64, arg4 dtos.PatchTodo) (models.Todo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Patch", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(models.Todo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Patch indicates an expected call of Patch.
+func (mr *MockServiceMockRecorder) Patch(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*MockService)(nil).Patch), arg0, arg1, arg2, arg3, arg4)
+}
+
+// Reopen mocks base method.
+func (m *MockService) Reopen(arg0 context.Context, arg1 todo.Owner, arg2 string) (models.Todo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reopen", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.Todo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reopen indicates an expected call of Reopen.
+func (mr *MockServiceMockRecorder) Reopen(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reopen", reflect.TypeOf((*MockService)(nil).Reopen), arg0, arg1, arg2)
+}
+
+// Restore mocks base method.
+func (m *MockService) Restore(arg0 context.Context, arg1 todo.Owner, arg2 string) (models.Todo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.Todo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockServiceMockRecorder) Restore(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockService)(nil).Restore), arg0, arg1, arg2)
+}
+
+// Search mocks base method.
+func (m *MockService) Search(arg0 context.Context, arg1 todo.Owner, arg2 string, arg3 int) ([]models.Todo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]models.Todo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockServiceMockRecorder) Search(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockService)(nil).Search), arg0, arg1, arg2, arg3)
+}
+
+// Share mocks base method.
+func (m *MockService) Share(arg0 context.Context, arg1 todo.Owner, arg2 string, arg3 todo.Owner, arg4 models.Role) (models.Share, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Share", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(models.Share)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Share indicates an expected call of Share.
+func (mr *MockServiceMockRecorder) Share(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Share", reflect.TypeOf((*MockService)(nil).Share), arg0, arg1, arg2, arg3, arg4)
+}
+
+// Trash mocks base method.
+func (m *MockService) Trash(arg0 context.Context, arg1 todo.Owner, arg2 todo.PageRequest) (todo.Page, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Trash", arg0, arg1, arg2)
+	ret0, _ := ret[0].(todo.Page)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Trash indicates an expected call of Trash.
+func (mr *MockServiceMockRecorder) Trash(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Trash", reflect.TypeOf((*MockService)(nil).Trash), arg0, arg1, arg2)
+}
+
+// Unarchive mocks base method.
+func (m *MockService) Unarchive(arg0 context.Context, arg1 todo.Owner, arg2 string) (models.Todo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unarchive", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.Todo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Unarchive indicates an expected call of Unarchive.
+func (mr *MockServiceMockRecorder) Unarchive(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unarchive", reflect.TypeOf((*MockService)(nil).Unarchive), arg0, arg1, arg2)
+}
+
+// Unshare mocks base method.
+func (m *MockService) Unshare(arg0 context.Context, arg1 todo.Owner, arg2 string, arg3 todo.Owner) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unshare", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unshare indicates an expected call of Unshare.
+func (mr *MockServiceMockRecorder) Unshare(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unshare", reflect.TypeOf((*MockService)(nil).Unshare), arg0, arg1, arg2, arg3)
+}
+
 // Update mocks base method.
-func (m *MockService) Update(arg0 context.Context, arg1, arg2 string, arg3 dtos.UpdateTodo) (models.Todo, error) {
+func (m *MockService) Update(arg0 context.Context, arg1 todo.Owner, arg2 string, arg3 int64, arg4 dtos.UpdateTodo) (models.Todo, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "Update", arg0, arg1, arg2, arg3, arg4)
 	ret0, _ := ret[0].(models.Todo)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockServiceMockRecorder) Update(arg0, arg1, arg2, arg3 any) *gomock.Call {
+func (mr *MockServiceMockRecorder) Update(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockService)(nil).Update), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockService)(nil).Update), arg0, arg1, arg2, arg3, arg4)
 }
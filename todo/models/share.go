@@ -0,0 +1,17 @@
+package models
+
+type Role string
+
+const (
+	RoleViewer Role = "viewer"
+	RoleEditor Role = "editor"
+)
+
+// Share grants the grantee access to a todo of the owner. Viewers can read
+// it, editors can change it too.
+type Share struct {
+	TodoID  string `json:"todo_id"`
+	Owner   string `json:"owner"`
+	Grantee string `json:"grantee"`
+	Role    Role   `json:"role"`
+}
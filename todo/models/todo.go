@@ -2,11 +2,20 @@ package models
 
 import "time"
 
+// Todo is a todo as it's stored. Owner is only set on the todos shared with
+// the user they're returned to, it's never stored.
 type Todo struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	DueDate     time.Time `json:"due_date"`
-	StartDate   time.Time `json:"start_date"`
-	Completed   bool      `json:"completed"`
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	DueDate     time.Time  `json:"due_date"`
+	StartDate   time.Time  `json:"start_date"`
+	Completed   bool       `json:"completed"`
+	CompletedAt *time.Time `json:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at"`
+	ArchivedAt  *time.Time `json:"archived_at"`
+	Version     int64      `json:"version"`
+	Owner       string     `json:"owner,omitempty"`
 }
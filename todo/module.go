@@ -1,14 +1,23 @@
 package todo
 
-import "go.uber.org/fx"
+import (
+	"database/sql"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/fx"
+
+	"todo-app/internal/storage"
+)
 
 var Module = fx.Module(
 	"todo-module",
 	fx.Provide(
 		fx.Private,
+		NewRepository,
 		fx.Annotate(
-			NewRedisRepository,
-			fx.As(new(Repository)),
+			NewSystemClock,
+			fx.As(new(Clock)),
 		),
 	),
 	fx.Provide(
@@ -17,4 +26,24 @@ var Module = fx.Module(
 			fx.As(new(Service)),
 		),
 	),
+	fx.Provide(
+		fx.Private,
+		NewPurger,
+		NewArchiver,
+	),
+	fx.Invoke(MigrateRepository, StartPurger, StartArchiver),
 )
+
+func NewRepository(clients storage.Clients) (Repository, error) {
+	return storage.NewRepository(clients, storage.Repositories[Repository]{
+		Redis:    func(client redis.UniversalClient) Repository { return NewRedisRepository(client) },
+		SQLite:   func(db *sql.DB) Repository { return NewSQLiteRepository(db) },
+		Postgres: func(pool *pgxpool.Pool) Repository { return NewPostgresRepository(pool) },
+		Memory:   func() Repository { return NewMemoryRepository() },
+	})
+}
+
+// MigrateRepository migrates the repository when the app starts.
+func MigrateRepository(repository Repository, lc fx.Lifecycle) {
+	storage.MigrateOnStart(repository, lc)
+}
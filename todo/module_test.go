@@ -0,0 +1,50 @@
+package todo
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"todo-app/config"
+	"todo-app/internal/storage"
+)
+
+func TestNewRepository(t *testing.T) {
+	t.Run("should return the redis repository", func(t *testing.T) {
+		repository, err := NewRepository(storage.Clients{
+			Config: config.Config{Storage: config.RedisStorage},
+			Redis:  redis.NewClient(&redis.Options{}),
+		})
+		assert.NoError(t, err)
+		assert.IsType(t, &RedisRepository{}, repository)
+	})
+
+	t.Run("should return the sqlite repository", func(t *testing.T) {
+		repository, err := NewRepository(storage.Clients{
+			Config: config.Config{Storage: config.SQLiteStorage},
+			SQLite: &sql.DB{},
+		})
+		assert.NoError(t, err)
+		assert.IsType(t, &SQLiteRepository{}, repository)
+	})
+
+	t.Run("should return the postgres repository", func(t *testing.T) {
+		repository, err := NewRepository(storage.Clients{
+			Config:   config.Config{Storage: config.PostgresStorage},
+			Postgres: &pgxpool.Pool{},
+		})
+		assert.NoError(t, err)
+		assert.IsType(t, &PostgresRepository{}, repository)
+	})
+
+	t.Run("should return the memory repository", func(t *testing.T) {
+		repository, err := NewRepository(storage.Clients{
+			Config: config.Config{Storage: config.MemoryStorage},
+		})
+		assert.NoError(t, err)
+		assert.IsType(t, &MemoryRepository{}, repository)
+	})
+}
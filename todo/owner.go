@@ -0,0 +1,92 @@
+package todo
+
+import (
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidOwner = fmt.Errorf("the owner must be a valid email address")
+
+// maxOwnerLength is the longest address that fits in the path of SMTP, and
+// maxLocalLength and maxLabelLength the longest local part and domain label.
+const (
+	maxOwnerLength = 254
+	maxLocalLength = 64
+	maxLabelLength = 63
+)
+
+// Owner is the email address the todos belong to, trimmed and lowercased so
+// the same address always maps to the same todos.
+type Owner string
+
+// NewOwner validates the address of an owner against an allow-list, rather
+// than everything RFC 5322 accepts. The local part is made of letters,
+// digits and . _ % + - ' and the domain of at least two labels of letters,
+// digits and hyphens. This is deliberate: the owners end up in Redis keys,
+// where { } delimit the hash tags and * ? [ ] are globs to SCAN, and in URL
+// paths, where / ? # split them, so the other characters RFC 5322 allows,
+// ! # $ & * / = ? ^ ` { | } ~, are rejected instead of escaped everywhere.
+// Single-label domains like localhost can't receive mail from outside the
+// host and are rejected too.
+func NewOwner(email string) (Owner, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || len(email) > maxOwnerLength || !validLocalPart(local) || !validDomain(domain) {
+		return "", ErrInvalidOwner
+	}
+
+	return Owner(email), nil
+}
+
+func (o Owner) String() string {
+	return string(o)
+}
+
+// validLocalPart accepts dot-separated words of the allowed characters.
+func validLocalPart(local string) bool {
+	if len(local) > maxLocalLength {
+		return false
+	}
+
+	for _, word := range strings.Split(local, ".") {
+		if word == "" {
+			return false
+		}
+
+		for _, c := range word {
+			if !isAlphanumeric(c) && !strings.ContainsRune("_%+-'", c) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// validDomain accepts host names with at least two labels, which don't
+// start or end with a hyphen.
+func validDomain(domain string) bool {
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return false
+	}
+
+	for _, label := range labels {
+		if label == "" || len(label) > maxLabelLength || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+
+		for _, c := range label {
+			if !isAlphanumeric(c) && c != '-' {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// isAlphanumeric only accepts ASCII, the owners are already lowercased.
+func isAlphanumeric(c rune) bool {
+	return c >= 'a' && c <= 'z' || c >= '0' && c <= '9'
+}
@@ -0,0 +1,82 @@
+package todo_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"todo-app/todo"
+)
+
+func TestNewOwner(t *testing.T) {
+	t.Run("should normalize the case and the whitespace", func(t *testing.T) {
+		owner, err := todo.NewOwner("  Bob@Example.COM ")
+		assert.NoError(t, err)
+		assert.Equal(t, todo.Owner("bob@example.com"), owner)
+	})
+
+	t.Run("should accept the allowed characters", func(t *testing.T) {
+		owner, err := todo.NewOwner("bob.o'neil+todos_1%x-y@mail-2.example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, "bob.o'neil+todos_1%x-y@mail-2.example.com", owner.String())
+	})
+
+	for _, email := range []string{
+		"",
+		"bob",
+		"bob@",
+		"@example.com",
+		"bob:*@example.com",
+		"bob@example.com:*",
+		"Bob <bob@example.com>",
+		"<bob@example.com>",
+		`"bob smith"@example.com`,
+		"bob@example.com, alice@example.com",
+		"a*@x.com",
+		"b@x}",
+		"a@b",
+		"a@b@x.com",
+		".bob@example.com",
+		"bob.@example.com",
+		"bob..smith@example.com",
+		"bob@example..com",
+		"bob@-example.com",
+		"bob@example-.com",
+		"bob@exa_mple.com",
+		"bøb@example.com",
+		strings.Repeat("b", 65) + "@example.com",
+		"bob@" + strings.Repeat("e", 64) + ".com",
+		"bob@" + strings.Repeat("example.", 32) + "com",
+	} {
+		t.Run("should reject "+email, func(t *testing.T) {
+			owner, err := todo.NewOwner(email)
+			assert.ErrorIs(t, err, todo.ErrInvalidOwner)
+			assert.Zero(t, owner)
+		})
+	}
+	// RFC 5322 accepts these, the allow-list doesn't on purpose.
+	for _, email := range []string{
+		"a!b@x.com",
+		"a#b@x.com",
+		"a$b@x.com",
+		"a&b@x.com",
+		"a*b@x.com",
+		"a/b@x.com",
+		"a=b@x.com",
+		"a?b@x.com",
+		"a^b@x.com",
+		"a`b@x.com",
+		"a{b}@x.com",
+		"a|b@x.com",
+		"a~b@x.com",
+		"user@localhost",
+		"user@x",
+	} {
+		t.Run("should reject the valid address "+email, func(t *testing.T) {
+			owner, err := todo.NewOwner(email)
+			assert.ErrorIs(t, err, todo.ErrInvalidOwner)
+			assert.Zero(t, owner)
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package todo
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"todo-app/todo/models"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 100
+)
+
+var (
+	ErrInvalidCursor = fmt.Errorf("invalid cursor")
+	ErrInvalidLimit  = fmt.Errorf("limit must be between 1 and %d", MaxPageLimit)
+)
+
+// PageRequest asks for the todos sorted by Sort in Order after Cursor, which
+// is empty for the first page and the NextCursor of the previous page
+// otherwise. Ties are broken by id, so the order is always deterministic.
+type PageRequest struct {
+	Limit  int
+	Cursor string
+	Sort   SortField
+	Order  SortOrder
+}
+
+// Page is a slice of the todos of an email. NextCursor is empty on the last
+// page.
+type Page struct {
+	Todos      []models.Todo
+	NextCursor string
+}
+
+// cursor points to the last todo of a page. It holds the sort it was issued
+// for, so it can't be reused with a different one.
+type cursor struct {
+	Sort  SortField `json:"s"`
+	Order SortOrder `json:"o"`
+	Value string    `json:"v"`
+	ID    string    `json:"id"`
+}
+
+// key is the sortKey of the todo the cursor points to.
+func (c cursor) key() string {
+	return c.Value + "\x00" + c.ID
+}
+
+// arg is the sort value of the cursor in the type of its column.
+func (c cursor) arg() any {
+	if c.Sort == SortByName {
+		return c.Value
+	}
+
+	value, _ := time.Parse(sortTimeLayout, c.Value)
+	return value
+}
+
+func (p PageRequest) limit() int {
+	if p.Limit <= 0 {
+		return DefaultPageLimit
+	}
+
+	return p.Limit
+}
+
+func (p PageRequest) sort() SortField {
+	if p.Sort == "" {
+		return SortByCreatedAt
+	}
+
+	return p.Sort
+}
+
+func (p PageRequest) order() SortOrder {
+	if p.Order == "" {
+		return Ascending
+	}
+
+	return p.Order
+}
+
+// after decodes the cursor of the request, which is nil for the first page.
+func (p PageRequest) after() (*cursor, error) {
+	if err := validateSort(p.sort(), p.order()); err != nil {
+		return nil, err
+	}
+
+	if p.Cursor == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(p.Cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var after cursor
+	if err = json.Unmarshal(data, &after); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if after.Sort != p.sort() || after.Order != p.order() || uuid.Validate(after.ID) != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if after.Sort != SortByName {
+		if _, err = time.Parse(sortTimeLayout, after.Value); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	return &after, nil
+}
+
+// newPage trims todos, which holds up to one more todo than the limit, and
+// points the cursor to the last returned todo if there are more.
+func newPage(todos []models.Todo, page PageRequest) Page {
+	limit := page.limit()
+	if len(todos) <= limit {
+		return Page{Todos: todos}
+	}
+
+	todos = todos[:limit]
+	last := todos[limit-1]
+	data, _ := json.Marshal(cursor{
+		Sort:  page.sort(),
+		Order: page.order(),
+		Value: sortValue(last, page.sort()),
+		ID:    last.ID,
+	})
+
+	return Page{
+		Todos:      todos,
+		NextCursor: base64.RawURLEncoding.EncodeToString(data),
+	}
+}
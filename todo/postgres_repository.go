@@ -0,0 +1,451 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"todo-app/internal/storage"
+	"todo-app/todo/models"
+)
+
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{
+		pool: pool,
+	}
+}
+
+func (p *PostgresRepository) Create(ctx context.Context, email Owner, todo models.Todo) (models.Todo, error) {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return models.Todo{}, ErrWhileCreating
+	}
+	defer tx.Rollback(ctx)
+
+	todo.ID = uuid.NewString()
+	todo.Version = 1
+	if err = p.insert(ctx, tx, email, todo); err != nil {
+		return models.Todo{}, ErrWhileCreating
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return models.Todo{}, ErrWhileCreating
+	}
+
+	return todo, nil
+}
+
+func (p *PostgresRepository) GetAll(ctx context.Context, email Owner, filter TodoFilter, page PageRequest) (Page, error) {
+	after, err := page.after()
+	if err != nil {
+		return Page{}, err
+	}
+
+	// Names are compared byte-wise, like in the other repositories, instead
+	// of following the collation of the database.
+	column := string(page.sort())
+	if page.sort() == SortByName {
+		column = `name COLLATE "C"`
+	}
+
+	query, args := listQuery(column, email, filter, page, after, func(n int) string {
+		return "$" + strconv.Itoa(n)
+	})
+
+	rows, err := p.pool.Query(ctx, query, args...)
+	if err != nil {
+		return Page{}, ErrWhileRetrieving
+	}
+	defer rows.Close()
+
+	var todos []models.Todo
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return Page{}, ErrWhileRetrieving
+		}
+
+		todos = append(todos, todo)
+	}
+
+	if rows.Err() != nil {
+		return Page{}, ErrWhileRetrieving
+	}
+
+	return newPage(todos, page), nil
+}
+
+func (p *PostgresRepository) GetByID(ctx context.Context, email Owner, id string) (models.Todo, error) {
+	if err := validateID(id); err != nil {
+		return models.Todo{}, err
+	}
+
+	row := p.pool.QueryRow(ctx, "SELECT "+todoColumns+" FROM todos WHERE owner = $1 AND id = $2", email, id)
+	todo, err := scanTodo(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Todo{}, ErrTodoNotFound
+	}
+
+	if err != nil {
+		return models.Todo{}, ErrWhileRetrieving
+	}
+
+	return todo, nil
+}
+
+func (p *PostgresRepository) GetByIDs(ctx context.Context, email Owner, ids []string) ([]models.Todo, error) {
+	if err := validateIDs(ids); err != nil {
+		return nil, err
+	}
+
+	todos := make([]models.Todo, 0, len(ids))
+	if len(ids) == 0 {
+		return todos, nil
+	}
+
+	rows, err := p.pool.Query(ctx, "SELECT "+todoColumns+" FROM todos WHERE owner = $1 AND id = ANY($2::uuid[])", email, ids)
+	if err != nil {
+		return nil, ErrWhileRetrieving
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, ErrWhileRetrieving
+		}
+
+		todos = append(todos, todo)
+	}
+
+	if rows.Err() != nil {
+		return nil, ErrWhileRetrieving
+	}
+
+	return todos, nil
+}
+
+func (p *PostgresRepository) Update(ctx context.Context, email Owner, id string, todo models.Todo) (models.Todo, error) {
+	if err := validateID(id); err != nil {
+		return models.Todo{}, err
+	}
+
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return models.Todo{}, ErrWhileUpdating
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(
+		ctx,
+		"UPDATE todos SET name = $1, description = $2, start_date = $3, due_date = $4, completed = $5, completed_at = $6, updated_at = $7, deleted_at = $8, archived_at = $9, version = version + 1 WHERE owner = $10 AND id = $11 AND version = $12 RETURNING created_at, version",
+		todo.Name, todo.Description, todo.StartDate, todo.DueDate, todo.Completed, storage.NullTime(todo.CompletedAt), todo.UpdatedAt, storage.NullTime(todo.DeletedAt), storage.NullTime(todo.ArchivedAt), email, id, todo.Version,
+	).Scan(&todo.CreatedAt, &todo.Version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Todo{}, p.conflict(ctx, tx, email, id, ErrWhileUpdating)
+	}
+
+	if err != nil {
+		return models.Todo{}, ErrWhileUpdating
+	}
+
+	todo.ID = id
+	if _, err = tx.Exec(ctx, "DELETE FROM todo_terms WHERE todo_id = $1", id); err != nil {
+		return models.Todo{}, ErrWhileUpdating
+	}
+
+	if err = p.indexTerms(ctx, tx, email, todo); err != nil {
+		return models.Todo{}, ErrWhileUpdating
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return models.Todo{}, ErrWhileUpdating
+	}
+
+	return todo, nil
+}
+
+func (p *PostgresRepository) Search(ctx context.Context, email Owner, terms []string, limit int) ([]models.Todo, error) {
+	query, args := searchQuery(email, terms, func(n int) string {
+		return "$" + strconv.Itoa(n)
+	})
+
+	rows, err := p.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, ErrWhileRetrieving
+	}
+	defer rows.Close()
+
+	var todos []models.Todo
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, ErrWhileRetrieving
+		}
+
+		todos = append(todos, todo)
+	}
+
+	if rows.Err() != nil {
+		return nil, ErrWhileRetrieving
+	}
+
+	return rank(todos, terms, limit), nil
+}
+
+// Purge relies on the foreign key to remove the search entries of the todos.
+func (p *PostgresRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	tag, err := p.pool.Exec(ctx, "DELETE FROM todos WHERE deleted_at < $1", before)
+	if err != nil {
+		return 0, ErrWhilePurging
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+func (p *PostgresRepository) ArchiveCompleted(ctx context.Context, before time.Time, at time.Time) (int, error) {
+	tag, err := p.pool.Exec(
+		ctx,
+		"UPDATE todos SET archived_at = $1, updated_at = $1, version = version + 1 WHERE completed AND completed_at < $2 AND archived_at IS NULL AND deleted_at IS NULL",
+		at, before,
+	)
+	if err != nil {
+		return 0, ErrWhileArchiving
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+// Batch reads the todos the writes refer to, locking them, and persists the
+// changes in a single transaction.
+func (p *PostgresRepository) Batch(ctx context.Context, email Owner, writes []BatchWrite) ([]BatchResult, error) {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return nil, ErrWhileWriting
+	}
+	defer tx.Rollback(ctx)
+
+	stored := make(map[string]models.Todo)
+	if ids := batchIDs(writes); len(ids) > 0 {
+		rows, err := tx.Query(ctx, "SELECT "+todoColumns+" FROM todos WHERE owner = $1 AND id = ANY($2::uuid[]) FOR UPDATE", email, ids)
+		if err != nil {
+			return nil, ErrWhileWriting
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			todo, err := scanTodo(rows)
+			if err != nil {
+				return nil, ErrWhileWriting
+			}
+
+			stored[todo.ID] = todo
+		}
+
+		if rows.Err() != nil {
+			return nil, ErrWhileWriting
+		}
+	}
+
+	results, changes := planBatch(writes, stored)
+	for _, change := range changes {
+		if err = p.apply(ctx, tx, email, change); err != nil {
+			return nil, ErrWhileWriting
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, ErrWhileWriting
+	}
+
+	return results, nil
+}
+
+// apply persists a change of a batch. The version of the todo is checked
+// again, the rows are locked but a create may have raced the read.
+func (p *PostgresRepository) apply(ctx context.Context, tx pgx.Tx, email Owner, change batchChange) error {
+	if change.before == nil {
+		return p.insert(ctx, tx, email, *change.after)
+	}
+
+	var tag pgconn.CommandTag
+	var err error
+	if change.after == nil {
+		tag, err = tx.Exec(ctx, "DELETE FROM todos WHERE owner = $1 AND id = $2 AND version = $3", email, change.id, change.before.Version)
+	} else {
+		todo := change.after
+		tag, err = tx.Exec(
+			ctx,
+			"UPDATE todos SET name = $1, description = $2, start_date = $3, due_date = $4, completed = $5, completed_at = $6, updated_at = $7, deleted_at = $8, archived_at = $9, version = $10 WHERE owner = $11 AND id = $12 AND version = $13",
+			todo.Name, todo.Description, todo.StartDate, todo.DueDate, todo.Completed, storage.NullTime(todo.CompletedAt), todo.UpdatedAt, storage.NullTime(todo.DeletedAt), storage.NullTime(todo.ArchivedAt), todo.Version, email, change.id, change.before.Version,
+		)
+	}
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrVersionMismatch
+	}
+
+	if change.after == nil {
+		return nil
+	}
+
+	if _, err = tx.Exec(ctx, "DELETE FROM todo_terms WHERE todo_id = $1", change.id); err != nil {
+		return err
+	}
+
+	return p.indexTerms(ctx, tx, email, *change.after)
+}
+
+func (p *PostgresRepository) insert(ctx context.Context, tx pgx.Tx, email Owner, todo models.Todo) error {
+	_, err := tx.Exec(
+		ctx,
+		"INSERT INTO todos (id, owner, name, description, start_date, due_date, completed, completed_at, created_at, updated_at, deleted_at, archived_at, version) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)",
+		todo.ID, email, todo.Name, todo.Description, todo.StartDate, todo.DueDate, todo.Completed, storage.NullTime(todo.CompletedAt), todo.CreatedAt, todo.UpdatedAt, storage.NullTime(todo.DeletedAt), storage.NullTime(todo.ArchivedAt), todo.Version,
+	)
+	if err != nil {
+		return err
+	}
+
+	return p.indexTerms(ctx, tx, email, todo)
+}
+
+// conflict tells why a write matched no rows: the todo doesn't exist or it
+// has another version. fallback is returned if that can't be checked.
+func (p *PostgresRepository) conflict(ctx context.Context, q interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}, email Owner, id string, fallback error) error {
+	var exists bool
+	err := q.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM todos WHERE owner = $1 AND id = $2)", email, id).Scan(&exists)
+	if err != nil {
+		return fallback
+	}
+
+	if !exists {
+		return ErrTodoNotFound
+	}
+
+	return ErrVersionMismatch
+}
+
+// indexTerms adds the words of the todo to the search index. Its entries
+// are removed along with the todo by the foreign key.
+func (p *PostgresRepository) indexTerms(ctx context.Context, tx pgx.Tx, email Owner, todo models.Todo) error {
+	for _, term := range searchTerms(todo) {
+		_, err := tx.Exec(ctx, "INSERT INTO todo_terms (todo_id, owner, term) VALUES ($1, $2, $3)", todo.ID, email, term)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Share only inserts the share if the todo belongs to the email, changing the
+// role if it was already shared with the grantee. The shares are removed
+// along with the todo by the foreign key.
+func (p *PostgresRepository) Share(ctx context.Context, email Owner, id string, grantee Owner, role models.Role) error {
+	if err := validateID(id); err != nil {
+		return err
+	}
+
+	tag, err := p.pool.Exec(
+		ctx,
+		"INSERT INTO todo_shares ("+shareColumns+") SELECT id, owner, $1, $2 FROM todos WHERE owner = $3 AND id = $4 ON CONFLICT (todo_id, grantee) DO UPDATE SET role = excluded.role",
+		grantee, role, email, id,
+	)
+	if err != nil {
+		return ErrWhileSharing
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrTodoNotFound
+	}
+
+	return nil
+}
+
+func (p *PostgresRepository) Unshare(ctx context.Context, email Owner, id string, grantee Owner) error {
+	if err := validateID(id); err != nil {
+		return err
+	}
+
+	tag, err := p.pool.Exec(ctx, "DELETE FROM todo_shares WHERE owner = $1 AND todo_id = $2 AND grantee = $3", email, id, grantee)
+	if err != nil {
+		return ErrWhileSharing
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrShareNotFound
+	}
+
+	return nil
+}
+
+// GetShares and GetSharedWith compare the emails byte-wise, like the other
+// repositories, instead of following the collation of the database.
+func (p *PostgresRepository) GetShares(ctx context.Context, email Owner, id string) ([]models.Share, error) {
+	if err := validateID(id); err != nil {
+		return nil, err
+	}
+
+	return p.queryShares(ctx, `SELECT `+shareColumns+` FROM todo_shares WHERE owner = $1 AND todo_id = $2 ORDER BY grantee COLLATE "C"`, email, id)
+}
+
+func (p *PostgresRepository) GetSharedWith(ctx context.Context, grantee Owner) ([]models.Share, error) {
+	return p.queryShares(ctx, `SELECT `+shareColumns+` FROM todo_shares WHERE grantee = $1 ORDER BY owner COLLATE "C", todo_id`, grantee)
+}
+
+func (p *PostgresRepository) GetShare(ctx context.Context, grantee Owner, id string) (models.Share, error) {
+	if err := validateID(id); err != nil {
+		return models.Share{}, err
+	}
+
+	row := p.pool.QueryRow(ctx, "SELECT "+shareColumns+" FROM todo_shares WHERE grantee = $1 AND todo_id = $2", grantee, id)
+	share, err := scanShare(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Share{}, ErrShareNotFound
+	}
+
+	if err != nil {
+		return models.Share{}, ErrWhileRetrieving
+	}
+
+	return share, nil
+}
+
+func (p *PostgresRepository) queryShares(ctx context.Context, query string, args ...any) ([]models.Share, error) {
+	rows, err := p.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, ErrWhileRetrieving
+	}
+	defer rows.Close()
+
+	shares := []models.Share{}
+	for rows.Next() {
+		share, err := scanShare(rows)
+		if err != nil {
+			return nil, ErrWhileRetrieving
+		}
+
+		shares = append(shares, share)
+	}
+
+	if rows.Err() != nil {
+		return nil, ErrWhileRetrieving
+	}
+
+	return shares, nil
+}
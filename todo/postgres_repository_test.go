@@ -0,0 +1,16 @@
+package todo
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPostgresRepository(t *testing.T) {
+	t.Run("should return a not nil instance", func(t *testing.T) {
+		repository := NewPostgresRepository(&pgxpool.Pool{})
+		assert.NotNil(t, repository)
+		assert.IsType(t, &PostgresRepository{}, repository)
+	})
+}
@@ -0,0 +1,40 @@
+package todo
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/fx"
+
+	"todo-app/config"
+)
+
+// Purger deletes for good the todos that have been in the trash for longer
+// than the retention.
+type Purger struct {
+	repository Repository
+	clock      Clock
+	retention  time.Duration
+}
+
+func NewPurger(repository Repository, clock Clock, configs config.Config) *Purger {
+	return &Purger{
+		repository: repository,
+		clock:      clock,
+		retention:  configs.TrashRetention,
+	}
+}
+
+func (p *Purger) Purge(ctx context.Context) (int, error) {
+	return p.repository.Purge(ctx, p.clock.Now().Add(-p.retention))
+}
+
+// StartPurger runs the purger every TrashPurgeInterval while the app is
+// running, unless the retention or the interval is 0.
+func StartPurger(purger *Purger, configs config.Config, lc fx.Lifecycle) {
+	if configs.TrashRetention == 0 || configs.TrashPurgeInterval == 0 {
+		return
+	}
+
+	startJob(lc, "purging the trash", configs.TrashPurgeInterval, purger.Purge)
+}
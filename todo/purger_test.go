@@ -0,0 +1,80 @@
+package todo_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/mock/gomock"
+
+	"todo-app/config"
+	"todo-app/todo"
+	"todo-app/todo/mocks"
+)
+
+func TestPurger_Purge(t *testing.T) {
+	ctxMatcher := reflect.TypeOf((*context.Context)(nil)).Elem()
+
+	t.Run("should purge the todos deleted before the retention", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			Purge(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(now.Add(-24*time.Hour))).
+			Return(3, nil)
+
+		purger := todo.NewPurger(repository, newClock(ctrl), config.Config{TrashRetention: 24 * time.Hour})
+		purged, err := purger.Purge(context.TODO())
+		assert.NoError(t, err)
+		assert.Equal(t, 3, purged)
+	})
+}
+
+func TestStartPurger(t *testing.T) {
+	t.Run("should purge periodically until the app stops", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		purged := make(chan struct{}, 1)
+		repository.
+			EXPECT().
+			Purge(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(context.Context, time.Time) (int, error) {
+				select {
+				case purged <- struct{}{}:
+				default:
+				}
+				return 0, nil
+			}).
+			MinTimes(1)
+
+		configs := config.Config{TrashRetention: time.Hour, TrashPurgeInterval: time.Millisecond}
+		purger := todo.NewPurger(repository, newClock(ctrl), configs)
+		lc := fxtest.NewLifecycle(t)
+		todo.StartPurger(purger, configs, lc)
+
+		lc.RequireStart()
+		select {
+		case <-purged:
+		case <-time.After(time.Second):
+			t.Fatal("the purger didn't run")
+		}
+		lc.RequireStop()
+	})
+
+	t.Run("should not purge if the interval is 0", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+
+		configs := config.Config{TrashRetention: time.Hour}
+		purger := todo.NewPurger(repository, newClock(ctrl), configs)
+		lc := fxtest.NewLifecycle(t)
+		todo.StartPurger(purger, configs, lc)
+
+		lc.RequireStart()
+		time.Sleep(10 * time.Millisecond)
+		lc.RequireStop()
+	})
+}
@@ -5,54 +5,107 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 
+	"todo-app/internal/storage"
 	"todo-app/todo/models"
 )
 
+// The keys of a user wrap the email in a hash tag, so they share a cluster slot.
 const (
-	redisKey = "todo-%s"
+	redisKey                = "todo-{%s}"             // active todos by id
+	redisSortKey            = "todo-{%s}:%s"          // sortKey of every active todo per sort field
+	redisSearchKey          = "todo-{%s}:search"      // "term\x00id" entries looked up by prefix
+	redisTrashKey           = "todo-{%s}:trash"       // ids of the deleted todos by deletion time
+	redisTrashOwnersKey     = "todo-trash-owners"     // emails that may have deleted todos
+	redisCompletedKey       = "todo-{%s}:completed"   // ids of the unarchived completed todos by completion time
+	redisCompletedOwnersKey = "todo-completed-owners" // emails that may have completed todos
+	redisArchiveKey         = "todo-{%s}:archive"     // archived todos by id
+	redisArchiveSortKey     = "todo-{%s}:archive:%s"  // sortKey of every archived todo per sort field
+	redisSharesKey          = "todo-{%s}:shares:%s"   // role of every grantee of a todo
+	redisSharedKey          = "todo-{%s}:shared"      // shares granted to a user
+	redisIndexedKey         = "todo-{%s}:indexed"     // redisIndexVersion the indexes were built for
+	redisLegacyPattern      = "todo-*"                // hashes Migrate may move
+	redisMigratedKey        = "todo-migrated"         // redisMigrationVersion last run
+)
+
+const (
+	redisScanBatch        = 100 // least number of index entries read at once
+	redisMaxRetries       = 5   // attempts of a transaction aborted by concurrent writes
+	redisIndexVersion     = 1   // increased whenever the indexes change, to rebuild them
+	redisMigrationVersion = 1   // increased whenever Migrate has new keys to upgrade
 )
 
 var (
 	ErrWhileCreating   = fmt.Errorf("error while creating")
 	ErrWhileRetrieving = fmt.Errorf("error while retreving")
-	ErrWhileDeleting   = fmt.Errorf("error while deleting")
 	ErrWhileUpdating   = fmt.Errorf("error while updating")
+	ErrWhileWriting    = fmt.Errorf("error while writing the batch")
+	ErrWhilePurging    = fmt.Errorf("error while purging")
+	ErrWhileArchiving  = fmt.Errorf("error while archiving")
+	ErrWhileSharing    = fmt.Errorf("error while sharing")
+	ErrWhileMigrating  = fmt.Errorf("error while migrating")
 	ErrInvalidID       = fmt.Errorf("invalid id")
 	ErrTodoNotFound    = fmt.Errorf("todo not found")
+	ErrVersionMismatch = fmt.Errorf("the todo was modified by another request")
+	ErrShareNotFound   = fmt.Errorf("share not found")
 )
 
+// Repository stores the todos of every email, repotest holds the behaviour it must share.
+//
 //go:generate mockgen -destination mocks/repository_mock.go -package mocks . Repository
 type Repository interface {
-	Create(ctx context.Context, email string, todo models.Todo) (models.Todo, error)
-	GetAll(ctx context.Context, email string) ([]models.Todo, error)
-	GetByID(ctx context.Context, email string, id string) (models.Todo, error)
-	Delete(ctx context.Context, email string, id string) error
-	Update(ctx context.Context, email string, id string, todo models.Todo) (models.Todo, error)
+	Create(ctx context.Context, email Owner, todo models.Todo) (models.Todo, error)
+	GetAll(ctx context.Context, email Owner, filter TodoFilter, page PageRequest) (Page, error)
+	GetByID(ctx context.Context, email Owner, id string) (models.Todo, error)
+	// GetByIDs reads the todos with the ids at once, in no particular order,
+	// skipping the ones that don't exist.
+	GetByIDs(ctx context.Context, email Owner, ids []string) ([]models.Todo, error)
+	Update(ctx context.Context, email Owner, id string, todo models.Todo) (models.Todo, error)
+	Search(ctx context.Context, email Owner, terms []string, limit int) ([]models.Todo, error)
+	Batch(ctx context.Context, email Owner, writes []BatchWrite) ([]BatchResult, error)
+	Purge(ctx context.Context, before time.Time) (int, error)
+	ArchiveCompleted(ctx context.Context, before time.Time, at time.Time) (int, error)
+	Share(ctx context.Context, email Owner, id string, grantee Owner, role models.Role) error
+	Unshare(ctx context.Context, email Owner, id string, grantee Owner) error
+	GetShares(ctx context.Context, email Owner, id string) ([]models.Share, error)
+	GetSharedWith(ctx context.Context, grantee Owner) ([]models.Share, error)
+	GetShare(ctx context.Context, grantee Owner, id string) (models.Share, error)
 }
 
 type RedisRepository struct {
-	client *redis.Client
+	client redis.UniversalClient
 }
 
-func NewRedisRepository(client *redis.Client) *RedisRepository {
+func NewRedisRepository(client redis.UniversalClient) *RedisRepository {
 	return &RedisRepository{
 		client: client,
 	}
 }
 
-func (r *RedisRepository) Create(ctx context.Context, email string, todo models.Todo) (models.Todo, error) {
+func (r *RedisRepository) Create(ctx context.Context, email Owner, todo models.Todo) (models.Todo, error) {
 	todo.ID = uuid.NewString()
+	todo.Version = 1
 	todoBytes, err := json.Marshal(todo)
 	if err != nil {
 		return models.Todo{}, ErrWhileCreating
 	}
 
-	userKey := fmt.Sprintf(redisKey, email)
-	err = r.client.HSet(ctx, userKey, todo.ID, todoBytes).Err()
+	if err = r.track(ctx, email, todo); err != nil {
+		return models.Todo{}, ErrWhileCreating
+	}
+
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, todoKey(email, todo), todo.ID, todoBytes)
+		addToIndexes(ctx, pipe, email, todo)
+		return nil
+	})
 	if err != nil {
 		return models.Todo{}, ErrWhileCreating
 	}
@@ -60,99 +113,1196 @@ func (r *RedisRepository) Create(ctx context.Context, email string, todo models.
 	return todo, nil
 }
 
-func (r *RedisRepository) GetAll(ctx context.Context, email string) ([]models.Todo, error) {
-	userKey := fmt.Sprintf(redisKey, email)
-	result, err := r.client.HGetAll(ctx, userKey).Result()
+// GetAll lists the active todos and, if they're asked for, the archived
+// ones, merging both in the order of the page.
+func (r *RedisRepository) GetAll(ctx context.Context, email Owner, filter TodoFilter, page PageRequest) (Page, error) {
+	after, err := page.after()
 	if err != nil {
-		return nil, ErrWhileRetrieving
+		return Page{}, err
+	}
+
+	if err = r.ensureIndexed(ctx, email); err != nil {
+		return Page{}, ErrWhileRetrieving
+	}
+
+	field, order := page.sort(), page.order()
+	todos, err := r.scan(ctx, fmt.Sprintf(redisKey, email), fmt.Sprintf(redisSortKey, email, field), filter, page, after)
+	if err != nil {
+		return Page{}, err
+	}
+
+	if filter.IncludeArchived {
+		archived, err := r.scan(ctx, fmt.Sprintf(redisArchiveKey, email), fmt.Sprintf(redisArchiveSortKey, email, field), filter, page, after)
+		if err != nil {
+			return Page{}, err
+		}
+
+		todos = append(todos, archived...)
+		sort.Slice(todos, func(i, j int) bool {
+			return isAfter(sortKey(todos[j], field), sortKey(todos[i], field), order)
+		})
+	}
+
+	if limit := page.limit(); len(todos) > limit+1 {
+		todos = todos[:limit+1]
+	}
+
+	return newPage(todos, page), nil
+}
+
+// scan walks the sort index in batches, keeping the todos of the hash that
+// match the filter until one more than the limit is found.
+func (r *RedisRepository) scan(ctx context.Context, hashKey string, indexKey string, filter TodoFilter, page PageRequest, after *cursor) ([]models.Todo, error) {
+	order := page.order()
+	limit := page.limit()
+	args := redis.ZRangeArgs{
+		Key:   indexKey,
+		Start: "-",
+		Stop:  "+",
+		ByLex: true,
+		Rev:   order == Descending,
+		Count: int64(max(limit+1, redisScanBatch)),
+	}
+
+	last := ""
+	if after != nil {
+		last = after.key()
 	}
 
 	var todos []models.Todo
-	for _, todoString := range result {
-		var todo models.Todo
-		if err = json.Unmarshal([]byte(todoString), &todo); err != nil {
+	for len(todos) <= limit {
+		if last != "" && order == Descending {
+			args.Stop = "(" + last
+		} else if last != "" {
+			args.Start = "(" + last
+		}
+
+		keys, err := r.client.ZRangeArgs(ctx, args).Result()
+		if err != nil {
 			return nil, ErrWhileRetrieving
 		}
 
-		todos = append(todos, todo)
+		if len(keys) == 0 {
+			break
+		}
+
+		ids := make([]string, 0, len(keys))
+		for _, key := range keys {
+			ids = append(ids, key[strings.LastIndexByte(key, 0)+1:])
+		}
+
+		result, err := r.client.HMGet(ctx, hashKey, ids...).Result()
+		if err != nil {
+			return nil, ErrWhileRetrieving
+		}
+
+		for _, value := range result {
+			todoString, ok := value.(string)
+			if !ok {
+				continue
+			}
+
+			todo, err := decode(todoString)
+			if err != nil {
+				return nil, ErrWhileRetrieving
+			}
+
+			if filter.matches(todo) {
+				todos = append(todos, todo)
+			}
+		}
+
+		if int64(len(keys)) < args.Count {
+			break
+		}
+
+		last = keys[len(keys)-1]
 	}
 
 	return todos, nil
 }
 
-func (r *RedisRepository) GetByID(ctx context.Context, email string, id string) (models.Todo, error) {
+func (r *RedisRepository) GetByID(ctx context.Context, email Owner, id string) (models.Todo, error) {
 	if err := validateID(id); err != nil {
 		return models.Todo{}, err
 	}
 
-	userKey := fmt.Sprintf(redisKey, email)
-	result, err := r.client.HGet(ctx, userKey, id).Result()
-	if err != nil && !errors.Is(err, redis.Nil) {
+	values, err := read(ctx, r.client, email, id)
+	if err != nil {
 		return models.Todo{}, ErrWhileRetrieving
 	}
 
-	if errors.Is(err, redis.Nil) {
+	result, ok := values[id]
+	if !ok {
 		return models.Todo{}, ErrTodoNotFound
 	}
 
-	var todo models.Todo
-	if err = json.Unmarshal([]byte(result), &todo); err != nil {
+	todo, err := decode(result)
+	if err != nil {
 		return models.Todo{}, ErrWhileRetrieving
 	}
 
 	return todo, nil
 }
 
-func (r *RedisRepository) Delete(ctx context.Context, email string, id string) error {
+func (r *RedisRepository) GetByIDs(ctx context.Context, email Owner, ids []string) ([]models.Todo, error) {
+	if err := validateIDs(ids); err != nil {
+		return nil, err
+	}
+
+	todos := make([]models.Todo, 0, len(ids))
+	if len(ids) == 0 {
+		return todos, nil
+	}
+
+	values, err := read(ctx, r.client, email, ids...)
+	if err != nil {
+		return nil, ErrWhileRetrieving
+	}
+
+	for _, id := range ids {
+		result, ok := values[id]
+		if !ok {
+			continue
+		}
+
+		todo, err := decode(result)
+		if err != nil {
+			return nil, ErrWhileRetrieving
+		}
+
+		todos = append(todos, todo)
+	}
+
+	return todos, nil
+}
+
+func (r *RedisRepository) Update(ctx context.Context, email Owner, id string, todo models.Todo) (models.Todo, error) {
+	if err := validateID(id); err != nil {
+		return models.Todo{}, err
+	}
+
+	err := r.watch(ctx, email, func(tx *redis.Tx) error {
+		values, err := read(ctx, tx, email, id)
+		if err != nil {
+			return err
+		}
+
+		result, ok := values[id]
+		if !ok {
+			return ErrTodoNotFound
+		}
+
+		stored, err := decode(result)
+		if err != nil {
+			return err
+		}
+
+		if stored.Version != todo.Version {
+			return ErrVersionMismatch
+		}
+
+		todo.ID = id
+		todo.CreatedAt = stored.CreatedAt
+		todo.Version = stored.Version + 1
+		todoBytes, err := json.Marshal(todo)
+		if err != nil {
+			return err
+		}
+
+		if err = r.track(ctx, email, todo); err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HDel(ctx, todoKey(email, stored), id)
+			removeFromIndexes(ctx, pipe, email, stored)
+			pipe.HSet(ctx, todoKey(email, todo), id, todoBytes)
+			addToIndexes(ctx, pipe, email, todo)
+			return nil
+		})
+		return err
+	})
+	if errors.Is(err, ErrTodoNotFound) || errors.Is(err, ErrVersionMismatch) {
+		return models.Todo{}, err
+	}
+
+	if err != nil {
+		return models.Todo{}, ErrWhileUpdating
+	}
+
+	return todo, nil
+}
+
+// Batch reads the todos the writes refer to and persists the changes in a
+// single transaction, retried as a whole if any todo of the email changes
+// meanwhile.
+func (r *RedisRepository) Batch(ctx context.Context, email Owner, writes []BatchWrite) ([]BatchResult, error) {
+	var results []BatchResult
+	var grants map[string][]string
+	err := r.watch(ctx, email, func(tx *redis.Tx) error {
+		stored := make(map[string]models.Todo)
+		if ids := batchIDs(writes); len(ids) > 0 {
+			values, err := read(ctx, tx, email, ids...)
+			if err != nil {
+				return err
+			}
+
+			for id, todoString := range values {
+				todo, err := decode(todoString)
+				if err != nil {
+					return err
+				}
+
+				stored[id] = todo
+			}
+		}
+
+		var changes []batchChange
+		results, changes = planBatch(writes, stored)
+		if len(changes) == 0 {
+			return nil
+		}
+
+		var deleted []string
+		for _, change := range changes {
+			if change.after == nil {
+				deleted = append(deleted, change.id)
+				continue
+			}
+
+			if err := r.track(ctx, email, *change.after); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		if grants, err = grantees(ctx, tx, email, deleted...); err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, change := range changes {
+				if change.before != nil {
+					pipe.HDel(ctx, todoKey(email, *change.before), change.id)
+					removeFromIndexes(ctx, pipe, email, *change.before)
+				}
+
+				if change.after == nil {
+					pipe.Del(ctx, fmt.Sprintf(redisSharesKey, email, change.id))
+					continue
+				}
+
+				todoBytes, err := json.Marshal(change.after)
+				if err != nil {
+					return err
+				}
+
+				pipe.HSet(ctx, todoKey(email, *change.after), change.id, todoBytes)
+				addToIndexes(ctx, pipe, email, *change.after)
+			}
+			return nil
+		})
+		return err
+	})
+	if err != nil || r.revoke(ctx, grants) != nil {
+		return nil, ErrWhileWriting
+	}
+
+	return results, nil
+}
+
+// Purge goes through the trash of every email that had deleted todos. The
+// emails are kept in the set, another todo may be deleted meanwhile.
+func (r *RedisRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	emails, err := r.client.SMembers(ctx, redisTrashOwnersKey).Result()
+	if err != nil {
+		return 0, ErrWhilePurging
+	}
+
+	var purged int
+	for _, email := range emails {
+		for {
+			entries, count, err := r.purge(ctx, Owner(email), before)
+			if err != nil {
+				return purged, ErrWhilePurging
+			}
+
+			purged += count
+			if entries < redisScanBatch {
+				break
+			}
+		}
+	}
+
+	return purged, nil
+}
+
+// purge removes up to redisScanBatch entries of the trash of the email,
+// returning how many it read and how many todos it deleted.
+func (r *RedisRepository) purge(ctx context.Context, email Owner, before time.Time) (int, int, error) {
+	trashKey := fmt.Sprintf(redisTrashKey, email)
+	var ids []string
+	var purged int
+	var grants map[string][]string
+	err := r.watch(ctx, email, func(tx *redis.Tx) error {
+		var err error
+		purged = 0
+		ids, err = tx.ZRangeByScore(ctx, trashKey, &redis.ZRangeBy{
+			Min:   "-inf",
+			Max:   "(" + strconv.FormatInt(before.UnixMilli(), 10),
+			Count: redisScanBatch,
+		}).Result()
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		values, err := read(ctx, tx, email, ids...)
+		if err != nil {
+			return err
+		}
+
+		if grants, err = grantees(ctx, tx, email, ids...); err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, id := range ids {
+				pipe.ZRem(ctx, trashKey, id)
+				todoString, ok := values[id]
+				if !ok {
+					continue
+				}
+
+				purged++
+				pipe.Del(ctx, fmt.Sprintf(redisSharesKey, email, id))
+				todo, err := decode(todoString)
+				if err != nil {
+					pipe.HDel(ctx, fmt.Sprintf(redisKey, email), id)
+					pipe.HDel(ctx, fmt.Sprintf(redisArchiveKey, email), id)
+					continue
+				}
+
+				pipe.HDel(ctx, todoKey(email, todo), id)
+				removeFromIndexes(ctx, pipe, email, todo)
+			}
+			return nil
+		})
+		return err
+	})
+	if err == nil {
+		err = r.revoke(ctx, grants)
+	}
+
+	return len(ids), purged, err
+}
+
+// ArchiveCompleted goes through the completed todos of every email that had
+// any, which are kept in the set like the ones of the trash. The todos
+// completed before the index existed are added to it by Migrate.
+func (r *RedisRepository) ArchiveCompleted(ctx context.Context, before time.Time, at time.Time) (int, error) {
+	emails, err := r.client.SMembers(ctx, redisCompletedOwnersKey).Result()
+	if err != nil {
+		return 0, ErrWhileArchiving
+	}
+
+	var archived int
+	for _, email := range emails {
+		for {
+			entries, count, err := r.archive(ctx, Owner(email), before, at)
+			if err != nil {
+				return archived, ErrWhileArchiving
+			}
+
+			archived += count
+			if entries < redisScanBatch {
+				break
+			}
+		}
+	}
+
+	return archived, nil
+}
+
+// archive moves up to redisScanBatch todos of the email completed before the
+// time to the archive, returning how many entries of the index it read and
+// how many todos it archived.
+func (r *RedisRepository) archive(ctx context.Context, email Owner, before time.Time, at time.Time) (int, int, error) {
+	completedKey := fmt.Sprintf(redisCompletedKey, email)
+	var ids []string
+	var archived int
+	err := r.watch(ctx, email, func(tx *redis.Tx) error {
+		var err error
+		archived = 0
+		ids, err = tx.ZRangeByScore(ctx, completedKey, &redis.ZRangeBy{
+			Min:   "-inf",
+			Max:   "(" + strconv.FormatInt(before.UnixMilli(), 10),
+			Count: redisScanBatch,
+		}).Result()
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		values, err := read(ctx, tx, email, ids...)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, id := range ids {
+				pipe.ZRem(ctx, completedKey, id)
+				stored, err := decode(values[id])
+				if err != nil || !awaitsArchive(stored) {
+					continue
+				}
+
+				todo := stored
+				todo.ArchivedAt = &at
+				todo.UpdatedAt = at
+				todo.Version++
+				todoBytes, err := json.Marshal(todo)
+				if err != nil {
+					return err
+				}
+
+				pipe.HDel(ctx, todoKey(email, stored), id)
+				removeFromIndexes(ctx, pipe, email, stored)
+				pipe.HSet(ctx, todoKey(email, todo), id, todoBytes)
+				addToIndexes(ctx, pipe, email, todo)
+				archived++
+			}
+			return nil
+		})
+		return err
+	})
+
+	return len(ids), archived, err
+}
+
+// Migrate upgrades the keys written by older versions, it runs when the app
+// starts. The todos and shares of the legacy hashes, and of the owners
+// written before they were normalized, are moved to the current ones, which
+// keep what they already have. The hashes of owners that aren't valid
+// emails are left alone, no request can reach them. Then the indexes of
+// every email that aren't up to date are rebuilt, so the jobs find their
+// todos. redisMigratedKey records that it's done, so it's skipped on the
+// next starts.
+func (r *RedisRepository) Migrate(ctx context.Context) error {
+	version, err := r.client.Get(ctx, redisMigratedKey).Int()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("%w: %w", ErrWhileMigrating, err)
+	}
+
+	if version >= redisMigrationVersion {
+		return nil
+	}
+
+	keys, err := storage.ScanRedisKeys(ctx, r.client, redisLegacyPattern, "hash")
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrWhileMigrating, err)
+	}
+
+	// The emails whose todos were moved are reindexed even if they were
+	// indexed before, the todos merged into their hashes aren't.
+	var emails []Owner
+	moved := make(map[Owner]bool)
+	for _, key := range keys {
+		hash, ok := parseHash(key)
+		if !ok {
+			continue
+		}
+
+		if err = r.move(ctx, key, hash.current, hash.convert); err != nil {
+			return fmt.Errorf("%w: moving %s: %w", ErrWhileMigrating, key, err)
+		}
+
+		if !hash.todos {
+			continue
+		}
+
+		if hash.owner != hash.email.String() {
+			if err = r.client.Del(ctx, indexKeys(Owner(hash.owner))...).Err(); err != nil {
+				return fmt.Errorf("%w: removing the indexes of %s: %w", ErrWhileMigrating, hash.owner, err)
+			}
+		}
+
+		if _, ok := moved[hash.email]; !ok {
+			emails = append(emails, hash.email)
+		}
+		moved[hash.email] = moved[hash.email] || key != hash.current
+	}
+
+	for _, email := range emails {
+		index := r.ensureIndexed
+		if moved[email] {
+			index = r.reindex
+		}
+
+		if err = index(ctx, email); err != nil {
+			return fmt.Errorf("%w: indexing the todos of %s: %w", ErrWhileMigrating, email, err)
+		}
+	}
+
+	if err = r.client.Set(ctx, redisMigratedKey, redisMigrationVersion, 0).Err(); err != nil {
+		return fmt.Errorf("%w: %w", ErrWhileMigrating, err)
+	}
+
+	return nil
+}
+
+// redisHash is a hash of todos or shares found by Migrate. owner is the
+// email as it's written in the key, and current the key the hash belongs in
+// with the normalized email. convert normalizes the fields and values of the
+// shares, the todos are moved as they are.
+type redisHash struct {
+	owner   string
+	email   Owner
+	current string
+	todos   bool
+	convert func(field string, value string) (string, string)
+}
+
+// parseHash reads the hash of active or archived todos, of the legacy ones,
+// or of the shares of a todo or a grantee, with the key. The other hashes,
+// and the ones whose owner isn't a valid email, are left out.
+func parseHash(key string) (redisHash, bool) {
+	rest, ok := strings.CutPrefix(key, "todo-")
+	if !ok {
+		return redisHash{}, false
+	}
+
+	hash := redisHash{owner: rest, todos: true}
+	suffix := ""
+	if strings.HasPrefix(rest, "{") {
+		end := strings.LastIndexByte(rest, '}')
+		if end < 0 {
+			return redisHash{}, false
+		}
+
+		hash.owner, suffix = rest[1:end], rest[end+1:]
+	}
+
+	email, err := NewOwner(hash.owner)
+	if err != nil {
+		return redisHash{}, false
+	}
+
+	hash.email = email
+	switch id, shares := strings.CutPrefix(suffix, ":shares:"); {
+	case suffix == "":
+		hash.current = fmt.Sprintf(redisKey, email)
+	case suffix == ":archive":
+		hash.current = fmt.Sprintf(redisArchiveKey, email)
+	case shares:
+		hash.current, hash.todos, hash.convert = fmt.Sprintf(redisSharesKey, email, id), false, normalizeGrantee
+	case suffix == ":shared":
+		hash.current, hash.todos, hash.convert = fmt.Sprintf(redisSharedKey, email), false, normalizeShare
+	default:
+		return redisHash{}, false
+	}
+
+	return hash, true
+}
+
+// normalizeGrantee normalizes the grantee of a field of the shares of a
+// todo.
+func normalizeGrantee(grantee string, role string) (string, string) {
+	if owner, err := NewOwner(grantee); err == nil {
+		return owner.String(), role
+	}
+
+	return grantee, role
+}
+
+// normalizeShare normalizes the emails of a share granted to a user.
+func normalizeShare(id string, value string) (string, string) {
+	var share models.Share
+	if err := json.Unmarshal([]byte(value), &share); err != nil {
+		return id, value
+	}
+
+	for _, email := range []*string{&share.Owner, &share.Grantee} {
+		if owner, err := NewOwner(*email); err == nil {
+			*email = owner.String()
+		}
+	}
+
+	shareBytes, err := json.Marshal(share)
+	if err != nil {
+		return id, value
+	}
+
+	return id, string(shareBytes)
+}
+
+// ensureIndexed rebuilds the indexes of the email unless they're up to date,
+// so the todos stored before they existed are listed too.
+func (r *RedisRepository) ensureIndexed(ctx context.Context, email Owner) error {
+	version, err := r.client.Get(ctx, fmt.Sprintf(redisIndexedKey, email)).Int()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	if version == redisIndexVersion {
+		return nil
+	}
+
+	return r.reindex(ctx, email)
+}
+
+// reindex rebuilds every index of the email from its hashes. The todos that
+// can't be decoded are indexed by id alone, so listing them fails like it
+// does for the rest.
+func (r *RedisRepository) reindex(ctx context.Context, email Owner) error {
+	return r.watch(ctx, email, func(tx *redis.Tx) error {
+		activeKey, archiveKey := fmt.Sprintf(redisKey, email), fmt.Sprintf(redisArchiveKey, email)
+		var todos []models.Todo
+		for _, key := range []string{activeKey, archiveKey} {
+			values, err := tx.HGetAll(ctx, key).Result()
+			if err != nil {
+				return err
+			}
+
+			for id, todoString := range values {
+				todo, _ := decode(todoString)
+
+				todo.ID = id
+				if key == archiveKey && todo.ArchivedAt == nil {
+					todo.ArchivedAt = &time.Time{}
+				}
+
+				if err = r.track(ctx, email, todo); err != nil {
+					return err
+				}
+
+				todos = append(todos, todo)
+			}
+		}
+
+		_, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, indexKeys(email)...)
+
+			for _, todo := range todos {
+				addToIndexes(ctx, pipe, email, todo)
+			}
+			pipe.Set(ctx, fmt.Sprintf(redisIndexedKey, email), redisIndexVersion, 0)
+			return nil
+		})
+		return err
+	})
+}
+
+// indexKeys are the keys reindex rebuilds from the hashes of the email.
+func indexKeys(email Owner) []string {
+	keys := []string{
+		fmt.Sprintf(redisSearchKey, email),
+		fmt.Sprintf(redisTrashKey, email),
+		fmt.Sprintf(redisCompletedKey, email),
+	}
+	for _, field := range sortFields {
+		keys = append(keys, fmt.Sprintf(redisSortKey, email, field), fmt.Sprintf(redisArchiveSortKey, email, field))
+	}
+
+	return keys
+}
+
+// move copies the fields of a hash to another one, converted by convert,
+// unless it already has them, and then removes the ones left behind from
+// the first. The fields kept in the same hash are overwritten if their
+// value changed. Without convert the fields are moved as they are. The
+// first hash is watched, so the fields written to it meanwhile are copied
+// again rather than removed.
+func (r *RedisRepository) move(ctx context.Context, from string, to string, convert func(field string, value string) (string, string)) error {
+	if convert == nil {
+		if from == to {
+			return nil
+		}
+
+		convert = func(field string, value string) (string, string) {
+			return field, value
+		}
+	}
+
+	copied := make(map[string]string)
+	for i := 0; i < redisMaxRetries; i++ {
+		err := r.client.Watch(ctx, func(tx *redis.Tx) error {
+			return r.moveWatched(ctx, tx, from, to, convert, copied)
+		}, from)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+
+	return redis.TxFailedErr
+}
+
+// moveWatched is an attempt of move. Both hashes may be in different slots,
+// so the fields are copied to the second one before the transaction that
+// removes them from the first. copied holds what the previous attempts
+// wrote, which is overwritten if the field changed since.
+func (r *RedisRepository) moveWatched(ctx context.Context, tx *redis.Tx, from string, to string, convert func(field string, value string) (string, string), copied map[string]string) error {
+	values, err := tx.HGetAll(ctx, from).Result()
+	if err != nil || len(values) == 0 {
+		return err
+	}
+
+	rewritten := make(map[string]string)
+	pending := make(map[string]string)
+	var moved []string
+	for field, value := range values {
+		newField, newValue := convert(field, value)
+		switch {
+		case from == to && newField == field:
+			if newValue != value {
+				rewritten[field] = newValue
+			}
+			continue
+		case from == to:
+			if _, ok := values[newField]; !ok {
+				rewritten[newField] = newValue
+			}
+		default:
+			pending[newField] = newValue
+		}
+		moved = append(moved, field)
+	}
+
+	if len(pending) > 0 {
+		cmds := make(map[string]*redis.BoolCmd, len(pending))
+		_, err = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for field, value := range pending {
+				if previous, ok := copied[field]; !ok {
+					cmds[field] = pipe.HSetNX(ctx, to, field, value)
+				} else if previous != value {
+					pipe.HSet(ctx, to, field, value)
+					copied[field] = value
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for field, cmd := range cmds {
+			if cmd.Val() {
+				copied[field] = pending[field]
+			}
+		}
+	}
+
+	if len(rewritten) == 0 && len(moved) == 0 {
+		return nil
+	}
+
+	_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for field, value := range rewritten {
+			pipe.HSet(ctx, from, field, value)
+		}
+		if len(moved) > 0 {
+			pipe.HDel(ctx, from, moved...)
+		}
+		return nil
+	})
+	return err
+}
+
+// track records the email among the ones to purge or archive if the todo is
+// deleted or waits to be archived. It's written before the todo, so the jobs
+// can't miss it.
+func (r *RedisRepository) track(ctx context.Context, email Owner, todo models.Todo) error {
+	if todo.DeletedAt != nil {
+		if err := r.client.SAdd(ctx, redisTrashOwnersKey, email.String()).Err(); err != nil {
+			return err
+		}
+	}
+
+	if awaitsArchive(todo) {
+		return r.client.SAdd(ctx, redisCompletedOwnersKey, email.String()).Err()
+	}
+
+	return nil
+}
+
+// watch runs fn in an optimistic transaction on the hashes of the email. The
+// transaction is retried if any todo of the email changed before it
+// committed, fn checks the version of the one it's changing.
+func (r *RedisRepository) watch(ctx context.Context, email Owner, fn func(tx *redis.Tx) error) error {
+	keys := []string{fmt.Sprintf(redisKey, email), fmt.Sprintf(redisArchiveKey, email)}
+	for i := 0; i < redisMaxRetries; i++ {
+		err := r.client.Watch(ctx, fn, keys...)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+
+	return redis.TxFailedErr
+}
+
+// read gets the todos with the ids as they're stored, looking in the archive
+// for the ones that aren't active. Missing todos are left out.
+func read(ctx context.Context, client redis.Cmdable, email Owner, ids ...string) (map[string]string, error) {
+	values := make(map[string]string, len(ids))
+	missing := ids
+	for _, key := range []string{fmt.Sprintf(redisKey, email), fmt.Sprintf(redisArchiveKey, email)} {
+		if len(missing) == 0 {
+			break
+		}
+
+		result, err := client.HMGet(ctx, key, missing...).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		var next []string
+		for i, value := range result {
+			if todoString, ok := value.(string); ok {
+				values[missing[i]] = todoString
+			} else {
+				next = append(next, missing[i])
+			}
+		}
+
+		missing = next
+	}
+
+	return values, nil
+}
+
+// grantees reads who the todos with the ids are shared with, by id. The ones
+// that aren't shared are left out.
+func grantees(ctx context.Context, client redis.Cmdable, email Owner, ids ...string) (map[string][]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	cmds := make([]*redis.StringSliceCmd, len(ids))
+	_, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range ids {
+			cmds[i] = pipe.HKeys(ctx, fmt.Sprintf(redisSharesKey, email, id))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	grants := make(map[string][]string)
+	for i, cmd := range cmds {
+		if len(cmd.Val()) > 0 {
+			grants[ids[i]] = cmd.Val()
+		}
+	}
+
+	return grants, nil
+}
+
+// revoke removes the grants of the todos deleted for good from the shares of
+// their grantees. The grantees are in other slots, so it runs once the todos
+// are gone, and the grants left behind if it fails lead nowhere.
+func (r *RedisRepository) revoke(ctx context.Context, grants map[string][]string) error {
+	if len(grants) == 0 {
+		return nil
+	}
+
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for id, grantees := range grants {
+			for _, grantee := range grantees {
+				pipe.HDel(ctx, fmt.Sprintf(redisSharedKey, grantee), id)
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+// todoKey is the hash the todo is stored in.
+func todoKey(email Owner, todo models.Todo) string {
+	if todo.ArchivedAt != nil {
+		return fmt.Sprintf(redisArchiveKey, email)
+	}
+
+	return fmt.Sprintf(redisKey, email)
+}
+
+// Search looks every term up in the search index by prefix, and ranks the
+// todos found for all of them.
+func (r *RedisRepository) Search(ctx context.Context, email Owner, terms []string, limit int) ([]models.Todo, error) {
+	if err := r.ensureIndexed(ctx, email); err != nil {
+		return nil, ErrWhileRetrieving
+	}
+
+	searchKey := fmt.Sprintf(redisSearchKey, email)
+
+	var ids map[string]bool
+	for _, term := range terms {
+		entries, err := r.client.ZRangeByLex(ctx, searchKey, &redis.ZRangeBy{
+			Min: "[" + term,
+			Max: "(" + term + "\xff",
+		}).Result()
+		if err != nil {
+			return nil, ErrWhileRetrieving
+		}
+
+		found := make(map[string]bool, len(entries))
+		for _, entry := range entries {
+			id := entry[strings.LastIndexByte(entry, 0)+1:]
+			if ids == nil || ids[id] {
+				found[id] = true
+			}
+		}
+
+		ids = found
+		if len(ids) == 0 {
+			return nil, nil
+		}
+	}
+
+	fields := make([]string, 0, len(ids))
+	for id := range ids {
+		fields = append(fields, id)
+	}
+
+	result, err := r.client.HMGet(ctx, fmt.Sprintf(redisKey, email), fields...).Result()
+	if err != nil {
+		return nil, ErrWhileRetrieving
+	}
+
+	todos := make([]models.Todo, 0, len(result))
+	for _, value := range result {
+		todoString, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		todo, err := decode(todoString)
+		if err != nil {
+			return nil, ErrWhileRetrieving
+		}
+
+		if todo.DeletedAt == nil {
+			todos = append(todos, todo)
+		}
+	}
+
+	return rank(todos, terms, limit), nil
+}
+
+// Share writes the role to the grantees of the todo first, checking it
+// exists, and then to the shares of the grantee, which is in another slot.
+func (r *RedisRepository) Share(ctx context.Context, email Owner, id string, grantee Owner, role models.Role) error {
 	if err := validateID(id); err != nil {
 		return err
 	}
 
-	userKey := fmt.Sprintf(redisKey, email)
-	exists, err := r.client.HExists(ctx, userKey, id).Result()
+	shareBytes, err := json.Marshal(models.Share{TodoID: id, Owner: email.String(), Grantee: grantee.String(), Role: role})
 	if err != nil {
-		return ErrWhileDeleting
+		return ErrWhileSharing
 	}
 
-	if !exists {
-		return ErrTodoNotFound
+	err = r.watch(ctx, email, func(tx *redis.Tx) error {
+		values, err := read(ctx, tx, email, id)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := values[id]; !ok {
+			return ErrTodoNotFound
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, fmt.Sprintf(redisSharesKey, email, id), grantee.String(), string(role))
+			return nil
+		})
+		return err
+	})
+	if errors.Is(err, ErrTodoNotFound) {
+		return err
 	}
 
-	err = r.client.HDel(ctx, userKey, id).Err()
 	if err != nil {
-		return ErrWhileDeleting
+		return ErrWhileSharing
+	}
+
+	if err = r.client.HSet(ctx, fmt.Sprintf(redisSharedKey, grantee), id, shareBytes).Err(); err != nil {
+		return ErrWhileSharing
 	}
 
 	return nil
 }
 
-func (r *RedisRepository) Update(ctx context.Context, email string, id string, todo models.Todo) (models.Todo, error) {
+// Unshare revokes the share from the grantee before removing it from the
+// grantees of the todo, so it can't be left granted without being listed.
+func (r *RedisRepository) Unshare(ctx context.Context, email Owner, id string, grantee Owner) error {
 	if err := validateID(id); err != nil {
-		return models.Todo{}, err
+		return err
 	}
 
-	userKey := fmt.Sprintf(redisKey, email)
-	exists, err := r.client.HExists(ctx, userKey, id).Result()
+	sharesKey := fmt.Sprintf(redisSharesKey, email, id)
+	shared, err := r.client.HExists(ctx, sharesKey, grantee.String()).Result()
 	if err != nil {
-		return models.Todo{}, ErrWhileUpdating
+		return ErrWhileSharing
 	}
 
-	if !exists {
-		return models.Todo{}, ErrTodoNotFound
+	if !shared {
+		return ErrShareNotFound
 	}
 
-	todoBytes, err := json.Marshal(todo)
+	if err = r.client.HDel(ctx, fmt.Sprintf(redisSharedKey, grantee), id).Err(); err != nil {
+		return ErrWhileSharing
+	}
+
+	if err = r.client.HDel(ctx, sharesKey, grantee.String()).Err(); err != nil {
+		return ErrWhileSharing
+	}
+
+	return nil
+}
+
+func (r *RedisRepository) GetShares(ctx context.Context, email Owner, id string) ([]models.Share, error) {
+	if err := validateID(id); err != nil {
+		return nil, err
+	}
+
+	roles, err := r.client.HGetAll(ctx, fmt.Sprintf(redisSharesKey, email, id)).Result()
 	if err != nil {
-		return models.Todo{}, ErrWhileUpdating
+		return nil, ErrWhileRetrieving
 	}
 
-	err = r.client.HSet(ctx, userKey, id, todoBytes).Err()
+	shares := make([]models.Share, 0, len(roles))
+	for grantee, role := range roles {
+		shares = append(shares, models.Share{TodoID: id, Owner: email.String(), Grantee: grantee, Role: models.Role(role)})
+	}
+
+	sortShares(shares)
+	return shares, nil
+}
+
+func (r *RedisRepository) GetSharedWith(ctx context.Context, grantee Owner) ([]models.Share, error) {
+	values, err := r.client.HGetAll(ctx, fmt.Sprintf(redisSharedKey, grantee)).Result()
 	if err != nil {
-		return models.Todo{}, ErrWhileUpdating
+		return nil, ErrWhileRetrieving
+	}
+
+	shares := make([]models.Share, 0, len(values))
+	for _, value := range values {
+		var share models.Share
+		if err = json.Unmarshal([]byte(value), &share); err != nil {
+			return nil, ErrWhileRetrieving
+		}
+
+		shares = append(shares, share)
+	}
+
+	sortShares(shares)
+	return shares, nil
+}
+
+func (r *RedisRepository) GetShare(ctx context.Context, grantee Owner, id string) (models.Share, error) {
+	if err := validateID(id); err != nil {
+		return models.Share{}, err
 	}
 
-	todo.ID = id
-	return todo, err
+	result, err := r.client.HGet(ctx, fmt.Sprintf(redisSharedKey, grantee), id).Result()
+	if errors.Is(err, redis.Nil) {
+		return models.Share{}, ErrShareNotFound
+	}
+
+	if err != nil {
+		return models.Share{}, ErrWhileRetrieving
+	}
+
+	var share models.Share
+	if err = json.Unmarshal([]byte(result), &share); err != nil {
+		return models.Share{}, ErrWhileRetrieving
+	}
+
+	return share, nil
+}
+
+// addToIndexes adds the todo to the sort indexes of its hash, the trash or
+// the completed todos to archive, and the search index unless it's archived.
+func addToIndexes(ctx context.Context, pipe redis.Pipeliner, email Owner, todo models.Todo) {
+	for _, field := range sortFields {
+		pipe.ZAdd(ctx, sortIndexKey(email, todo, field), redis.Z{Member: sortKey(todo, field)})
+	}
+
+	if todo.DeletedAt != nil {
+		pipe.ZAdd(ctx, fmt.Sprintf(redisTrashKey, email), redis.Z{Score: float64(todo.DeletedAt.UnixMilli()), Member: todo.ID})
+	}
+
+	if awaitsArchive(todo) {
+		pipe.ZAdd(ctx, fmt.Sprintf(redisCompletedKey, email), redis.Z{Score: float64(todo.CompletedAt.UnixMilli()), Member: todo.ID})
+	}
+
+	if todo.ArchivedAt != nil {
+		return
+	}
+
+	terms := searchTerms(todo)
+	if len(terms) == 0 {
+		return
+	}
+
+	entries := make([]redis.Z, 0, len(terms))
+	for _, term := range terms {
+		entries = append(entries, redis.Z{Member: term + "\x00" + todo.ID})
+	}
+	pipe.ZAdd(ctx, fmt.Sprintf(redisSearchKey, email), entries...)
+}
+
+func removeFromIndexes(ctx context.Context, pipe redis.Pipeliner, email Owner, todo models.Todo) {
+	for _, field := range sortFields {
+		pipe.ZRem(ctx, sortIndexKey(email, todo, field), sortKey(todo, field))
+	}
+
+	if todo.DeletedAt != nil {
+		pipe.ZRem(ctx, fmt.Sprintf(redisTrashKey, email), todo.ID)
+	}
+
+	if awaitsArchive(todo) {
+		pipe.ZRem(ctx, fmt.Sprintf(redisCompletedKey, email), todo.ID)
+	}
+
+	if todo.ArchivedAt != nil {
+		return
+	}
+
+	terms := searchTerms(todo)
+	if len(terms) == 0 {
+		return
+	}
+
+	entries := make([]any, 0, len(terms))
+	for _, term := range terms {
+		entries = append(entries, term+"\x00"+todo.ID)
+	}
+	pipe.ZRem(ctx, fmt.Sprintf(redisSearchKey, email), entries...)
+}
+
+// sortIndexKey is the sort index by the field of the hash the todo is stored
+// in.
+func sortIndexKey(email Owner, todo models.Todo, field SortField) string {
+	if todo.ArchivedAt != nil {
+		return fmt.Sprintf(redisArchiveSortKey, email, field)
+	}
+
+	return fmt.Sprintf(redisSortKey, email, field)
+}
+
+// decode reads a todo as it's stored. The todos stored before they had a
+// version are at version 1, like the ones created since.
+func decode(value string) (models.Todo, error) {
+	var todo models.Todo
+	if err := json.Unmarshal([]byte(value), &todo); err != nil {
+		return models.Todo{}, err
+	}
+
+	if todo.Version == 0 {
+		todo.Version = 1
+	}
+
+	return todo, nil
 }
 
 func validateID(id string) error {
@@ -162,3 +1312,13 @@ func validateID(id string) error {
 
 	return nil
 }
+
+func validateIDs(ids []string) error {
+	for _, id := range ids {
+		if err := validateID(id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -9,9 +9,8 @@ import (
 
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
-	"github.com/testcontainers/testcontainers-go"
-	"github.com/testcontainers/testcontainers-go/wait"
 
+	"todo-app/internal/storage/storagetest"
 	"todo-app/todo/models"
 )
 
@@ -26,7 +25,7 @@ func TestNewRedisRepository(t *testing.T) {
 
 func TestRedisRepository_Create(t *testing.T) {
 	t.Run("should return a nil error and a todo with a uuid", func(t *testing.T) {
-		client := getRedisClient(t)
+		client := storagetest.RedisClient(t)
 		ctx := context.TODO()
 		todo := models.Todo{
 			Name:        "name",
@@ -42,7 +41,7 @@ func TestRedisRepository_Create(t *testing.T) {
 	})
 
 	t.Run("should return an error if the todo cannot be created", func(t *testing.T) {
-		client := getRedisClient(t)
+		client := storagetest.RedisClient(t)
 		ctx := context.TODO()
 		canceled, cancel := context.WithCancel(ctx)
 		cancel()
@@ -60,76 +59,33 @@ func TestRedisRepository_Create(t *testing.T) {
 	})
 }
 
-func TestRedisRepository_Delete(t *testing.T) {
-	t.Run("should return an error if the id is invalid", func(t *testing.T) {
-		client := getRedisClient(t)
-		ctx := context.TODO()
-
-		repository := NewRedisRepository(client)
-		err := repository.Delete(ctx, "test@test.test", "invalidid")
-		assert.ErrorIs(t, err, ErrInvalidID)
-	})
-
-	t.Run("should return an error if the todo cannot be deleted", func(t *testing.T) {
-		client := getRedisClient(t)
-		canceled, cancel := context.WithCancel(context.TODO())
-		cancel()
-
-		repository := NewRedisRepository(client)
-		err := repository.Delete(canceled, "test@test.test", "279f4a4e-48dc-4569-83df-8b30ce488599")
-		assert.ErrorIs(t, err, ErrWhileDeleting)
-	})
-
-	t.Run("should return nil if no error happens", func(t *testing.T) {
-		client := getRedisClient(t)
-		ctx := context.TODO()
-
-		todo, err := json.Marshal(models.Todo{
-			ID:          "279f4a4e-48dc-4569-83df-8b30ce488599",
-			Name:        "name",
-			Description: "description",
-			StartDate:   time.Now(),
-			DueDate:     time.Now().Add(time.Minute * 5),
-		})
-		assert.NoError(t, err)
-
-		userKey := fmt.Sprintf(redisKey, "test@test.test")
-		err = client.HSet(ctx, userKey, "279f4a4e-48dc-4569-83df-8b30ce488599", todo).Err()
-		assert.NoError(t, err)
-
-		repository := NewRedisRepository(client)
-		err = repository.Delete(ctx, "test@test.test", "279f4a4e-48dc-4569-83df-8b30ce488599")
-		assert.NoError(t, err)
-	})
-}
-
 func TestRedisRepository_GetAll(t *testing.T) {
 	t.Run("should return an error if the todos can't be retrieved", func(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.TODO())
 		cancel()
 
-		client := getRedisClient(t)
+		client := storagetest.RedisClient(t)
 		repository := NewRedisRepository(client)
-		response, err := repository.GetAll(ctx, "test@test.test")
+		response, err := repository.GetAll(ctx, "test@test.test", TodoFilter{}, PageRequest{})
 		assert.ErrorIs(t, err, ErrWhileRetrieving)
-		assert.Nil(t, response)
+		assert.Zero(t, response)
 	})
 
 	t.Run("should return an error if the unmarshal fails", func(t *testing.T) {
 		ctx := context.TODO()
-		client := getRedisClient(t)
+		client := storagetest.RedisClient(t)
 		err := client.HSet(ctx, fmt.Sprintf(redisKey, "test@test.test"), "279f4a4e-48dc-4569-83df-8b30ce488599", "{]").Err()
 		assert.NoError(t, err)
 
 		repository := NewRedisRepository(client)
-		response, err := repository.GetAll(ctx, "test@test.test")
+		response, err := repository.GetAll(ctx, "test@test.test", TodoFilter{}, PageRequest{})
 		assert.ErrorIs(t, err, ErrWhileRetrieving)
-		assert.Nil(t, response)
+		assert.Zero(t, response)
 	})
 
 	t.Run("should return the saved todos if no error happens", func(t *testing.T) {
 		ctx := context.TODO()
-		client := getRedisClient(t)
+		client := storagetest.RedisClient(t)
 		todo, err := json.Marshal(models.Todo{
 			ID:          "279f4a4e-48dc-4569-83df-8b30ce488599",
 			Name:        "name",
@@ -143,15 +99,16 @@ func TestRedisRepository_GetAll(t *testing.T) {
 		assert.NoError(t, err)
 
 		repository := NewRedisRepository(client)
-		response, err := repository.GetAll(ctx, "test@test.test")
+		response, err := repository.GetAll(ctx, "test@test.test", TodoFilter{}, PageRequest{})
 		assert.NoError(t, err)
-		assert.Len(t, response, 1)
+		assert.Len(t, response.Todos, 1)
+		assert.Empty(t, response.NextCursor)
 	})
 }
 
 func TestRedisRepository_GetByID(t *testing.T) {
 	t.Run("should return an error if the id is invalid", func(t *testing.T) {
-		client := getRedisClient(t)
+		client := storagetest.RedisClient(t)
 		ctx := context.TODO()
 
 		repository := NewRedisRepository(client)
@@ -161,7 +118,7 @@ func TestRedisRepository_GetByID(t *testing.T) {
 	})
 
 	t.Run("should return an error if the todo can't be retrieved", func(t *testing.T) {
-		client := getRedisClient(t)
+		client := storagetest.RedisClient(t)
 		canceled, cancel := context.WithCancel(context.TODO())
 		cancel()
 
@@ -180,7 +137,7 @@ func TestRedisRepository_GetByID(t *testing.T) {
 			StartDate:   time.Now(),
 			DueDate:     time.Now().Add(time.Minute * 5),
 		})
-		client := getRedisClient(t)
+		client := storagetest.RedisClient(t)
 		err = client.HSet(ctx, fmt.Sprintf(redisKey, "test@test.test"), "279f4a4e-48dc-4569-83df-8b30ce488599", string(todo)).Err()
 		assert.NoError(t, err)
 
@@ -191,9 +148,25 @@ func TestRedisRepository_GetByID(t *testing.T) {
 		assert.Equal(t, "279f4a4e-48dc-4569-83df-8b30ce488599", response.ID)
 	})
 
+	t.Run("should read the todos stored without a version at version 1", func(t *testing.T) {
+		ctx := context.TODO()
+		client := storagetest.RedisClient(t)
+		err := client.HSet(ctx, fmt.Sprintf(redisKey, "test@test.test"), "279f4a4e-48dc-4569-83df-8b30ce488599", `{"name":"name"}`).Err()
+		assert.NoError(t, err)
+
+		repository := NewRedisRepository(client)
+		response, err := repository.GetByID(ctx, "test@test.test", "279f4a4e-48dc-4569-83df-8b30ce488599")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), response.Version)
+
+		response, err = repository.Update(ctx, "test@test.test", "279f4a4e-48dc-4569-83df-8b30ce488599", response)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), response.Version)
+	})
+
 	t.Run("should return the unmarshal error", func(t *testing.T) {
 		ctx := context.TODO()
-		client := getRedisClient(t)
+		client := storagetest.RedisClient(t)
 		err := client.HSet(ctx, fmt.Sprintf(redisKey, "test@test.test"), "279f4a4e-48dc-4569-83df-8b30ce488599", `{]`).Err()
 		assert.NoError(t, err)
 
@@ -206,7 +179,7 @@ func TestRedisRepository_GetByID(t *testing.T) {
 
 func TestRedisRepository_Update(t *testing.T) {
 	t.Run("should return an error if the id is invalid", func(t *testing.T) {
-		client := getRedisClient(t)
+		client := storagetest.RedisClient(t)
 		ctx := context.TODO()
 
 		repository := NewRedisRepository(client)
@@ -216,7 +189,7 @@ func TestRedisRepository_Update(t *testing.T) {
 	})
 
 	t.Run("should return an error if the todo can't be updated", func(t *testing.T) {
-		client := getRedisClient(t)
+		client := storagetest.RedisClient(t)
 		canceled, cancel := context.WithCancel(context.TODO())
 		cancel()
 
@@ -227,7 +200,7 @@ func TestRedisRepository_Update(t *testing.T) {
 	})
 
 	t.Run("should return the todo if no error happens", func(t *testing.T) {
-		client := getRedisClient(t)
+		client := storagetest.RedisClient(t)
 		ctx := context.TODO()
 
 		todo, err := json.Marshal(models.Todo{
@@ -251,34 +224,255 @@ func TestRedisRepository_Update(t *testing.T) {
 	})
 }
 
-func getRedisClient(t *testing.T) *redis.Client {
-	req := testcontainers.ContainerRequest{
-		Image:        "redis:latest",
-		ExposedPorts: []string{"6379/tcp"},
-		WaitingFor:   wait.ForLog("Ready to accept connections"),
-	}
+func TestRedisRepository_Search(t *testing.T) {
+	t.Run("should find the todos stored before the search index", func(t *testing.T) {
+		ctx := context.TODO()
+		client := storagetest.RedisClient(t)
+		todo, err := json.Marshal(models.Todo{
+			ID:          "279f4a4e-48dc-4569-83df-8b30ce488599",
+			Name:        "buy milk",
+			Description: "description",
+			StartDate:   time.Now(),
+			DueDate:     time.Now().Add(time.Minute * 5),
+		})
+		assert.NoError(t, err)
+
+		err = client.HSet(ctx, fmt.Sprintf(redisKey, "test@test.test"), "279f4a4e-48dc-4569-83df-8b30ce488599", string(todo)).Err()
+		assert.NoError(t, err)
 
-	ctx := context.TODO()
-	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
-		ContainerRequest: req,
-		Started:          true,
+		repository := NewRedisRepository(client)
+		response, err := repository.Search(ctx, "test@test.test", []string{"milk"}, 10)
+		assert.NoError(t, err)
+		assert.Len(t, response, 1)
 	})
-	if err != nil {
-		t.Fatal(err)
-	}
+}
 
-	redisHost, err := container.Endpoint(ctx, "")
-	if err != nil {
-		t.Fatal(err)
-	}
+func TestRedisRepository_ArchiveCompleted(t *testing.T) {
+	t.Run("should archive the todos completed before the index", func(t *testing.T) {
+		ctx := context.TODO()
+		client := storagetest.RedisClient(t)
+		completedAt := time.Now().Add(-48 * time.Hour)
+		todo, err := json.Marshal(models.Todo{
+			ID:          "279f4a4e-48dc-4569-83df-8b30ce488599",
+			Name:        "name",
+			Description: "description",
+			StartDate:   time.Now(),
+			DueDate:     time.Now().Add(time.Minute * 5),
+			Completed:   true,
+			CompletedAt: &completedAt,
+		})
+		assert.NoError(t, err)
+
+		err = client.HSet(ctx, fmt.Sprintf(redisKey, "test@test.test"), "279f4a4e-48dc-4569-83df-8b30ce488599", string(todo)).Err()
+		assert.NoError(t, err)
+
+		repository := NewRedisRepository(client)
+		assert.NoError(t, repository.Migrate(ctx))
+
+		archived, err := repository.ArchiveCompleted(ctx, time.Now().Add(-24*time.Hour), time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, archived)
+
+		response, err := repository.GetByID(ctx, "test@test.test", "279f4a4e-48dc-4569-83df-8b30ce488599")
+		assert.NoError(t, err)
+		assert.NotNil(t, response.ArchivedAt)
+	})
+}
+
+func TestRedisRepository_Migrate(t *testing.T) {
+	t.Run("should move the todos of the legacy keys", func(t *testing.T) {
+		ctx := context.TODO()
+		client := storagetest.RedisClient(t)
+		todo, err := json.Marshal(models.Todo{
+			ID:          "279f4a4e-48dc-4569-83df-8b30ce488599",
+			Name:        "name",
+			Description: "description",
+			StartDate:   time.Now(),
+			DueDate:     time.Now().Add(time.Minute * 5),
+		})
+		assert.NoError(t, err)
+
+		err = client.HSet(ctx, "todo-test@test.test", "279f4a4e-48dc-4569-83df-8b30ce488599", string(todo)).Err()
+		assert.NoError(t, err)
+
+		repository := NewRedisRepository(client)
+		assert.NoError(t, repository.Migrate(ctx))
+
+		response, err := repository.GetByID(ctx, "test@test.test", "279f4a4e-48dc-4569-83df-8b30ce488599")
+		assert.NoError(t, err)
+		assert.Equal(t, "name", response.Name)
+
+		exists, err := client.Exists(ctx, "todo-test@test.test").Result()
+		assert.NoError(t, err)
+		assert.Zero(t, exists)
+	})
+
+	t.Run("should index the todos stored before the indexes", func(t *testing.T) {
+		ctx := context.TODO()
+		client := storagetest.RedisClient(t)
+		todo, err := json.Marshal(models.Todo{
+			ID:          "279f4a4e-48dc-4569-83df-8b30ce488599",
+			Name:        "name",
+			Description: "description",
+			StartDate:   time.Now(),
+			DueDate:     time.Now().Add(time.Minute * 5),
+		})
+		assert.NoError(t, err)
+
+		err = client.HSet(ctx, fmt.Sprintf(redisKey, "test@test.test"), "279f4a4e-48dc-4569-83df-8b30ce488599", string(todo)).Err()
+		assert.NoError(t, err)
+
+		repository := NewRedisRepository(client)
+		assert.NoError(t, repository.Migrate(ctx))
 
-	client := redis.NewClient(&redis.Options{
-		Addr: redisHost,
+		for _, field := range sortFields {
+			count, err := client.ZCard(ctx, fmt.Sprintf(redisSortKey, "test@test.test", field)).Result()
+			assert.NoError(t, err)
+			assert.Equal(t, int64(1), count)
+		}
+
+		version, err := client.Get(ctx, fmt.Sprintf(redisIndexedKey, "test@test.test")).Int()
+		assert.NoError(t, err)
+		assert.Equal(t, redisIndexVersion, version)
 	})
 
-	t.Cleanup(func() {
-		container.Terminate(ctx)
+	t.Run("should keep the todos already moved", func(t *testing.T) {
+		ctx := context.TODO()
+		client := storagetest.RedisClient(t)
+		err := client.HSet(ctx, "todo-test@test.test", "279f4a4e-48dc-4569-83df-8b30ce488599", `{"name":"legacy"}`).Err()
+		assert.NoError(t, err)
+		err = client.HSet(ctx, fmt.Sprintf(redisKey, "test@test.test"), "279f4a4e-48dc-4569-83df-8b30ce488599", `{"name":"current"}`).Err()
+		assert.NoError(t, err)
+
+		repository := NewRedisRepository(client)
+		assert.NoError(t, repository.Migrate(ctx))
+
+		response, err := repository.GetByID(ctx, "test@test.test", "279f4a4e-48dc-4569-83df-8b30ce488599")
+		assert.NoError(t, err)
+		assert.Equal(t, "current", response.Name)
 	})
 
-	return client
+	t.Run("should skip the keys once migrated", func(t *testing.T) {
+		ctx := context.TODO()
+		client := storagetest.RedisClient(t)
+		repository := NewRedisRepository(client)
+		assert.NoError(t, repository.Migrate(ctx))
+
+		err := client.HSet(ctx, "todo-test@test.test", "279f4a4e-48dc-4569-83df-8b30ce488599", `{"name":"legacy"}`).Err()
+		assert.NoError(t, err)
+		assert.NoError(t, repository.Migrate(ctx))
+
+		exists, err := client.Exists(ctx, "todo-test@test.test").Result()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), exists)
+	})
+
+	t.Run("should move the todos and shares of the owners written before they were normalized", func(t *testing.T) {
+		ctx := context.TODO()
+		client := storagetest.RedisClient(t)
+		id := "279f4a4e-48dc-4569-83df-8b30ce488599"
+		todo, err := json.Marshal(models.Todo{ID: id, Name: "name", StartDate: time.Now(), DueDate: time.Now()})
+		assert.NoError(t, err)
+		share, err := json.Marshal(models.Share{TodoID: id, Owner: "Test@Test.test", Grantee: "Other@Test.test", Role: models.RoleViewer})
+		assert.NoError(t, err)
+
+		assert.NoError(t, client.HSet(ctx, fmt.Sprintf(redisKey, "Test@Test.test"), id, string(todo)).Err())
+		assert.NoError(t, client.ZAdd(ctx, fmt.Sprintf(redisSearchKey, "Test@Test.test"), redis.Z{Member: "name\x00" + id}).Err())
+		assert.NoError(t, client.HSet(ctx, fmt.Sprintf(redisSharesKey, "Test@Test.test", id), "Other@Test.test", string(models.RoleViewer)).Err())
+		assert.NoError(t, client.HSet(ctx, fmt.Sprintf(redisSharedKey, "Other@Test.test"), id, string(share)).Err())
+		assert.NoError(t, client.HSet(ctx, fmt.Sprintf(redisKey, "a*@test.test"), id, string(todo)).Err())
+
+		repository := NewRedisRepository(client)
+		assert.NoError(t, repository.Migrate(ctx))
+
+		response, err := repository.GetByID(ctx, "test@test.test", id)
+		assert.NoError(t, err)
+		assert.Equal(t, "name", response.Name)
+
+		shares, err := repository.GetShares(ctx, "test@test.test", id)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.Share{{TodoID: id, Owner: "test@test.test", Grantee: "other@test.test", Role: models.RoleViewer}}, shares)
+
+		shared, err := repository.GetSharedWith(ctx, "other@test.test")
+		assert.NoError(t, err)
+		assert.Equal(t, []models.Share{{TodoID: id, Owner: "test@test.test", Grantee: "other@test.test", Role: models.RoleViewer}}, shared)
+
+		exists, err := client.Exists(ctx, fmt.Sprintf(redisKey, "Test@Test.test"), fmt.Sprintf(redisSearchKey, "Test@Test.test")).Result()
+		assert.NoError(t, err)
+		assert.Zero(t, exists)
+
+		exists, err = client.Exists(ctx, fmt.Sprintf(redisKey, "a*@test.test")).Result()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), exists)
+	})
+
+	t.Run("should index the todos merged into an owner already indexed", func(t *testing.T) {
+		ctx := context.TODO()
+		client := storagetest.RedisClient(t)
+		repository := NewRedisRepository(client)
+		created, err := repository.Create(ctx, "bob@x.com", models.Todo{Name: "created", StartDate: time.Now(), DueDate: time.Now()})
+		assert.NoError(t, err)
+		assert.NoError(t, repository.ensureIndexed(ctx, "bob@x.com"))
+
+		id := "279f4a4e-48dc-4569-83df-8b30ce488599"
+		todo, err := json.Marshal(models.Todo{ID: id, Name: "merged", StartDate: time.Now(), DueDate: time.Now()})
+		assert.NoError(t, err)
+		assert.NoError(t, client.HSet(ctx, fmt.Sprintf(redisKey, "Bob@x.com"), id, string(todo)).Err())
+
+		assert.NoError(t, repository.Migrate(ctx))
+
+		page, err := repository.GetAll(ctx, "bob@x.com", TodoFilter{}, PageRequest{Limit: 10})
+		assert.NoError(t, err)
+		if assert.Len(t, page.Todos, 2) {
+			assert.ElementsMatch(t, []string{created.ID, id}, []string{page.Todos[0].ID, page.Todos[1].ID})
+		}
+
+		found, err := repository.Search(ctx, "bob@x.com", []string{"merged"}, 10)
+		assert.NoError(t, err)
+		if assert.Len(t, found, 1) {
+			assert.Equal(t, id, found[0].ID)
+		}
+	})
+}
+
+func TestParseHash(t *testing.T) {
+	for key, current := range map[string]string{
+		"todo-Test@Test.test":                      fmt.Sprintf(redisKey, "test@test.test"),
+		"todo-{test@test.test}":                    fmt.Sprintf(redisKey, "test@test.test"),
+		"todo-{Test@Test.test}:archive":            fmt.Sprintf(redisArchiveKey, "test@test.test"),
+		"todo-{Test@Test.test}:shares:some-id":     fmt.Sprintf(redisSharesKey, "test@test.test", "some-id"),
+		"todo-{Test@Test.test}:shared":             fmt.Sprintf(redisSharedKey, "test@test.test"),
+		"todo-{test@test.test}:shares:other-id":    fmt.Sprintf(redisSharesKey, "test@test.test", "other-id"),
+		"todo-{test@test.test}:archive:created_at": "",
+		"todo-{a*@test.test}":                      "",
+		"todo-{a{b}@test.test}":                    "",
+		"todo-{test@test.test":                     "",
+		"other-test@test.test":                     "",
+	} {
+		t.Run("should parse "+key, func(t *testing.T) {
+			hash, ok := parseHash(key)
+			assert.Equal(t, current != "", ok)
+			assert.Equal(t, current, hash.current)
+		})
+	}
+}
+
+func TestNormalizeShare(t *testing.T) {
+	t.Run("should normalize the owner and the grantee", func(t *testing.T) {
+		share, err := json.Marshal(models.Share{TodoID: "id", Owner: "Test@Test.test", Grantee: "Other@Test.test", Role: models.RoleEditor})
+		assert.NoError(t, err)
+
+		id, value := normalizeShare("id", string(share))
+		assert.Equal(t, "id", id)
+
+		var normalized models.Share
+		assert.NoError(t, json.Unmarshal([]byte(value), &normalized))
+		assert.Equal(t, models.Share{TodoID: "id", Owner: "test@test.test", Grantee: "other@test.test", Role: models.RoleEditor}, normalized)
+	})
+
+	t.Run("should leave the values that aren't shares as they are", func(t *testing.T) {
+		id, value := normalizeShare("id", "invalid")
+		assert.Equal(t, "id", id)
+		assert.Equal(t, "invalid", value)
+	})
 }
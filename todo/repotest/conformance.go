@@ -0,0 +1,1425 @@
+// Package repotest holds the behaviour every todo.Repository implementation
+// must share, so new backends can be checked against the existing ones.
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"todo-app/todo"
+	"todo-app/todo/models"
+)
+
+const (
+	email      todo.Owner = "test@test.test"
+	otherEmail todo.Owner = "other@test.test"
+	grantee    todo.Owner = "grantee@test.test"
+	missingID             = "279f4a4e-48dc-4569-83df-8b30ce488599"
+	invalidID             = "invalidid"
+)
+
+// Factory returns an empty repository. It's called once per test case.
+type Factory func(t *testing.T) todo.Repository
+
+func RunConformance(t *testing.T, factory Factory) {
+	t.Run("Create", func(t *testing.T) { testCreate(t, factory) })
+	t.Run("GetAll", func(t *testing.T) { testGetAll(t, factory) })
+	t.Run("Sort", func(t *testing.T) { testSort(t, factory) })
+	t.Run("Filter", func(t *testing.T) { testFilter(t, factory) })
+	t.Run("Search", func(t *testing.T) { testSearch(t, factory) })
+	t.Run("GetByID", func(t *testing.T) { testGetByID(t, factory) })
+	t.Run("GetByIDs", func(t *testing.T) { testGetByIDs(t, factory) })
+	t.Run("Update", func(t *testing.T) { testUpdate(t, factory) })
+	t.Run("Batch", func(t *testing.T) { testBatch(t, factory) })
+	t.Run("Trash", func(t *testing.T) { testTrash(t, factory) })
+	t.Run("Archive", func(t *testing.T) { testArchive(t, factory) })
+	t.Run("Share", func(t *testing.T) { testShare(t, factory) })
+}
+
+func testCreate(t *testing.T, factory Factory) {
+	t.Run("should assign a uuid and persist the todo", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+
+		todo := newTodo("name")
+		created, err := repository.Create(ctx, email, todo)
+		require.NoError(t, err)
+		assert.NoError(t, uuid.Validate(created.ID))
+
+		response, err := repository.GetByID(ctx, email, created.ID)
+		require.NoError(t, err)
+		assertTodo(t, created, response)
+	})
+
+	t.Run("should ignore the given id", func(t *testing.T) {
+		repository := factory(t)
+
+		todo := newTodo("name")
+		todo.ID = missingID
+		created, err := repository.Create(context.TODO(), email, todo)
+		require.NoError(t, err)
+		assert.NotEqual(t, missingID, created.ID)
+	})
+
+	t.Run("should start the todo at version 1", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+
+		todo := newTodo("name")
+		todo.Version = 7
+		created, err := repository.Create(ctx, email, todo)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), created.Version)
+
+		response, err := repository.GetByID(ctx, email, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), response.Version)
+	})
+
+	t.Run("should persist the completion date", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+
+		completedAt := time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC)
+		todo := newTodo("name")
+		todo.Completed = true
+		todo.CompletedAt = &completedAt
+		created, err := repository.Create(ctx, email, todo)
+		require.NoError(t, err)
+
+		response, err := repository.GetByID(ctx, email, created.ID)
+		require.NoError(t, err)
+		assert.True(t, response.Completed)
+		require.NotNil(t, response.CompletedAt)
+		assert.True(t, completedAt.Equal(*response.CompletedAt))
+	})
+
+	t.Run("should return ErrWhileCreating if the context is canceled", func(t *testing.T) {
+		repository := factory(t)
+
+		response, err := repository.Create(canceledContext(), email, newTodo("name"))
+		assert.ErrorIs(t, err, todo.ErrWhileCreating)
+		assert.Zero(t, response)
+	})
+}
+
+func testGetAll(t *testing.T, factory Factory) {
+	t.Run("should return no todos for an unknown email", func(t *testing.T) {
+		repository := factory(t)
+
+		response, err := repository.GetAll(context.TODO(), email, todo.TodoFilter{}, todo.PageRequest{})
+		assert.NoError(t, err)
+		assert.Empty(t, response.Todos)
+		assert.Empty(t, response.NextCursor)
+	})
+
+	t.Run("should return every todo of the email", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+
+		first, err := repository.Create(ctx, email, newTodo("first"))
+		require.NoError(t, err)
+		second, err := repository.Create(ctx, email, newTodo("second"))
+		require.NoError(t, err)
+
+		response, err := repository.GetAll(ctx, email, todo.TodoFilter{}, todo.PageRequest{})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{first.ID, second.ID}, ids(response.Todos))
+		assert.Empty(t, response.NextCursor)
+	})
+
+	t.Run("should isolate the todos per email", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+
+		own, err := repository.Create(ctx, email, newTodo("own"))
+		require.NoError(t, err)
+		_, err = repository.Create(ctx, otherEmail, newTodo("other"))
+		require.NoError(t, err)
+
+		response, err := repository.GetAll(ctx, email, todo.TodoFilter{}, todo.PageRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{own.ID}, ids(response.Todos))
+	})
+
+	t.Run("should paginate through every todo once", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+
+		var expected []string
+		for i := 0; i < 5; i++ {
+			created, err := repository.Create(ctx, email, newTodo("name"))
+			require.NoError(t, err)
+			expected = append(expected, created.ID)
+		}
+
+		var pages [][]string
+		page := todo.PageRequest{Limit: 2}
+		for {
+			response, err := repository.GetAll(ctx, email, todo.TodoFilter{}, page)
+			require.NoError(t, err)
+			pages = append(pages, ids(response.Todos))
+			if response.NextCursor == "" {
+				break
+			}
+
+			page.Cursor = response.NextCursor
+		}
+
+		require.Len(t, pages, 3)
+		assert.Len(t, pages[0], 2)
+		assert.Len(t, pages[1], 2)
+		assert.Len(t, pages[2], 1)
+		assert.ElementsMatch(t, expected, append(append(pages[0], pages[1]...), pages[2]...))
+	})
+
+	t.Run("should not return a cursor if the last page is full", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+
+		for i := 0; i < 2; i++ {
+			_, err := repository.Create(ctx, email, newTodo("name"))
+			require.NoError(t, err)
+		}
+
+		response, err := repository.GetAll(ctx, email, todo.TodoFilter{}, todo.PageRequest{Limit: 2})
+		require.NoError(t, err)
+		assert.Len(t, response.Todos, 2)
+		assert.Empty(t, response.NextCursor)
+	})
+
+	t.Run("should skip the todos deleted before the cursor", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+
+		for i := 0; i < 3; i++ {
+			_, err := repository.Create(ctx, email, newTodo("name"))
+			require.NoError(t, err)
+		}
+
+		first, err := repository.GetAll(ctx, email, todo.TodoFilter{}, todo.PageRequest{Limit: 1})
+		require.NoError(t, err)
+		require.NotEmpty(t, first.NextCursor)
+		trash(t, repository, email, first.Todos[0], time.Now())
+
+		rest, err := repository.GetAll(ctx, email, todo.TodoFilter{}, todo.PageRequest{Limit: 10, Cursor: first.NextCursor})
+		require.NoError(t, err)
+		assert.Len(t, rest.Todos, 2)
+		assert.NotContains(t, ids(rest.Todos), first.Todos[0].ID)
+	})
+
+	t.Run("should return ErrInvalidCursor if the cursor can't be decoded", func(t *testing.T) {
+		repository := factory(t)
+
+		response, err := repository.GetAll(context.TODO(), email, todo.TodoFilter{}, todo.PageRequest{Cursor: "not a cursor"})
+		assert.ErrorIs(t, err, todo.ErrInvalidCursor)
+		assert.Zero(t, response)
+	})
+
+	t.Run("should return ErrWhileRetrieving if the context is canceled", func(t *testing.T) {
+		repository := factory(t)
+
+		response, err := repository.GetAll(canceledContext(), email, todo.TodoFilter{}, todo.PageRequest{})
+		assert.ErrorIs(t, err, todo.ErrWhileRetrieving)
+		assert.Zero(t, response)
+	})
+}
+
+func testSort(t *testing.T, factory Factory) {
+	fields := []todo.SortField{todo.SortByCreatedAt, todo.SortByDueDate, todo.SortByStartDate, todo.SortByName}
+	orders := []todo.SortOrder{todo.Ascending, todo.Descending}
+
+	// createSortable creates todos sharing some values of every field, so the
+	// order depends on the tiebreak too.
+	createSortable := func(t *testing.T, repository todo.Repository) {
+		day := time.Date(2024, time.March, 1, 9, 0, 0, 0, time.UTC)
+		for i, name := range []string{"b", "a", "B", "a", "c"} {
+			todo := newTodo(name)
+			todo.StartDate = day.Add(time.Duration(i%2) * time.Hour)
+			todo.DueDate = day.Add(time.Duration(i%3) * 24 * time.Hour)
+			todo.CreatedAt = day.Add(-time.Duration(i%2) * time.Minute)
+			_, err := repository.Create(context.TODO(), email, todo)
+			require.NoError(t, err)
+		}
+	}
+
+	for _, field := range fields {
+		for _, order := range orders {
+			t.Run(fmt.Sprintf("should sort by %s %s breaking ties by id", field, order), func(t *testing.T) {
+				repository := factory(t)
+				createSortable(t, repository)
+
+				response, err := repository.GetAll(context.TODO(), email, todo.TodoFilter{}, todo.PageRequest{Sort: field, Order: order})
+				require.NoError(t, err)
+				require.Len(t, response.Todos, 5)
+				assertSorted(t, response.Todos, field, order)
+			})
+
+			t.Run(fmt.Sprintf("should paginate sorted by %s %s", field, order), func(t *testing.T) {
+				ctx := context.TODO()
+				repository := factory(t)
+				createSortable(t, repository)
+
+				all, err := repository.GetAll(ctx, email, todo.TodoFilter{}, todo.PageRequest{Sort: field, Order: order})
+				require.NoError(t, err)
+
+				var paged []models.Todo
+				page := todo.PageRequest{Limit: 2, Sort: field, Order: order}
+				for {
+					response, err := repository.GetAll(ctx, email, todo.TodoFilter{}, page)
+					require.NoError(t, err)
+					paged = append(paged, response.Todos...)
+					if response.NextCursor == "" {
+						break
+					}
+
+					page.Cursor = response.NextCursor
+				}
+
+				assert.Equal(t, ids(all.Todos), ids(paged))
+			})
+		}
+	}
+
+	t.Run("should sort by creation date ascending by default", func(t *testing.T) {
+		repository := factory(t)
+		createSortable(t, repository)
+
+		response, err := repository.GetAll(context.TODO(), email, todo.TodoFilter{}, todo.PageRequest{})
+		require.NoError(t, err)
+		assertSorted(t, response.Todos, todo.SortByCreatedAt, todo.Ascending)
+	})
+
+	t.Run("should return ErrInvalidSort if the field is unknown", func(t *testing.T) {
+		repository := factory(t)
+
+		response, err := repository.GetAll(context.TODO(), email, todo.TodoFilter{}, todo.PageRequest{Sort: "owner"})
+		assert.ErrorIs(t, err, todo.ErrInvalidSort)
+		assert.Zero(t, response)
+	})
+
+	t.Run("should return ErrInvalidCursor if the cursor is for another sort", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+		createSortable(t, repository)
+
+		response, err := repository.GetAll(ctx, email, todo.TodoFilter{}, todo.PageRequest{Limit: 1, Sort: todo.SortByName})
+		require.NoError(t, err)
+		require.NotEmpty(t, response.NextCursor)
+
+		_, err = repository.GetAll(ctx, email, todo.TodoFilter{}, todo.PageRequest{Cursor: response.NextCursor, Sort: todo.SortByDueDate})
+		assert.ErrorIs(t, err, todo.ErrInvalidCursor)
+
+		_, err = repository.GetAll(ctx, email, todo.TodoFilter{}, todo.PageRequest{Cursor: response.NextCursor, Sort: todo.SortByName, Order: todo.Descending})
+		assert.ErrorIs(t, err, todo.ErrInvalidCursor)
+	})
+}
+
+func testFilter(t *testing.T, factory Factory) {
+	day := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	at := func(days int) *time.Time {
+		value := day.Add(time.Duration(days) * 24 * time.Hour)
+		return &value
+	}
+	completed, open := true, false
+
+	// createFilterable creates the todos "past", "done" and "future", due and
+	// started on consecutive days.
+	createFilterable := func(t *testing.T, repository todo.Repository) {
+		for i, name := range []string{"past", "done", "future"} {
+			todo := newTodo(name)
+			todo.StartDate = *at(i * 2)
+			todo.DueDate = *at(i*2 + 1)
+			todo.Completed = name == "done"
+			_, err := repository.Create(context.TODO(), email, todo)
+			require.NoError(t, err)
+		}
+	}
+
+	cases := []struct {
+		name     string
+		filter   todo.TodoFilter
+		expected []string
+	}{
+		{name: "completed", filter: todo.TodoFilter{Completed: &completed}, expected: []string{"done"}},
+		{name: "open", filter: todo.TodoFilter{Completed: &open}, expected: []string{"past", "future"}},
+		{name: "due before", filter: todo.TodoFilter{DueBefore: at(3)}, expected: []string{"past"}},
+		{name: "due after", filter: todo.TodoFilter{DueAfter: at(3)}, expected: []string{"future"}},
+		{name: "start before", filter: todo.TodoFilter{StartBefore: at(2)}, expected: []string{"past"}},
+		{name: "start after", filter: todo.TodoFilter{StartAfter: at(0)}, expected: []string{"done", "future"}},
+		{name: "due range", filter: todo.TodoFilter{DueAfter: at(0), DueBefore: at(5)}, expected: []string{"past", "done"}},
+		{name: "open and due after", filter: todo.TodoFilter{Completed: &open, DueAfter: at(2)}, expected: []string{"future"}},
+		{name: "nothing", filter: todo.TodoFilter{DueBefore: at(0)}, expected: nil},
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("should filter by %s", c.name), func(t *testing.T) {
+			repository := factory(t)
+			createFilterable(t, repository)
+
+			response, err := repository.GetAll(context.TODO(), email, c.filter, todo.PageRequest{})
+			require.NoError(t, err)
+			assert.ElementsMatch(t, c.expected, names(response.Todos))
+		})
+	}
+
+	t.Run("should paginate through the filtered todos", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+		createFilterable(t, repository)
+		createFilterable(t, repository)
+
+		var listed []models.Todo
+		page := todo.PageRequest{Limit: 1, Sort: todo.SortByDueDate}
+		for {
+			response, err := repository.GetAll(ctx, email, todo.TodoFilter{Completed: &open}, page)
+			require.NoError(t, err)
+			listed = append(listed, response.Todos...)
+			if response.NextCursor == "" {
+				break
+			}
+
+			page.Cursor = response.NextCursor
+		}
+
+		assert.Equal(t, []string{"past", "past", "future", "future"}, names(listed))
+	})
+}
+
+func testSearch(t *testing.T, factory Factory) {
+	create := func(t *testing.T, repository todo.Repository, email todo.Owner, name, description string) models.Todo {
+		todo := newTodo(name)
+		todo.Description = description
+		created, err := repository.Create(context.TODO(), email, todo)
+		require.NoError(t, err)
+		return created
+	}
+
+	t.Run("should find whole words and prefixes ignoring case", func(t *testing.T) {
+		repository := factory(t)
+		milk := create(t, repository, email, "Buy MILK", "at the store")
+		milkshake := create(t, repository, email, "Milkshake", "")
+		create(t, repository, email, "Walk the dog", "")
+
+		response, err := repository.Search(context.TODO(), email, []string{"milk"}, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{milk.ID, milkshake.ID}, ids(response))
+	})
+
+	t.Run("should rank the name over the description", func(t *testing.T) {
+		repository := factory(t)
+		inDescription := create(t, repository, email, "Errands", "groceries for the week")
+		inName := create(t, repository, email, "Groceries", "")
+
+		response, err := repository.Search(context.TODO(), email, []string{"groceries"}, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{inName.ID, inDescription.ID}, ids(response))
+	})
+
+	t.Run("should only find the todos with every term", func(t *testing.T) {
+		repository := factory(t)
+		both := create(t, repository, email, "Pay the rent", "before friday")
+		create(t, repository, email, "Pay the bills", "")
+
+		response, err := repository.Search(context.TODO(), email, []string{"pay", "fri"}, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{both.ID}, ids(response))
+	})
+
+	t.Run("should follow updates and deletes", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+		created := create(t, repository, email, "Call mom", "")
+		deleted := create(t, repository, email, "Call the bank", "")
+
+		update := newTodo("Email mom")
+		update.Version = created.Version
+		_, err := repository.Update(ctx, email, created.ID, update)
+		require.NoError(t, err)
+		trash(t, repository, email, deleted, time.Now())
+
+		response, err := repository.Search(ctx, email, []string{"call"}, 10)
+		require.NoError(t, err)
+		assert.Empty(t, response)
+
+		response, err = repository.Search(ctx, email, []string{"email"}, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{created.ID}, ids(response))
+	})
+
+	t.Run("should isolate the todos per email and apply the limit", func(t *testing.T) {
+		repository := factory(t)
+		for i := 0; i < 3; i++ {
+			create(t, repository, email, "Water the plants", "")
+		}
+		create(t, repository, otherEmail, "Water the plants", "")
+
+		response, err := repository.Search(context.TODO(), email, []string{"water"}, 2)
+		require.NoError(t, err)
+		assert.Len(t, response, 2)
+	})
+
+	t.Run("should return ErrWhileRetrieving if the context is canceled", func(t *testing.T) {
+		repository := factory(t)
+
+		response, err := repository.Search(canceledContext(), email, []string{"milk"}, 10)
+		assert.ErrorIs(t, err, todo.ErrWhileRetrieving)
+		assert.Nil(t, response)
+	})
+}
+
+func testGetByID(t *testing.T, factory Factory) {
+	t.Run("should return ErrInvalidID if the id is not a uuid", func(t *testing.T) {
+		repository := factory(t)
+
+		response, err := repository.GetByID(context.TODO(), email, invalidID)
+		assert.ErrorIs(t, err, todo.ErrInvalidID)
+		assert.Zero(t, response)
+	})
+
+	t.Run("should return ErrTodoNotFound if the todo doesn't exist", func(t *testing.T) {
+		repository := factory(t)
+
+		response, err := repository.GetByID(context.TODO(), email, missingID)
+		assert.ErrorIs(t, err, todo.ErrTodoNotFound)
+		assert.Zero(t, response)
+	})
+
+	t.Run("should return ErrTodoNotFound if the todo belongs to another email", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+
+		created, err := repository.Create(ctx, otherEmail, newTodo("name"))
+		require.NoError(t, err)
+
+		response, err := repository.GetByID(ctx, email, created.ID)
+		assert.ErrorIs(t, err, todo.ErrTodoNotFound)
+		assert.Zero(t, response)
+	})
+
+	t.Run("should return ErrWhileRetrieving if the context is canceled", func(t *testing.T) {
+		repository := factory(t)
+
+		response, err := repository.GetByID(canceledContext(), email, missingID)
+		assert.ErrorIs(t, err, todo.ErrWhileRetrieving)
+		assert.Zero(t, response)
+	})
+}
+
+func testGetByIDs(t *testing.T, factory Factory) {
+	t.Run("should return ErrInvalidID if an id is not a uuid", func(t *testing.T) {
+		repository := factory(t)
+
+		response, err := repository.GetByIDs(context.TODO(), email, []string{missingID, invalidID})
+		assert.ErrorIs(t, err, todo.ErrInvalidID)
+		assert.Empty(t, response)
+	})
+
+	t.Run("should return the active, trashed and archived todos and skip the missing ones", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+		at := time.Date(2024, time.March, 2, 9, 0, 0, 0, time.UTC)
+
+		active, err := repository.Create(ctx, email, newTodo("active"))
+		require.NoError(t, err)
+		trashed, err := repository.Create(ctx, email, newTodo("trashed"))
+		require.NoError(t, err)
+		trashed = trash(t, repository, email, trashed, at)
+		archived, err := repository.Create(ctx, email, newTodo("archived"))
+		require.NoError(t, err)
+		archived.ArchivedAt = &at
+		archived, err = repository.Update(ctx, email, archived.ID, archived)
+		require.NoError(t, err)
+		other, err := repository.Create(ctx, otherEmail, newTodo("other"))
+		require.NoError(t, err)
+
+		response, err := repository.GetByIDs(ctx, email, []string{active.ID, missingID, trashed.ID, archived.ID, other.ID})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{active.ID, trashed.ID, archived.ID}, ids(response))
+		for _, todo := range response {
+			if todo.ID == archived.ID {
+				assertTodo(t, archived, todo)
+			}
+		}
+	})
+
+	t.Run("should return an empty slice without ids", func(t *testing.T) {
+		response, err := factory(t).GetByIDs(context.TODO(), email, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, response)
+	})
+
+	t.Run("should return ErrWhileRetrieving if the context is canceled", func(t *testing.T) {
+		repository := factory(t)
+
+		response, err := repository.GetByIDs(canceledContext(), email, []string{missingID})
+		assert.ErrorIs(t, err, todo.ErrWhileRetrieving)
+		assert.Empty(t, response)
+	})
+}
+
+func testUpdate(t *testing.T, factory Factory) {
+	t.Run("should return ErrInvalidID if the id is not a uuid", func(t *testing.T) {
+		repository := factory(t)
+
+		response, err := repository.Update(context.TODO(), email, invalidID, newTodo("name"))
+		assert.ErrorIs(t, err, todo.ErrInvalidID)
+		assert.Zero(t, response)
+	})
+
+	t.Run("should return ErrTodoNotFound if the todo doesn't exist", func(t *testing.T) {
+		repository := factory(t)
+
+		response, err := repository.Update(context.TODO(), email, missingID, newTodo("name"))
+		assert.ErrorIs(t, err, todo.ErrTodoNotFound)
+		assert.Zero(t, response)
+	})
+
+	t.Run("should return ErrTodoNotFound if the todo belongs to another email", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+
+		created, err := repository.Create(ctx, otherEmail, newTodo("name"))
+		require.NoError(t, err)
+
+		response, err := repository.Update(ctx, email, created.ID, newTodo("updated"))
+		assert.ErrorIs(t, err, todo.ErrTodoNotFound)
+		assert.Zero(t, response)
+
+		stored, err := repository.GetByID(ctx, otherEmail, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "name", stored.Name)
+	})
+
+	t.Run("should replace the todo and preserve its id", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+
+		created, err := repository.Create(ctx, email, newTodo("name"))
+		require.NoError(t, err)
+
+		completedAt := time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC)
+		update := newTodo("updated")
+		update.Description = "updated description"
+		update.Completed = true
+		update.CompletedAt = &completedAt
+		update.Version = created.Version
+		response, err := repository.Update(ctx, email, created.ID, update)
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, response.ID)
+
+		stored, err := repository.GetByID(ctx, email, created.ID)
+		require.NoError(t, err)
+		assertTodo(t, response, stored)
+
+		all, err := repository.GetAll(ctx, email, todo.TodoFilter{}, todo.PageRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{created.ID}, ids(all.Todos))
+	})
+
+	t.Run("should preserve the creation date and store the update date", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+
+		created, err := repository.Create(ctx, email, newTodo("name"))
+		require.NoError(t, err)
+
+		update := newTodo("updated")
+		update.CreatedAt = created.CreatedAt.Add(time.Hour)
+		update.UpdatedAt = created.UpdatedAt.Add(time.Hour)
+		update.Version = created.Version
+		response, err := repository.Update(ctx, email, created.ID, update)
+		require.NoError(t, err)
+		assert.True(t, created.CreatedAt.Equal(response.CreatedAt))
+		assert.True(t, update.UpdatedAt.Equal(response.UpdatedAt))
+
+		stored, err := repository.GetByID(ctx, email, created.ID)
+		require.NoError(t, err)
+		assert.True(t, created.CreatedAt.Equal(stored.CreatedAt))
+		assert.True(t, update.UpdatedAt.Equal(stored.UpdatedAt))
+	})
+
+	t.Run("should ignore the id of the given todo", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+
+		created, err := repository.Create(ctx, email, newTodo("name"))
+		require.NoError(t, err)
+
+		update := newTodo("updated")
+		update.ID = missingID
+		update.Version = created.Version
+		response, err := repository.Update(ctx, email, created.ID, update)
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, response.ID)
+
+		stored, err := repository.GetByID(ctx, email, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, stored.ID)
+	})
+
+	t.Run("should increment the version", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+
+		created, err := repository.Create(ctx, email, newTodo("name"))
+		require.NoError(t, err)
+
+		update := newTodo("updated")
+		update.Version = created.Version
+		response, err := repository.Update(ctx, email, created.ID, update)
+		require.NoError(t, err)
+		assert.Equal(t, created.Version+1, response.Version)
+
+		stored, err := repository.GetByID(ctx, email, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, response.Version, stored.Version)
+	})
+
+	t.Run("should require the exact version, starting at 1", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+
+		created, err := repository.Create(ctx, email, newTodo("name"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), created.Version)
+
+		update := newTodo("updated")
+		response, err := repository.Update(ctx, email, created.ID, update)
+		assert.ErrorIs(t, err, todo.ErrVersionMismatch)
+		assert.Zero(t, response)
+	})
+
+	t.Run("should return ErrVersionMismatch if the version is stale", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+
+		created, err := repository.Create(ctx, email, newTodo("name"))
+		require.NoError(t, err)
+
+		update := newTodo("first")
+		update.Version = created.Version
+		_, err = repository.Update(ctx, email, created.ID, update)
+		require.NoError(t, err)
+
+		update = newTodo("second")
+		update.Version = created.Version
+		response, err := repository.Update(ctx, email, created.ID, update)
+		assert.ErrorIs(t, err, todo.ErrVersionMismatch)
+		assert.Zero(t, response)
+
+		stored, err := repository.GetByID(ctx, email, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "first", stored.Name)
+	})
+
+	t.Run("should return ErrWhileUpdating if the context is canceled", func(t *testing.T) {
+		repository := factory(t)
+
+		response, err := repository.Update(canceledContext(), email, missingID, newTodo("name"))
+		assert.ErrorIs(t, err, todo.ErrWhileUpdating)
+		assert.Zero(t, response)
+	})
+}
+
+func testBatch(t *testing.T, factory Factory) {
+	rename := func(name string) func(models.Todo) (models.Todo, error) {
+		return func(stored models.Todo) (models.Todo, error) {
+			stored.Name = name
+			return stored, nil
+		}
+	}
+
+	t.Run("should apply every write in order", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+
+		updated, err := repository.Create(ctx, email, newTodo("Call mom"))
+		require.NoError(t, err)
+		deleted, err := repository.Create(ctx, email, newTodo("Call the bank"))
+		require.NoError(t, err)
+
+		results, err := repository.Batch(ctx, email, []todo.BatchWrite{
+			{Op: todo.BatchCreate, Todo: newTodo("Water the plants")},
+			{Op: todo.BatchUpdate, ID: updated.ID, Version: updated.Version, Apply: rename("Email mom")},
+			{Op: todo.BatchDelete, ID: deleted.ID},
+		})
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+		for _, result := range results {
+			assert.NoError(t, result.Err)
+		}
+
+		created := results[0].Todo
+		assert.NoError(t, uuid.Validate(created.ID))
+		assert.Equal(t, int64(1), created.Version)
+		assert.Equal(t, "Email mom", results[1].Todo.Name)
+		assert.Equal(t, updated.Version+1, results[1].Todo.Version)
+		assert.True(t, updated.CreatedAt.Equal(results[1].Todo.CreatedAt))
+
+		stored, err := repository.GetByID(ctx, email, created.ID)
+		require.NoError(t, err)
+		assertTodo(t, created, stored)
+
+		stored, err = repository.GetByID(ctx, email, updated.ID)
+		require.NoError(t, err)
+		assertTodo(t, results[1].Todo, stored)
+
+		_, err = repository.GetByID(ctx, email, deleted.ID)
+		assert.ErrorIs(t, err, todo.ErrTodoNotFound)
+
+		all, err := repository.GetAll(ctx, email, todo.TodoFilter{}, todo.PageRequest{Sort: todo.SortByName})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Email mom", "Water the plants"}, names(all.Todos))
+
+		found, err := repository.Search(ctx, email, []string{"call"}, 10)
+		require.NoError(t, err)
+		assert.Empty(t, found)
+	})
+
+	t.Run("should skip the writes that fail and apply the rest", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+
+		created, err := repository.Create(ctx, email, newTodo("name"))
+		require.NoError(t, err)
+		other, err := repository.Create(ctx, otherEmail, newTodo("other"))
+		require.NoError(t, err)
+
+		failing := func(models.Todo) (models.Todo, error) {
+			return models.Todo{}, todo.ErrTodoIsCompleted
+		}
+		results, err := repository.Batch(ctx, email, []todo.BatchWrite{
+			{Op: todo.BatchUpdate, ID: invalidID, Apply: rename("invalid")},
+			{Op: todo.BatchUpdate, ID: missingID, Apply: rename("missing")},
+			{Op: todo.BatchDelete, ID: other.ID},
+			{Op: todo.BatchUpdate, ID: created.ID, Version: created.Version + 1, Apply: rename("stale")},
+			{Op: todo.BatchUpdate, ID: created.ID, Apply: failing},
+			{Op: todo.BatchUpdate, ID: created.ID, Apply: rename("renamed")},
+		})
+		require.NoError(t, err)
+		require.Len(t, results, 6)
+		assert.ErrorIs(t, results[0].Err, todo.ErrInvalidID)
+		assert.ErrorIs(t, results[1].Err, todo.ErrTodoNotFound)
+		assert.ErrorIs(t, results[2].Err, todo.ErrTodoNotFound)
+		assert.ErrorIs(t, results[3].Err, todo.ErrVersionMismatch)
+		assert.ErrorIs(t, results[4].Err, todo.ErrTodoIsCompleted)
+		assert.NoError(t, results[5].Err)
+
+		stored, err := repository.GetByID(ctx, email, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "renamed", stored.Name)
+		assert.Equal(t, created.Version+1, stored.Version)
+
+		_, err = repository.GetByID(ctx, otherEmail, other.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should see the earlier writes of the batch", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+
+		created, err := repository.Create(ctx, email, newTodo("name"))
+		require.NoError(t, err)
+
+		results, err := repository.Batch(ctx, email, []todo.BatchWrite{
+			{Op: todo.BatchUpdate, ID: created.ID, Version: created.Version, Apply: rename("first")},
+			{Op: todo.BatchUpdate, ID: created.ID, Version: created.Version + 1, Apply: rename("second")},
+			{Op: todo.BatchDelete, ID: created.ID, Version: created.Version + 2},
+			{Op: todo.BatchDelete, ID: created.ID},
+		})
+		require.NoError(t, err)
+		require.Len(t, results, 4)
+		assert.NoError(t, results[0].Err)
+		assert.NoError(t, results[1].Err)
+		assert.NoError(t, results[2].Err)
+		assert.ErrorIs(t, results[3].Err, todo.ErrTodoNotFound)
+
+		_, err = repository.GetByID(ctx, email, created.ID)
+		assert.ErrorIs(t, err, todo.ErrTodoNotFound)
+	})
+
+	t.Run("should return ErrWhileWriting if the context is canceled", func(t *testing.T) {
+		repository := factory(t)
+
+		results, err := repository.Batch(canceledContext(), email, []todo.BatchWrite{
+			{Op: todo.BatchCreate, Todo: newTodo("name")},
+		})
+		assert.ErrorIs(t, err, todo.ErrWhileWriting)
+		assert.Nil(t, results)
+	})
+}
+
+func testTrash(t *testing.T, factory Factory) {
+	day := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("should only list the todos in the trash with the deleted filter", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+		kept, err := repository.Create(ctx, email, newTodo("kept"))
+		require.NoError(t, err)
+		created, err := repository.Create(ctx, email, newTodo("trashed"))
+		require.NoError(t, err)
+		trashed := trash(t, repository, email, created, day)
+
+		response, err := repository.GetAll(ctx, email, todo.TodoFilter{}, todo.PageRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{kept.ID}, ids(response.Todos))
+
+		response, err = repository.GetAll(ctx, email, todo.TodoFilter{Deleted: true}, todo.PageRequest{})
+		require.NoError(t, err)
+		require.Len(t, response.Todos, 1)
+		assertTodo(t, trashed, response.Todos[0])
+
+		stored, err := repository.GetByID(ctx, email, created.ID)
+		require.NoError(t, err)
+		assertTodo(t, trashed, stored)
+	})
+
+	t.Run("should not search the todos in the trash", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+		created, err := repository.Create(ctx, email, newTodo("Buy milk"))
+		require.NoError(t, err)
+		trash(t, repository, email, created, day)
+
+		response, err := repository.Search(ctx, email, []string{"milk"}, 10)
+		require.NoError(t, err)
+		assert.Empty(t, response)
+	})
+
+	t.Run("should restore the todo when the deletion date is cleared", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+		created, err := repository.Create(ctx, email, newTodo("Buy milk"))
+		require.NoError(t, err)
+		trashed := trash(t, repository, email, created, day)
+
+		trashed.DeletedAt = nil
+		_, err = repository.Update(ctx, email, created.ID, trashed)
+		require.NoError(t, err)
+
+		response, err := repository.GetAll(ctx, email, todo.TodoFilter{Deleted: true}, todo.PageRequest{})
+		require.NoError(t, err)
+		assert.Empty(t, response.Todos)
+
+		found, err := repository.Search(ctx, email, []string{"milk"}, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{created.ID}, ids(found))
+
+		purged, err := repository.Purge(ctx, day.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, purged)
+	})
+
+	t.Run("should purge the todos trashed before the date of every email", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+		kept, err := repository.Create(ctx, email, newTodo("kept"))
+		require.NoError(t, err)
+		old, err := repository.Create(ctx, email, newTodo("old"))
+		require.NoError(t, err)
+		recent, err := repository.Create(ctx, email, newTodo("recent"))
+		require.NoError(t, err)
+		other, err := repository.Create(ctx, otherEmail, newTodo("other"))
+		require.NoError(t, err)
+		trash(t, repository, email, old, day)
+		trash(t, repository, email, recent, day.Add(48*time.Hour))
+		trash(t, repository, otherEmail, other, day)
+
+		purged, err := repository.Purge(ctx, day.Add(24*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 2, purged)
+
+		_, err = repository.GetByID(ctx, email, old.ID)
+		assert.ErrorIs(t, err, todo.ErrTodoNotFound)
+		_, err = repository.GetByID(ctx, otherEmail, other.ID)
+		assert.ErrorIs(t, err, todo.ErrTodoNotFound)
+		_, err = repository.GetByID(ctx, email, recent.ID)
+		assert.NoError(t, err)
+		_, err = repository.GetByID(ctx, email, kept.ID)
+		assert.NoError(t, err)
+
+		purged, err = repository.Purge(ctx, day.Add(24*time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, purged)
+	})
+
+	t.Run("should return ErrWhilePurging if the context is canceled", func(t *testing.T) {
+		repository := factory(t)
+
+		_, err := repository.Purge(canceledContext(), day)
+		assert.ErrorIs(t, err, todo.ErrWhilePurging)
+	})
+}
+
+func testArchive(t *testing.T, factory Factory) {
+	day := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	// createCompleted creates a todo completed at the time.
+	createCompleted := func(t *testing.T, repository todo.Repository, email todo.Owner, name string, at time.Time) models.Todo {
+		completed := newTodo(name)
+		completed.Completed = true
+		completed.CompletedAt = &at
+		created, err := repository.Create(context.TODO(), email, completed)
+		require.NoError(t, err)
+		return created
+	}
+
+	// archive archives the todo as if it was archived at the time.
+	archive := func(t *testing.T, repository todo.Repository, email todo.Owner, created models.Todo, at time.Time) models.Todo {
+		created.ArchivedAt = &at
+		updated, err := repository.Update(context.TODO(), email, created.ID, created)
+		require.NoError(t, err)
+		return updated
+	}
+
+	t.Run("should only list the archived todos with the archived filter", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+		kept, err := repository.Create(ctx, email, newTodo("kept"))
+		require.NoError(t, err)
+		archived := archive(t, repository, email, createCompleted(t, repository, email, "archived", day), day)
+
+		response, err := repository.GetAll(ctx, email, todo.TodoFilter{}, todo.PageRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{kept.ID}, ids(response.Todos))
+
+		response, err = repository.GetAll(ctx, email, todo.TodoFilter{IncludeArchived: true}, todo.PageRequest{})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{kept.ID, archived.ID}, ids(response.Todos))
+
+		stored, err := repository.GetByID(ctx, email, archived.ID)
+		require.NoError(t, err)
+		assertTodo(t, archived, stored)
+	})
+
+	t.Run("should paginate through the active and archived todos in order", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+		for i, name := range []string{"a", "b", "c", "d", "e", "f"} {
+			created := createCompleted(t, repository, email, name, day)
+			if i%2 == 0 {
+				archive(t, repository, email, created, day)
+			}
+		}
+
+		var listed []models.Todo
+		page := todo.PageRequest{Limit: 2, Sort: todo.SortByName, Order: todo.Descending}
+		for {
+			response, err := repository.GetAll(ctx, email, todo.TodoFilter{IncludeArchived: true}, page)
+			require.NoError(t, err)
+			listed = append(listed, response.Todos...)
+			if response.NextCursor == "" {
+				break
+			}
+
+			page.Cursor = response.NextCursor
+		}
+
+		assert.Equal(t, []string{"f", "e", "d", "c", "b", "a"}, names(listed))
+	})
+
+	t.Run("should not search the archived todos", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+		archive(t, repository, email, createCompleted(t, repository, email, "Buy milk", day), day)
+
+		response, err := repository.Search(ctx, email, []string{"milk"}, 10)
+		require.NoError(t, err)
+		assert.Empty(t, response)
+	})
+
+	t.Run("should unarchive the todo when the archive date is cleared", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+		archived := archive(t, repository, email, createCompleted(t, repository, email, "Buy milk", day), day)
+
+		archived.ArchivedAt = nil
+		unarchived, err := repository.Update(ctx, email, archived.ID, archived)
+		require.NoError(t, err)
+
+		response, err := repository.GetAll(ctx, email, todo.TodoFilter{}, todo.PageRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{archived.ID}, ids(response.Todos))
+
+		found, err := repository.Search(ctx, email, []string{"milk"}, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{archived.ID}, ids(found))
+
+		stored, err := repository.GetByID(ctx, email, archived.ID)
+		require.NoError(t, err)
+		assertTodo(t, unarchived, stored)
+	})
+
+	t.Run("should purge the archived todos", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+		trashed := archive(t, repository, email, createCompleted(t, repository, email, "trashed", day), day)
+		trash(t, repository, email, trashed, day)
+
+		response, err := repository.GetAll(ctx, email, todo.TodoFilter{Deleted: true, IncludeArchived: true}, todo.PageRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{trashed.ID}, ids(response.Todos))
+
+		purged, err := repository.Purge(ctx, day.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 1, purged)
+
+		_, err = repository.GetByID(ctx, email, trashed.ID)
+		assert.ErrorIs(t, err, todo.ErrTodoNotFound)
+	})
+
+	t.Run("should update the archived todos in a batch", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+		archived := archive(t, repository, email, createCompleted(t, repository, email, "archived", day), day)
+
+		results, err := repository.Batch(ctx, email, []todo.BatchWrite{{
+			Op: todo.BatchUpdate,
+			ID: archived.ID,
+			Apply: func(stored models.Todo) (models.Todo, error) {
+				stored.Name = "renamed"
+				return stored, nil
+			},
+		}})
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		require.NoError(t, results[0].Err)
+
+		response, err := repository.GetAll(ctx, email, todo.TodoFilter{IncludeArchived: true}, todo.PageRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"renamed"}, names(response.Todos))
+
+		response, err = repository.GetAll(ctx, email, todo.TodoFilter{}, todo.PageRequest{})
+		require.NoError(t, err)
+		assert.Empty(t, response.Todos)
+	})
+
+	t.Run("should archive the todos completed before the date of every email", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+		open, err := repository.Create(ctx, email, newTodo("open"))
+		require.NoError(t, err)
+		old := createCompleted(t, repository, email, "old", day)
+		recent := createCompleted(t, repository, email, "recent", day.Add(48*time.Hour))
+		other := createCompleted(t, repository, otherEmail, "other", day)
+		trashed := createCompleted(t, repository, email, "trashed", day)
+		trashed.DeletedAt = &day
+		_, err = repository.Update(ctx, email, trashed.ID, trashed)
+		require.NoError(t, err)
+		reopened := createCompleted(t, repository, email, "reopened", day)
+		reopened.Completed = false
+		reopened.CompletedAt = nil
+		_, err = repository.Update(ctx, email, reopened.ID, reopened)
+		require.NoError(t, err)
+
+		at := day.Add(72 * time.Hour)
+		archived, err := repository.ArchiveCompleted(ctx, day.Add(24*time.Hour), at)
+		require.NoError(t, err)
+		assert.Equal(t, 2, archived)
+
+		response, err := repository.GetAll(ctx, email, todo.TodoFilter{}, todo.PageRequest{})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{open.ID, recent.ID, reopened.ID}, ids(response.Todos))
+
+		expected := old
+		expected.ArchivedAt = &at
+		expected.UpdatedAt = at
+		expected.Version = old.Version + 1
+		stored, err := repository.GetByID(ctx, email, old.ID)
+		require.NoError(t, err)
+		assertTodo(t, expected, stored)
+
+		stored, err = repository.GetByID(ctx, otherEmail, other.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, stored.ArchivedAt)
+
+		archived, err = repository.ArchiveCompleted(ctx, day.Add(24*time.Hour), at)
+		require.NoError(t, err)
+		assert.Zero(t, archived)
+	})
+
+	t.Run("should return ErrWhileArchiving if the context is canceled", func(t *testing.T) {
+		repository := factory(t)
+
+		_, err := repository.ArchiveCompleted(canceledContext(), day, day)
+		assert.ErrorIs(t, err, todo.ErrWhileArchiving)
+	})
+}
+
+func testShare(t *testing.T, factory Factory) {
+	share := func(owner todo.Owner, id string, role models.Role) models.Share {
+		return models.Share{TodoID: id, Owner: owner.String(), Grantee: grantee.String(), Role: role}
+	}
+
+	t.Run("should return ErrInvalidID if the id is not a uuid", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+
+		assert.ErrorIs(t, repository.Share(ctx, email, invalidID, grantee, models.RoleViewer), todo.ErrInvalidID)
+		assert.ErrorIs(t, repository.Unshare(ctx, email, invalidID, grantee), todo.ErrInvalidID)
+		_, err := repository.GetShares(ctx, email, invalidID)
+		assert.ErrorIs(t, err, todo.ErrInvalidID)
+		_, err = repository.GetShare(ctx, grantee, invalidID)
+		assert.ErrorIs(t, err, todo.ErrInvalidID)
+	})
+
+	t.Run("should return ErrTodoNotFound if the todo belongs to another email", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+
+		created, err := repository.Create(ctx, otherEmail, newTodo("name"))
+		require.NoError(t, err)
+
+		err = repository.Share(ctx, email, created.ID, grantee, models.RoleViewer)
+		assert.ErrorIs(t, err, todo.ErrTodoNotFound)
+
+		_, err = repository.GetShare(ctx, grantee, created.ID)
+		assert.ErrorIs(t, err, todo.ErrShareNotFound)
+	})
+
+	t.Run("should list the share for the owner and the grantee", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+
+		created, err := repository.Create(ctx, email, newTodo("name"))
+		require.NoError(t, err)
+		other, err := repository.Create(ctx, otherEmail, newTodo("other"))
+		require.NoError(t, err)
+		require.NoError(t, repository.Share(ctx, email, created.ID, grantee, models.RoleViewer))
+		require.NoError(t, repository.Share(ctx, otherEmail, other.ID, grantee, models.RoleEditor))
+		require.NoError(t, repository.Share(ctx, email, created.ID, otherEmail, models.RoleEditor))
+
+		shares, err := repository.GetShares(ctx, email, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []models.Share{
+			share(email, created.ID, models.RoleViewer),
+			{TodoID: created.ID, Owner: email.String(), Grantee: otherEmail.String(), Role: models.RoleEditor},
+		}, shares)
+
+		shares, err = repository.GetSharedWith(ctx, grantee)
+		require.NoError(t, err)
+		assert.Equal(t, []models.Share{share(otherEmail, other.ID, models.RoleEditor), share(email, created.ID, models.RoleViewer)}, shares)
+
+		response, err := repository.GetShare(ctx, grantee, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, share(email, created.ID, models.RoleViewer), response)
+	})
+
+	t.Run("should return no shares for a todo or grantee without any", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+
+		shares, err := repository.GetShares(ctx, email, missingID)
+		require.NoError(t, err)
+		assert.Empty(t, shares)
+
+		shares, err = repository.GetSharedWith(ctx, grantee)
+		require.NoError(t, err)
+		assert.Empty(t, shares)
+
+		_, err = repository.GetShare(ctx, grantee, missingID)
+		assert.ErrorIs(t, err, todo.ErrShareNotFound)
+	})
+
+	t.Run("should replace the role of the grantee", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+
+		created, err := repository.Create(ctx, email, newTodo("name"))
+		require.NoError(t, err)
+		require.NoError(t, repository.Share(ctx, email, created.ID, grantee, models.RoleViewer))
+		require.NoError(t, repository.Share(ctx, email, created.ID, grantee, models.RoleEditor))
+
+		shares, err := repository.GetShares(ctx, email, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []models.Share{share(email, created.ID, models.RoleEditor)}, shares)
+
+		response, err := repository.GetShare(ctx, grantee, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.RoleEditor, response.Role)
+	})
+
+	t.Run("should revoke the share", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+
+		created, err := repository.Create(ctx, email, newTodo("name"))
+		require.NoError(t, err)
+		require.NoError(t, repository.Share(ctx, email, created.ID, grantee, models.RoleViewer))
+
+		assert.ErrorIs(t, repository.Unshare(ctx, otherEmail, created.ID, grantee), todo.ErrShareNotFound)
+		require.NoError(t, repository.Unshare(ctx, email, created.ID, grantee))
+		assert.ErrorIs(t, repository.Unshare(ctx, email, created.ID, grantee), todo.ErrShareNotFound)
+
+		shares, err := repository.GetShares(ctx, email, created.ID)
+		require.NoError(t, err)
+		assert.Empty(t, shares)
+
+		_, err = repository.GetShare(ctx, grantee, created.ID)
+		assert.ErrorIs(t, err, todo.ErrShareNotFound)
+	})
+
+	t.Run("should remove the shares of the todos purged", func(t *testing.T) {
+		ctx := context.TODO()
+		repository := factory(t)
+
+		purged, err := repository.Create(ctx, email, newTodo("purged"))
+		require.NoError(t, err)
+		trashed, err := repository.Create(ctx, email, newTodo("trashed"))
+		require.NoError(t, err)
+		require.NoError(t, repository.Share(ctx, email, purged.ID, grantee, models.RoleViewer))
+		require.NoError(t, repository.Share(ctx, email, trashed.ID, grantee, models.RoleViewer))
+
+		deletedAt := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+		trash(t, repository, email, purged, deletedAt)
+		trash(t, repository, email, trashed, deletedAt.Add(2*time.Hour))
+		_, err = repository.Purge(ctx, deletedAt.Add(time.Hour))
+		require.NoError(t, err)
+
+		shares, err := repository.GetSharedWith(ctx, grantee)
+		require.NoError(t, err)
+		assert.Equal(t, []string{trashed.ID}, shareIDs(shares))
+
+		shares, err = repository.GetShares(ctx, email, purged.ID)
+		require.NoError(t, err)
+		assert.Empty(t, shares)
+
+		shares, err = repository.GetShares(ctx, email, trashed.ID)
+		require.NoError(t, err)
+		assert.Len(t, shares, 1)
+	})
+
+	t.Run("should return ErrWhileSharing if the context is canceled", func(t *testing.T) {
+		repository := factory(t)
+
+		err := repository.Share(canceledContext(), email, missingID, grantee, models.RoleViewer)
+		assert.ErrorIs(t, err, todo.ErrWhileSharing)
+	})
+}
+
+// trash moves the todo to the trash as if it was deleted at the time.
+func trash(t *testing.T, repository todo.Repository, email todo.Owner, created models.Todo, at time.Time) models.Todo {
+	created.DeletedAt = &at
+	updated, err := repository.Update(context.TODO(), email, created.ID, created)
+	require.NoError(t, err)
+	return updated
+}
+
+func newTodo(name string) models.Todo {
+	startDate := time.Date(2024, time.March, 1, 9, 0, 0, 0, time.UTC)
+	return models.Todo{
+		Name:        name,
+		Description: "description",
+		StartDate:   startDate,
+		DueDate:     startDate.Add(time.Hour * 24),
+		CreatedAt:   startDate.Add(-time.Hour * 24),
+		UpdatedAt:   startDate.Add(-time.Hour * 24),
+	}
+}
+
+func canceledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+
+	return ctx
+}
+
+func ids(todos []models.Todo) []string {
+	response := make([]string, 0, len(todos))
+	for _, todo := range todos {
+		response = append(response, todo.ID)
+	}
+
+	return response
+}
+
+func shareIDs(shares []models.Share) []string {
+	result := make([]string, len(shares))
+	for i, share := range shares {
+		result[i] = share.TodoID
+	}
+
+	return result
+}
+
+func names(todos []models.Todo) []string {
+	response := make([]string, 0, len(todos))
+	for _, todo := range todos {
+		response = append(response, todo.Name)
+	}
+
+	return response
+}
+
+// assertSorted checks every todo comes after the previous one by the field
+// or, if both have the same value, by id.
+func assertSorted(t *testing.T, todos []models.Todo, field todo.SortField, order todo.SortOrder) {
+	t.Helper()
+
+	for i := 1; i < len(todos); i++ {
+		previous, current := todos[i-1], todos[i]
+		comparison := compareField(previous, current, field)
+		if comparison == 0 {
+			comparison = strings.Compare(previous.ID, current.ID)
+		}
+
+		if order == todo.Descending {
+			comparison = -comparison
+		}
+
+		assert.Negative(t, comparison, "%s %s: %q is listed before %q", field, order, previous.ID, current.ID)
+	}
+}
+
+func compareField(a, b models.Todo, field todo.SortField) int {
+	switch field {
+	case todo.SortByDueDate:
+		return a.DueDate.Compare(b.DueDate)
+	case todo.SortByStartDate:
+		return a.StartDate.Compare(b.StartDate)
+	case todo.SortByName:
+		return strings.Compare(a.Name, b.Name)
+	default:
+		return a.CreatedAt.Compare(b.CreatedAt)
+	}
+}
+
+// assertTodo compares todos field by field since backends may return the
+// dates in a different location than the one they were saved with.
+func assertTodo(t *testing.T, expected, actual models.Todo) {
+	t.Helper()
+
+	assert.Equal(t, expected.ID, actual.ID)
+	assert.Equal(t, expected.Name, actual.Name)
+	assert.Equal(t, expected.Description, actual.Description)
+	assert.True(t, expected.StartDate.Equal(actual.StartDate), "start date: expected %s, got %s", expected.StartDate, actual.StartDate)
+	assert.True(t, expected.DueDate.Equal(actual.DueDate), "due date: expected %s, got %s", expected.DueDate, actual.DueDate)
+	assert.Equal(t, expected.Completed, actual.Completed)
+	assert.Equal(t, expected.Version, actual.Version)
+	assert.True(t, expected.CreatedAt.Equal(actual.CreatedAt), "created at: expected %s, got %s", expected.CreatedAt, actual.CreatedAt)
+	assert.True(t, expected.UpdatedAt.Equal(actual.UpdatedAt), "updated at: expected %s, got %s", expected.UpdatedAt, actual.UpdatedAt)
+	if expected.CompletedAt == nil {
+		assert.Nil(t, actual.CompletedAt)
+	} else if assert.NotNil(t, actual.CompletedAt) {
+		assert.True(t, expected.CompletedAt.Equal(*actual.CompletedAt))
+	}
+	if expected.DeletedAt == nil {
+		assert.Nil(t, actual.DeletedAt)
+	} else if assert.NotNil(t, actual.DeletedAt) {
+		assert.True(t, expected.DeletedAt.Equal(*actual.DeletedAt))
+	}
+	if expected.ArchivedAt == nil {
+		assert.Nil(t, actual.ArchivedAt)
+	} else if assert.NotNil(t, actual.ArchivedAt) {
+		assert.True(t, expected.ArchivedAt.Equal(*actual.ArchivedAt))
+	}
+}
@@ -0,0 +1,104 @@
+package todo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"todo-app/todo/models"
+)
+
+// MaxSearchTerms bounds the words of a search query, every one of them is a
+// lookup in the search index.
+const MaxSearchTerms = 10
+
+var ErrInvalidQuery = fmt.Errorf("the search query must have between 1 and %d words", MaxSearchTerms)
+
+// tokenize splits the text into lowercase words of letters and digits.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// searchTerms returns the distinct words of the name and description of the
+// todo, which are the entries of the search index.
+func searchTerms(todo models.Todo) []string {
+	return unique(append(tokenize(todo.Name), tokenize(todo.Description)...))
+}
+
+func unique(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	response := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			response = append(response, term)
+		}
+	}
+
+	return response
+}
+
+// relevance scores how well the todo matches every term, which is zero if
+// any of them is missing. Whole words count more than prefixes and words of
+// the name more than the ones of the description.
+func relevance(todo models.Todo, terms []string) float64 {
+	name, description := tokenize(todo.Name), tokenize(todo.Description)
+
+	var score float64
+	for _, term := range terms {
+		termScore := 2*match(name, term) + match(description, term)
+		if termScore == 0 {
+			return 0
+		}
+
+		score += termScore
+	}
+
+	return score
+}
+
+func match(words []string, term string) float64 {
+	var score float64
+	for _, word := range words {
+		if word == term {
+			return 1
+		}
+
+		if strings.HasPrefix(word, term) {
+			score = 0.5
+		}
+	}
+
+	return score
+}
+
+// rank keeps the todos matching every term, sorted from the most relevant
+// and then by id, up to the limit.
+func rank(todos []models.Todo, terms []string, limit int) []models.Todo {
+	scores := make(map[string]float64, len(todos))
+	var response []models.Todo
+	for _, todo := range todos {
+		if score := relevance(todo, terms); score > 0 {
+			scores[todo.ID] = score
+			response = append(response, todo)
+		}
+	}
+
+	sort.Slice(response, func(i, j int) bool {
+		a, b := response[i], response[j]
+		if scores[a.ID] != scores[b.ID] {
+			return scores[a.ID] > scores[b.ID]
+		}
+
+		return a.ID < b.ID
+	})
+
+	if len(response) > limit {
+		response = response[:limit]
+	}
+
+	return response
+}
@@ -2,7 +2,9 @@ package todo
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"todo-app/todo/dtos"
@@ -14,63 +16,202 @@ var (
 	ErrInvalidDueDate           = fmt.Errorf("due date can not be parsed")
 	ErrStartDateMustBeGTDueDate = fmt.Errorf("start date must be before the due date")
 	ErrTodoIsCompleted          = fmt.Errorf("the todo cannot be modified if it's completed")
+	ErrTodoIsNotCompleted       = fmt.Errorf("the todo cannot be reopened if it's not completed")
+	ErrInvalidPatch             = fmt.Errorf("the name, start date and due date can't be removed")
+	ErrTodoIsNotDeleted         = fmt.Errorf("the todo cannot be restored if it's not in the trash")
+	ErrTodoIsArchived           = fmt.Errorf("the todo cannot be modified if it's archived")
+	ErrTodoIsNotArchived        = fmt.Errorf("the todo cannot be unarchived if it's not archived")
+	ErrTodoIsOpen               = fmt.Errorf("the todo cannot be archived if it's not completed")
 )
 
+// Service manages the todos of every email and the ones shared with it.
+//
 //go:generate mockgen -destination mocks/service_mock.go -package mocks . Service
 type Service interface {
-	Create(ctx context.Context, email string, dto dtos.CreateTodo) (models.Todo, error)
-	GetAll(ctx context.Context, email string) ([]models.Todo, error)
-	GetByID(ctx context.Context, email string, id string) (models.Todo, error)
-	Delete(ctx context.Context, email string, id string) error
-	Update(ctx context.Context, email string, id string, todo dtos.UpdateTodo) (models.Todo, error)
+	Create(ctx context.Context, email Owner, dto dtos.CreateTodo) (models.Todo, error)
+	GetAll(ctx context.Context, email Owner, filter TodoFilter, page PageRequest) (Page, error)
+	GetByID(ctx context.Context, email Owner, id string) (models.Todo, error)
+	Delete(ctx context.Context, email Owner, id string, version int64) error
+	Update(ctx context.Context, email Owner, id string, version int64, todo dtos.UpdateTodo) (models.Todo, error)
+	Patch(ctx context.Context, email Owner, id string, version int64, patch dtos.PatchTodo) (models.Todo, error)
+	Complete(ctx context.Context, email Owner, id string) (models.Todo, error)
+	Reopen(ctx context.Context, email Owner, id string) (models.Todo, error)
+	Search(ctx context.Context, email Owner, query string, limit int) ([]models.Todo, error)
+	Batch(ctx context.Context, email Owner, operations []dtos.BatchOperation) ([]BatchResult, error)
+	Trash(ctx context.Context, email Owner, page PageRequest) (Page, error)
+	Restore(ctx context.Context, email Owner, id string) (models.Todo, error)
+	Archive(ctx context.Context, email Owner, id string) (models.Todo, error)
+	Unarchive(ctx context.Context, email Owner, id string) (models.Todo, error)
+	Share(ctx context.Context, email Owner, id string, grantee Owner, role models.Role) (models.Share, error)
+	Unshare(ctx context.Context, email Owner, id string, grantee Owner) error
+	GetShares(ctx context.Context, email Owner, id string) ([]models.Share, error)
 }
 
 type TodosService struct {
 	repository Repository
+	clock      Clock
 }
 
-func NewTodosService(repository Repository) *TodosService {
+func NewTodosService(repository Repository, clock Clock) *TodosService {
 	return &TodosService{
 		repository: repository,
+		clock:      clock,
 	}
 }
 
-func (t *TodosService) Create(ctx context.Context, email string, dto dtos.CreateTodo) (models.Todo, error) {
+func (t *TodosService) Create(ctx context.Context, email Owner, dto dtos.CreateTodo) (models.Todo, error) {
 	startDate, dueDate, err := validateDates(dto.StartDate, dto.DueDate)
 	if err != nil {
 		return models.Todo{}, err
 	}
 
+	now := t.clock.Now()
 	todo := models.Todo{
 		DueDate:     dueDate,
 		StartDate:   startDate,
 		Name:        dto.Name,
 		Completed:   false,
 		Description: dto.Description,
+		CreatedAt:   now,
+		UpdatedAt:   now,
 	}
 
 	return t.repository.Create(ctx, email, todo)
 }
 
-func (t *TodosService) GetAll(ctx context.Context, email string) ([]models.Todo, error) {
-	return t.repository.GetAll(ctx, email)
+func (t *TodosService) GetAll(ctx context.Context, email Owner, filter TodoFilter, page PageRequest) (Page, error) {
+	page, err := validatePage(page)
+	if err != nil {
+		return Page{}, err
+	}
+
+	filter, err = filter.resolve(t.clock.Now())
+	if err != nil {
+		return Page{}, err
+	}
+
+	filter.Deleted = false
+	shares, err := t.repository.GetSharedWith(ctx, email)
+	if err != nil {
+		return Page{}, err
+	}
+
+	if len(shares) == 0 {
+		return t.repository.GetAll(ctx, email, filter, page)
+	}
+
+	return t.getAllShared(ctx, email, shares, filter, page)
+}
+
+// getAllShared merges the todos shared with the email into its page. One
+// more own todo than the limit is asked for, so the merged page can tell
+// whether there's another one. The shared todos are read at once per owner,
+// and the ones that are gone are skipped.
+func (t *TodosService) getAllShared(ctx context.Context, email Owner, shares []models.Share, filter TodoFilter, page PageRequest) (Page, error) {
+	after, err := page.after()
+	if err != nil {
+		return Page{}, err
+	}
+
+	own := page
+	own.Limit++
+	result, err := t.repository.GetAll(ctx, email, filter, own)
+	if err != nil {
+		return Page{}, err
+	}
+
+	var owners []string
+	ids := make(map[string][]string)
+	for _, share := range shares {
+		if _, ok := ids[share.Owner]; !ok {
+			owners = append(owners, share.Owner)
+		}
+		ids[share.Owner] = append(ids[share.Owner], share.TodoID)
+	}
+
+	field, order := page.sort(), page.order()
+	todos := result.Todos
+	for _, owner := range owners {
+		shared, err := t.repository.GetByIDs(ctx, Owner(owner), ids[owner])
+		if err != nil {
+			return Page{}, err
+		}
+
+		for _, todo := range shared {
+			if filter.matches(todo) && (after == nil || isAfter(sortKey(todo, field), after.key(), order)) {
+				todo.Owner = owner
+				todos = append(todos, todo)
+			}
+		}
+	}
+
+	sort.Slice(todos, func(i, j int) bool {
+		return isAfter(sortKey(todos[j], field), sortKey(todos[i], field), order)
+	})
+
+	if limit := page.limit(); len(todos) > limit+1 {
+		todos = todos[:limit+1]
+	}
+
+	return newPage(todos, page), nil
+}
+
+func (t *TodosService) GetByID(ctx context.Context, email Owner, id string) (models.Todo, error) {
+	owner, todo, err := t.getVersion(ctx, email, id, 0, canView)
+	if err != nil {
+		return models.Todo{}, err
+	}
+
+	return sharedBy(todo, owner, email), nil
+}
+
+func (t *TodosService) Delete(ctx context.Context, email Owner, id string, version int64) error {
+	_, todo, err := t.getVersion(ctx, email, id, version, canManage)
+	if err != nil {
+		return err
+	}
+
+	deletedAt := t.clock.Now()
+	todo.DeletedAt = &deletedAt
+	todo.UpdatedAt = deletedAt
+
+	_, err = t.repository.Update(ctx, email, id, todo)
+	return err
 }
 
-func (t *TodosService) GetByID(ctx context.Context, email string, id string) (models.Todo, error) {
-	return t.repository.GetByID(ctx, email, id)
+// Trash lists the deleted todos that haven't been purged yet.
+func (t *TodosService) Trash(ctx context.Context, email Owner, page PageRequest) (Page, error) {
+	page, err := validatePage(page)
+	if err != nil {
+		return Page{}, err
+	}
+
+	return t.repository.GetAll(ctx, email, TodoFilter{Deleted: true, IncludeArchived: true}, page)
 }
 
-func (t *TodosService) Delete(ctx context.Context, email string, id string) error {
-	return t.repository.Delete(ctx, email, id)
+func (t *TodosService) Restore(ctx context.Context, email Owner, id string) (models.Todo, error) {
+	_, todo, err := t.locate(ctx, email, id, canManage)
+	if err != nil {
+		return models.Todo{}, err
+	}
+
+	if todo.DeletedAt == nil {
+		return models.Todo{}, ErrTodoIsNotDeleted
+	}
+
+	todo.DeletedAt = nil
+	todo.UpdatedAt = t.clock.Now()
+
+	return t.repository.Update(ctx, email, id, todo)
 }
 
-func (t *TodosService) Update(ctx context.Context, email string, id string, dto dtos.UpdateTodo) (models.Todo, error) {
+func (t *TodosService) Update(ctx context.Context, email Owner, id string, version int64, dto dtos.UpdateTodo) (models.Todo, error) {
 	startDate, dueDate, err := validateDates(dto.StartDate, dto.DueDate)
 	if err != nil {
 		return models.Todo{}, err
 	}
 
-	todo, err := t.repository.GetByID(ctx, email, id)
+	owner, todo, err := t.getVersion(ctx, email, id, version, canEdit)
 	if err != nil {
 		return models.Todo{}, err
 	}
@@ -84,11 +225,386 @@ func (t *TodosService) Update(ctx context.Context, email string, id string, dto
 		StartDate:   startDate,
 		Name:        dto.Name,
 		Description: dto.Description,
+		CreatedAt:   todo.CreatedAt,
+		UpdatedAt:   t.clock.Now(),
+		Version:     todo.Version,
+	}
+
+	return t.update(ctx, email, owner, id, todo)
+}
+
+// Patch applies a merge patch to the todo. A removed description is left
+// empty, the rest of the fields are required.
+func (t *TodosService) Patch(ctx context.Context, email Owner, id string, version int64, patch dtos.PatchTodo) (models.Todo, error) {
+	if patch.Name.Null || patch.StartDate.Null || patch.DueDate.Null {
+		return models.Todo{}, ErrInvalidPatch
+	}
+
+	owner, todo, err := t.getVersion(ctx, email, id, version, canEdit)
+	if err != nil {
+		return models.Todo{}, err
+	}
+
+	if todo.Completed {
+		return models.Todo{}, ErrTodoIsCompleted
+	}
+
+	startDate, dueDate := todo.StartDate.UTC().Format(time.DateTime), todo.DueDate.UTC().Format(time.DateTime)
+	if patch.StartDate.Set {
+		startDate = patch.StartDate.Value
+	}
+
+	if patch.DueDate.Set {
+		dueDate = patch.DueDate.Value
+	}
+
+	todo.StartDate, todo.DueDate, err = validateDates(startDate, dueDate)
+	if err != nil {
+		return models.Todo{}, err
+	}
+
+	if patch.Name.Set {
+		todo.Name = patch.Name.Value
+	}
+
+	if patch.Description.Set {
+		todo.Description = patch.Description.Value
+	}
+
+	todo.UpdatedAt = t.clock.Now()
+	return t.update(ctx, email, owner, id, todo)
+}
+
+func (t *TodosService) Complete(ctx context.Context, email Owner, id string) (models.Todo, error) {
+	owner, todo, err := t.getVersion(ctx, email, id, 0, canEdit)
+	if err != nil {
+		return models.Todo{}, err
+	}
+
+	if todo.Completed {
+		return models.Todo{}, ErrTodoIsCompleted
+	}
+
+	completedAt := t.clock.Now()
+	todo.Completed = true
+	todo.CompletedAt = &completedAt
+	todo.UpdatedAt = completedAt
+
+	return t.update(ctx, email, owner, id, todo)
+}
+
+func (t *TodosService) Reopen(ctx context.Context, email Owner, id string) (models.Todo, error) {
+	owner, todo, err := t.getVersion(ctx, email, id, 0, canEdit)
+	if err != nil {
+		return models.Todo{}, err
+	}
+
+	if !todo.Completed {
+		return models.Todo{}, ErrTodoIsNotCompleted
+	}
+
+	if todo.ArchivedAt != nil {
+		return models.Todo{}, ErrTodoIsArchived
+	}
+
+	todo.Completed = false
+	todo.CompletedAt = nil
+	todo.UpdatedAt = t.clock.Now()
+
+	return t.update(ctx, email, owner, id, todo)
+}
+
+func (t *TodosService) Archive(ctx context.Context, email Owner, id string) (models.Todo, error) {
+	_, todo, err := t.getVersion(ctx, email, id, 0, canManage)
+	if err != nil {
+		return models.Todo{}, err
+	}
+
+	if todo.ArchivedAt != nil {
+		return models.Todo{}, ErrTodoIsArchived
 	}
 
+	if !todo.Completed {
+		return models.Todo{}, ErrTodoIsOpen
+	}
+
+	archivedAt := t.clock.Now()
+	todo.ArchivedAt = &archivedAt
+	todo.UpdatedAt = archivedAt
+
 	return t.repository.Update(ctx, email, id, todo)
 }
 
+func (t *TodosService) Unarchive(ctx context.Context, email Owner, id string) (models.Todo, error) {
+	_, todo, err := t.getVersion(ctx, email, id, 0, canManage)
+	if err != nil {
+		return models.Todo{}, err
+	}
+
+	if todo.ArchivedAt == nil {
+		return models.Todo{}, ErrTodoIsNotArchived
+	}
+
+	todo.ArchivedAt = nil
+	todo.UpdatedAt = t.clock.Now()
+
+	return t.repository.Update(ctx, email, id, todo)
+}
+
+func (t *TodosService) Search(ctx context.Context, email Owner, query string, limit int) ([]models.Todo, error) {
+	if limit == 0 {
+		limit = DefaultPageLimit
+	}
+
+	if limit < 0 || limit > MaxPageLimit {
+		return nil, ErrInvalidLimit
+	}
+
+	terms := unique(tokenize(query))
+	if len(terms) == 0 || len(terms) > MaxSearchTerms {
+		return nil, ErrInvalidQuery
+	}
+
+	return t.repository.Search(ctx, email, terms, limit)
+}
+
+// Share grants the grantee the role on a todo of the email, replacing the one
+// it had.
+func (t *TodosService) Share(ctx context.Context, email Owner, id string, grantee Owner, role models.Role) (models.Share, error) {
+	if err := validateRole(role); err != nil {
+		return models.Share{}, err
+	}
+
+	if grantee == email {
+		return models.Share{}, ErrInvalidGrantee
+	}
+
+	if _, _, err := t.getVersion(ctx, email, id, 0, canManage); err != nil {
+		return models.Share{}, err
+	}
+
+	if err := t.repository.Share(ctx, email, id, grantee, role); err != nil {
+		return models.Share{}, err
+	}
+
+	return models.Share{TodoID: id, Owner: email.String(), Grantee: grantee.String(), Role: role}, nil
+}
+
+func (t *TodosService) Unshare(ctx context.Context, email Owner, id string, grantee Owner) error {
+	if _, _, err := t.locate(ctx, email, id, canManage); err != nil {
+		return err
+	}
+
+	return t.repository.Unshare(ctx, email, id, grantee)
+}
+
+func (t *TodosService) GetShares(ctx context.Context, email Owner, id string) ([]models.Share, error) {
+	if _, _, err := t.locate(ctx, email, id, canManage); err != nil {
+		return nil, err
+	}
+
+	return t.repository.GetShares(ctx, email, id)
+}
+
+// getVersion reads the todo, checking it's at the version given unless it's
+// 0. The repository checks it again when the todo is written. Todos in the
+// trash aren't found.
+func (t *TodosService) getVersion(ctx context.Context, email Owner, id string, version int64, permission permission) (Owner, models.Todo, error) {
+	owner, todo, err := t.locate(ctx, email, id, permission)
+	if err != nil {
+		return "", models.Todo{}, err
+	}
+
+	if todo.DeletedAt != nil {
+		return "", models.Todo{}, ErrTodoNotFound
+	}
+
+	if version != 0 && todo.Version != version {
+		return "", models.Todo{}, ErrVersionMismatch
+	}
+
+	return owner, todo, nil
+}
+
+// locate reads the todo of the email or, if it has none with the id, the one
+// shared with it, returning its owner along with it. ErrForbidden is returned
+// if the role of the share doesn't allow the permission.
+func (t *TodosService) locate(ctx context.Context, email Owner, id string, permission permission) (Owner, models.Todo, error) {
+	todo, err := t.repository.GetByID(ctx, email, id)
+	if err == nil {
+		return email, todo, nil
+	}
+
+	if !errors.Is(err, ErrTodoNotFound) {
+		return "", models.Todo{}, err
+	}
+
+	share, err := t.repository.GetShare(ctx, email, id)
+	if errors.Is(err, ErrShareNotFound) {
+		return "", models.Todo{}, ErrTodoNotFound
+	}
+
+	if err != nil {
+		return "", models.Todo{}, err
+	}
+
+	if !permission.grantedTo(share.Role) {
+		return "", models.Todo{}, ErrForbidden
+	}
+
+	owner := Owner(share.Owner)
+	todo, err = t.repository.GetByID(ctx, owner, id)
+	if err != nil {
+		return "", models.Todo{}, err
+	}
+
+	return owner, todo, nil
+}
+
+// update writes the todo to its owner, which is the email unless it's shared
+// with it.
+func (t *TodosService) update(ctx context.Context, email Owner, owner Owner, id string, todo models.Todo) (models.Todo, error) {
+	todo, err := t.repository.Update(ctx, owner, id, todo)
+	if err != nil {
+		return models.Todo{}, err
+	}
+
+	return sharedBy(todo, owner, email), nil
+}
+
+// Batch validates every operation on its own and writes the valid ones in a
+// single call to the repository. The results follow the order of the
+// operations, the invalid ones carry their error.
+func (t *TodosService) Batch(ctx context.Context, email Owner, operations []dtos.BatchOperation) ([]BatchResult, error) {
+	if len(operations) == 0 || len(operations) > MaxBatchSize {
+		return nil, ErrInvalidBatch
+	}
+
+	results := make([]BatchResult, len(operations))
+	writes := make([]BatchWrite, 0, len(operations))
+	indexes := make([]int, 0, len(operations))
+	now := t.clock.Now()
+	for i, operation := range operations {
+		write, err := newBatchWrite(operation, now)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		writes = append(writes, write)
+		indexes = append(indexes, i)
+	}
+
+	if len(writes) == 0 {
+		return results, nil
+	}
+
+	written, err := t.repository.Batch(ctx, email, writes)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, result := range written {
+		results[indexes[i]] = result
+	}
+
+	return results, nil
+}
+
+// newBatchWrite checks an operation of a batch. Updates and deletes follow
+// the rules of Update and Delete, applied to the todo when it's read by the
+// repository, so deletes are updates that move the todo to the trash.
+func newBatchWrite(operation dtos.BatchOperation, now time.Time) (BatchWrite, error) {
+	op := BatchOp(operation.Op)
+	if op == BatchDelete {
+		if err := validateID(operation.ID); err != nil {
+			return BatchWrite{}, err
+		}
+
+		return BatchWrite{
+			Op:      BatchUpdate,
+			ID:      operation.ID,
+			Version: operation.Version,
+			Apply: func(stored models.Todo) (models.Todo, error) {
+				if stored.DeletedAt != nil {
+					return models.Todo{}, ErrTodoNotFound
+				}
+
+				stored.DeletedAt = &now
+				stored.UpdatedAt = now
+				return stored, nil
+			},
+		}, nil
+	}
+
+	if op != BatchCreate && op != BatchUpdate {
+		return BatchWrite{}, ErrInvalidOperation
+	}
+
+	startDate, dueDate, err := validateDates(operation.StartDate, operation.DueDate)
+	if err != nil {
+		return BatchWrite{}, err
+	}
+
+	todo := models.Todo{
+		DueDate:     dueDate,
+		StartDate:   startDate,
+		Name:        operation.Name,
+		Description: operation.Description,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if op == BatchCreate {
+		return BatchWrite{Op: op, Todo: todo}, nil
+	}
+
+	if err = validateID(operation.ID); err != nil {
+		return BatchWrite{}, err
+	}
+
+	return BatchWrite{
+		Op:      op,
+		ID:      operation.ID,
+		Version: operation.Version,
+		Apply: func(stored models.Todo) (models.Todo, error) {
+			if stored.DeletedAt != nil {
+				return models.Todo{}, ErrTodoNotFound
+			}
+
+			if stored.Completed {
+				return models.Todo{}, ErrTodoIsCompleted
+			}
+
+			return todo, nil
+		},
+	}, nil
+}
+
+// validatePage fills in the defaults of the page and validates it.
+func validatePage(page PageRequest) (PageRequest, error) {
+	if page.Limit == 0 {
+		page.Limit = DefaultPageLimit
+	}
+
+	if page.Limit < 0 || page.Limit > MaxPageLimit {
+		return PageRequest{}, ErrInvalidLimit
+	}
+
+	if page.Sort == "" {
+		page.Sort = SortByCreatedAt
+	}
+
+	if page.Order == "" {
+		page.Order = Ascending
+	}
+
+	if err := validateSort(page.Sort, page.Order); err != nil {
+		return PageRequest{}, err
+	}
+
+	return page, nil
+}
+
 func validateDates(startDate, dueDate string) (time.Time, time.Time, error) {
 	parsedStartDate, err := time.Parse(time.DateTime, startDate)
 	if err != nil {
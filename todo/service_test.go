@@ -1,4 +1,4 @@
-package todo
+package todo_test
 
 import (
 	"context"
@@ -8,25 +8,35 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 
+	"todo-app/todo"
 	"todo-app/todo/dtos"
 	"todo-app/todo/mocks"
 	"todo-app/todo/models"
 )
 
+var now = time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+func newClock(ctrl *gomock.Controller) *mocks.MockClock {
+	clock := mocks.NewMockClock(ctrl)
+	clock.EXPECT().Now().Return(now).AnyTimes()
+	return clock
+}
+
 func TestNewTodosService(t *testing.T) {
 	t.Run("should return a not nil instance", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		repository := mocks.NewMockRepository(ctrl)
-		service := NewTodosService(repository)
+		service := todo.NewTodosService(repository, newClock(ctrl))
 		assert.NotNil(t, service)
-		assert.IsType(t, &TodosService{}, service)
+		assert.IsType(t, &todo.TodosService{}, service)
 	})
 }
 
 func TestTodosService_Create(t *testing.T) {
-	email := "test@test.test"
+	email := todo.Owner("test@test.test")
 	ctx := context.TODO()
 	ctxMatcher := reflect.TypeOf((*context.Context)(nil)).Elem()
 	validStartDate := time.Now().Format(time.DateTime)
@@ -42,7 +52,7 @@ func TestTodosService_Create(t *testing.T) {
 			Name:        "name",
 		}
 
-		service := NewTodosService(repository)
+		service := todo.NewTodosService(repository, newClock(ctrl))
 		response, err := service.Create(ctx, email, dto)
 		assert.Error(t, err)
 		assert.Zero(t, response)
@@ -65,37 +75,186 @@ func TestTodosService_Create(t *testing.T) {
 			Name:        "name",
 		}
 
-		service := NewTodosService(repository)
+		service := todo.NewTodosService(repository, newClock(ctrl))
 		response, err := service.Create(ctx, email, dto)
 		assert.NoError(t, err)
 		assert.NotZero(t, response)
 	})
+
+	t.Run("should stamp the creation and update dates", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			Create(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.AssignableToTypeOf(models.Todo{})).
+			DoAndReturn(func(_ context.Context, _ todo.Owner, created models.Todo) (models.Todo, error) {
+				return created, nil
+			})
+
+		dto := dtos.CreateTodo{
+			DueDate:   validDueDate,
+			StartDate: validStartDate,
+			Name:      "name",
+		}
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.Create(ctx, email, dto)
+		assert.NoError(t, err)
+		assert.Equal(t, now, response.CreatedAt)
+		assert.Equal(t, now, response.UpdatedAt)
+	})
 }
 
 func TestTodosService_Delete(t *testing.T) {
 	ctx := context.TODO()
 	ctxMatcher := reflect.TypeOf((*context.Context)(nil)).Elem()
-	email := "test@test.test"
+	email := todo.Owner("test@test.test")
+	id := "279f4a4e-48dc-4569-83df-8b30ce488599"
+
+	t.Run("should move the todo to the trash", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			GetByID(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
+			Return(models.Todo{ID: id, Version: 2}, nil)
+		repository.
+			EXPECT().
+			Update(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id), gomock.Cond(func(x any) bool {
+				todo := x.(models.Todo)
+				return todo.DeletedAt != nil && todo.DeletedAt.Equal(now) && todo.UpdatedAt.Equal(now) && todo.Version == 2
+			})).
+			Return(models.Todo{ID: id, Version: 3}, nil)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		err := service.Delete(ctx, email, id, 2)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return ErrVersionMismatch if the version is stale", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			GetByID(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
+			Return(models.Todo{ID: id, Version: 3}, nil)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		err := service.Delete(ctx, email, id, 2)
+		assert.ErrorIs(t, err, todo.ErrVersionMismatch)
+	})
+
+	t.Run("should return ErrTodoNotFound if the todo is already in the trash", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		deletedAt := now.Add(-time.Hour)
+		repository.
+			EXPECT().
+			GetByID(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
+			Return(models.Todo{ID: id, DeletedAt: &deletedAt}, nil)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		err := service.Delete(ctx, email, id, 0)
+		assert.ErrorIs(t, err, todo.ErrTodoNotFound)
+	})
+}
+
+func TestTodosService_Trash(t *testing.T) {
+	ctx := context.TODO()
+	ctxMatcher := reflect.TypeOf((*context.Context)(nil)).Elem()
+	email := todo.Owner("test@test.test")
+
+	t.Run("should list the deleted todos", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		page := todo.PageRequest{Limit: todo.DefaultPageLimit, Sort: todo.SortByCreatedAt, Order: todo.Ascending}
+		repository.
+			EXPECT().
+			GetAll(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(todo.TodoFilter{Deleted: true, IncludeArchived: true}), gomock.Eq(page)).
+			Return(todo.Page{Todos: []models.Todo{{ID: "id"}}, NextCursor: "next"}, nil)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.Trash(ctx, email, todo.PageRequest{})
+		assert.NoError(t, err)
+		assert.Len(t, response.Todos, 1)
+		assert.Equal(t, "next", response.NextCursor)
+	})
+
+	t.Run("should return ErrInvalidLimit if the limit is out of range", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		_, err := service.Trash(ctx, email, todo.PageRequest{Limit: todo.MaxPageLimit + 1})
+		assert.ErrorIs(t, err, todo.ErrInvalidLimit)
+	})
+}
+
+func TestTodosService_Restore(t *testing.T) {
+	ctx := context.TODO()
+	ctxMatcher := reflect.TypeOf((*context.Context)(nil)).Elem()
+	email := todo.Owner("test@test.test")
 	id := "279f4a4e-48dc-4569-83df-8b30ce488599"
 
-	t.Run("should delete the todo", func(t *testing.T) {
+	t.Run("should take the todo out of the trash", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		repository := mocks.NewMockRepository(ctrl)
+		deletedAt := now.Add(-time.Hour)
+		repository.
+			EXPECT().
+			GetByID(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
+			Return(models.Todo{ID: id, DeletedAt: &deletedAt}, nil)
 		repository.
 			EXPECT().
-			Delete(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
-			Return(nil)
+			Update(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id), gomock.Cond(func(x any) bool {
+				todo := x.(models.Todo)
+				return todo.DeletedAt == nil && todo.UpdatedAt.Equal(now)
+			})).
+			DoAndReturn(func(_ context.Context, _ todo.Owner, _ string, todo models.Todo) (models.Todo, error) {
+				return todo, nil
+			})
 
-		service := NewTodosService(repository)
-		err := service.Delete(ctx, email, id)
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.Restore(ctx, email, id)
 		assert.NoError(t, err)
+		assert.Nil(t, response.DeletedAt)
+	})
+
+	t.Run("should return ErrTodoIsNotDeleted if the todo isn't in the trash", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			GetByID(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
+			Return(models.Todo{ID: id}, nil)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		_, err := service.Restore(ctx, email, id)
+		assert.ErrorIs(t, err, todo.ErrTodoIsNotDeleted)
+	})
+
+	t.Run("should return the repository error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			GetByID(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
+			Return(models.Todo{}, todo.ErrTodoNotFound)
+		repository.
+			EXPECT().
+			GetShare(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
+			Return(models.Share{}, todo.ErrShareNotFound)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		_, err := service.Restore(ctx, email, id)
+		assert.ErrorIs(t, err, todo.ErrTodoNotFound)
 	})
 }
 
 func TestTodosService_GetAll(t *testing.T) {
 	ctx := context.TODO()
 	ctxMatcher := reflect.TypeOf((*context.Context)(nil)).Elem()
-	email := "test@test.test"
+	email := todo.Owner("test@test.test")
 	id := "279f4a4e-48dc-4569-83df-8b30ce488599"
 
 	t.Run("should get all the todos", func(t *testing.T) {
@@ -103,20 +262,168 @@ func TestTodosService_GetAll(t *testing.T) {
 		repository := mocks.NewMockRepository(ctrl)
 		repository.
 			EXPECT().
-			GetAll(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email)).
-			Return([]models.Todo{{ID: id}}, nil)
+			GetSharedWith(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email)).
+			Return(nil, nil)
+		repository.
+			EXPECT().
+			GetAll(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(todo.TodoFilter{}), gomock.Eq(todo.PageRequest{Limit: 10, Cursor: "cursor", Sort: todo.SortByName, Order: todo.Descending})).
+			Return(todo.Page{Todos: []models.Todo{{ID: id}}, NextCursor: "next"}, nil)
 
-		service := NewTodosService(repository)
-		response, err := service.GetAll(ctx, email)
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.GetAll(ctx, email, todo.TodoFilter{}, todo.PageRequest{Limit: 10, Cursor: "cursor", Sort: todo.SortByName, Order: todo.Descending})
 		assert.NoError(t, err)
-		assert.Len(t, response, 1)
+		assert.Len(t, response.Todos, 1)
+		assert.Equal(t, "next", response.NextCursor)
+	})
+
+	t.Run("should read the shared todos at once per owner", func(t *testing.T) {
+		createdAt := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+		alice, bob := todo.Owner("alice@test.test"), todo.Owner("bob@test.test")
+		ids := []string{
+			"5b3c8a3e-0a4c-4d1e-9a55-2f8a1c8f1a01",
+			"5b3c8a3e-0a4c-4d1e-9a55-2f8a1c8f1a02",
+			"5b3c8a3e-0a4c-4d1e-9a55-2f8a1c8f1a03",
+		}
+
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			GetSharedWith(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email)).
+			Return([]models.Share{
+				{TodoID: ids[0], Owner: alice.String(), Grantee: email.String(), Role: models.RoleViewer},
+				{TodoID: ids[1], Owner: bob.String(), Grantee: email.String(), Role: models.RoleViewer},
+				{TodoID: ids[2], Owner: alice.String(), Grantee: email.String(), Role: models.RoleEditor},
+			}, nil)
+		repository.
+			EXPECT().
+			GetAll(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(todo.TodoFilter{}), gomock.Any()).
+			Return(todo.Page{Todos: []models.Todo{{ID: id, CreatedAt: createdAt}}}, nil)
+		repository.
+			EXPECT().
+			GetByIDs(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(alice), gomock.Eq([]string{ids[0], ids[2]})).
+			Return([]models.Todo{{ID: ids[2], CreatedAt: createdAt.Add(time.Hour)}}, nil)
+		repository.
+			EXPECT().
+			GetByIDs(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(bob), gomock.Eq([]string{ids[1]})).
+			Return([]models.Todo{{ID: ids[1], CreatedAt: createdAt.Add(2 * time.Hour)}}, nil)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.GetAll(ctx, email, todo.TodoFilter{}, todo.PageRequest{})
+		assert.NoError(t, err)
+		if assert.Len(t, response.Todos, 3) {
+			assert.Equal(t, []string{id, ids[2], ids[1]}, []string{response.Todos[0].ID, response.Todos[1].ID, response.Todos[2].ID})
+			assert.Equal(t, []string{"", alice.String(), bob.String()}, []string{response.Todos[0].Owner, response.Todos[1].Owner, response.Todos[2].Owner})
+		}
+	})
+
+	t.Run("should use the default limit and sort if none are given", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			GetSharedWith(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email)).
+			Return(nil, nil)
+		repository.
+			EXPECT().
+			GetAll(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(todo.TodoFilter{}), gomock.Eq(todo.PageRequest{Limit: todo.DefaultPageLimit, Sort: todo.SortByCreatedAt, Order: todo.Ascending})).
+			Return(todo.Page{}, nil)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		_, err := service.GetAll(ctx, email, todo.TodoFilter{}, todo.PageRequest{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return an error if the limit is out of range", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		for _, limit := range []int{-1, todo.MaxPageLimit + 1} {
+			response, err := service.GetAll(ctx, email, todo.TodoFilter{}, todo.PageRequest{Limit: limit})
+			assert.ErrorIs(t, err, todo.ErrInvalidLimit)
+			assert.Zero(t, response)
+		}
+	})
+
+	t.Run("should resolve overdue into the open todos due before now", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			GetSharedWith(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email)).
+			Return(nil, nil)
+		repository.
+			EXPECT().
+			GetAll(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ todo.Owner, filter todo.TodoFilter, _ todo.PageRequest) (todo.Page, error) {
+				assert.False(t, filter.Overdue)
+				if assert.NotNil(t, filter.Completed) {
+					assert.False(t, *filter.Completed)
+				}
+				if assert.NotNil(t, filter.DueBefore) {
+					assert.Equal(t, now, *filter.DueBefore)
+				}
+				return todo.Page{}, nil
+			})
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		_, err := service.GetAll(ctx, email, todo.TodoFilter{Overdue: true}, todo.PageRequest{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("should keep an earlier due date when resolving overdue", func(t *testing.T) {
+		dueBefore := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			GetSharedWith(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email)).
+			Return(nil, nil)
+		repository.
+			EXPECT().
+			GetAll(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ todo.Owner, filter todo.TodoFilter, _ todo.PageRequest) (todo.Page, error) {
+				assert.Equal(t, &dueBefore, filter.DueBefore)
+				return todo.Page{}, nil
+			})
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		_, err := service.GetAll(ctx, email, todo.TodoFilter{Overdue: true, DueBefore: &dueBefore}, todo.PageRequest{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return an error if overdue todos are asked to be completed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+
+		completed := true
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.GetAll(ctx, email, todo.TodoFilter{Overdue: true, Completed: &completed}, todo.PageRequest{})
+		assert.ErrorIs(t, err, todo.ErrInvalidFilter)
+		assert.Zero(t, response)
+	})
+
+	t.Run("should return an error if the sort is unknown", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.GetAll(ctx, email, todo.TodoFilter{}, todo.PageRequest{Sort: "owner"})
+		assert.ErrorIs(t, err, todo.ErrInvalidSort)
+		assert.Zero(t, response)
+
+		response, err = service.GetAll(ctx, email, todo.TodoFilter{}, todo.PageRequest{Order: "up"})
+		assert.ErrorIs(t, err, todo.ErrInvalidOrder)
+		assert.Zero(t, response)
 	})
 }
 
 func TestTodosService_GetByID(t *testing.T) {
 	ctx := context.TODO()
 	ctxMatcher := reflect.TypeOf((*context.Context)(nil)).Elem()
-	email := "test@test.test"
+	email := todo.Owner("test@test.test")
 	id := "279f4a4e-48dc-4569-83df-8b30ce488599"
 
 	t.Run("should get by id", func(t *testing.T) {
@@ -127,15 +434,29 @@ func TestTodosService_GetByID(t *testing.T) {
 			GetByID(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
 			Return(models.Todo{ID: id}, nil)
 
-		service := NewTodosService(repository)
+		service := todo.NewTodosService(repository, newClock(ctrl))
 		response, err := service.GetByID(ctx, email, id)
 		assert.NoError(t, err)
 		assert.Equal(t, id, response.ID)
 	})
+
+	t.Run("should not find todos in the trash", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		deletedAt := now
+		repository.
+			EXPECT().
+			GetByID(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
+			Return(models.Todo{ID: id, DeletedAt: &deletedAt}, nil)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		_, err := service.GetByID(ctx, email, id)
+		assert.ErrorIs(t, err, todo.ErrTodoNotFound)
+	})
 }
 
 func TestTodosService_Update(t *testing.T) {
-	email := "test@test.test"
+	email := todo.Owner("test@test.test")
 	ctx := context.TODO()
 	ctxMatcher := reflect.TypeOf((*context.Context)(nil)).Elem()
 	validStartDate := time.Now().Format(time.DateTime)
@@ -152,8 +473,8 @@ func TestTodosService_Update(t *testing.T) {
 			Name:        "name",
 		}
 
-		service := NewTodosService(repository)
-		response, err := service.Update(ctx, email, id, dto)
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.Update(ctx, email, id, 0, dto)
 		assert.Error(t, err)
 		assert.Zero(t, response)
 	})
@@ -173,13 +494,13 @@ func TestTodosService_Update(t *testing.T) {
 			Name:        "name",
 		}
 
-		service := NewTodosService(repository)
-		response, err := service.Update(ctx, email, id, dto)
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.Update(ctx, email, id, 0, dto)
 		assert.Error(t, err)
 		assert.Zero(t, response)
 	})
 
-	t.Run("should return the ErrTodoIsCompleted error", func(t *testing.T) {
+	t.Run("should return the todo.ErrTodoIsCompleted error", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		repository := mocks.NewMockRepository(ctrl)
 		repository.
@@ -194,9 +515,9 @@ func TestTodosService_Update(t *testing.T) {
 			Name:        "name",
 		}
 
-		service := NewTodosService(repository)
-		response, err := service.Update(ctx, email, id, dto)
-		assert.ErrorIs(t, err, ErrTodoIsCompleted)
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.Update(ctx, email, id, 0, dto)
+		assert.ErrorIs(t, err, todo.ErrTodoIsCompleted)
 		assert.Zero(t, response)
 	})
 
@@ -219,37 +540,761 @@ func TestTodosService_Update(t *testing.T) {
 			Name:        "name",
 		}
 
-		service := NewTodosService(repository)
-		response, err := service.Update(ctx, email, id, dto)
-		assert.NoError(t, err, ErrTodoIsCompleted)
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.Update(ctx, email, id, 0, dto)
+		assert.NoError(t, err, todo.ErrTodoIsCompleted)
 		assert.NotZero(t, response)
 		assert.Equal(t, id, response.ID)
 	})
-}
 
-func Test_ValidateDates(t *testing.T) {
-	validStartDate := time.Now().Format(time.DateTime)
-	validDueDate := time.Now().Add(time.Minute * 5).Format(time.DateTime)
+	t.Run("should update the version that was read and stamp the update date", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			GetByID(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
+			Return(models.Todo{ID: id, Version: 3}, nil)
+		repository.
+			EXPECT().
+			Update(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id), gomock.Cond(func(x any) bool {
+				return x.(models.Todo).Version == 3 && x.(models.Todo).UpdatedAt.Equal(now)
+			})).
+			Return(models.Todo{ID: id, Version: 4}, nil)
+
+		dto := dtos.UpdateTodo{
+			DueDate:   validDueDate,
+			StartDate: validStartDate,
+			Name:      "name",
+		}
 
-	t.Run("should return the ErrInvalidStartDate", func(t *testing.T) {
-		_, _, err := validateDates("invalid", validDueDate)
-		assert.ErrorIs(t, err, ErrInvalidStartDate)
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.Update(ctx, email, id, 3, dto)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), response.Version)
 	})
 
-	t.Run("should return the ErrInvalidDueDate", func(t *testing.T) {
-		_, _, err := validateDates(validStartDate, "invalid")
-		assert.ErrorIs(t, err, ErrInvalidDueDate)
+	t.Run("should return ErrVersionMismatch if the version is stale", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			GetByID(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
+			Return(models.Todo{ID: id, Version: 3}, nil)
+
+		dto := dtos.UpdateTodo{
+			DueDate:   validDueDate,
+			StartDate: validStartDate,
+			Name:      "name",
+		}
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.Update(ctx, email, id, 2, dto)
+		assert.ErrorIs(t, err, todo.ErrVersionMismatch)
+		assert.Zero(t, response)
 	})
+}
 
-	t.Run("should return the ErrStartDateMustBeGTDueDate", func(t *testing.T) {
-		startDate := time.Now().Format(time.DateTime)
-		dueDate := time.Now().Add(time.Minute * -10).Format(time.DateTime)
-		_, _, err := validateDates(startDate, dueDate)
-		assert.ErrorIs(t, err, ErrStartDateMustBeGTDueDate)
+func TestTodosService_Patch(t *testing.T) {
+	ctx := context.TODO()
+	ctxMatcher := reflect.TypeOf((*context.Context)(nil)).Elem()
+	email := todo.Owner("test@test.test")
+	id := "279f4a4e-48dc-4569-83df-8b30ce488599"
+	createdAt := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+	stored := models.Todo{
+		ID:          id,
+		Name:        "name",
+		Description: "description",
+		StartDate:   time.Date(2024, time.March, 1, 9, 0, 0, 0, time.UTC),
+		DueDate:     time.Date(2024, time.March, 2, 9, 0, 0, 0, time.UTC),
+		CreatedAt:   createdAt,
+		Version:     3,
+	}
+
+	newService := func(t *testing.T, current models.Todo) (*todo.TodosService, *mocks.MockRepository) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			GetByID(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
+			Return(current, nil).
+			AnyTimes()
+
+		return todo.NewTodosService(repository, newClock(ctrl)), repository
+	}
+
+	t.Run("should only change the fields of the patch", func(t *testing.T) {
+		service, repository := newService(t, stored)
+
+		expected := stored
+		expected.Description = "updated"
+		expected.UpdatedAt = now
+		expected.DueDate = time.Date(2024, time.March, 5, 18, 0, 0, 0, time.UTC)
+		repository.
+			EXPECT().
+			Update(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id), gomock.Eq(expected)).
+			Return(expected, nil)
+
+		response, err := service.Patch(ctx, email, id, 3, dtos.PatchTodo{
+			Description: dtos.PatchField[string]{Set: true, Value: "updated"},
+			DueDate:     dtos.PatchField[string]{Set: true, Value: "2024-03-05 18:00:00"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, expected, response)
 	})
 
-	t.Run("should nil if no error happens", func(t *testing.T) {
-		_, _, err := validateDates(validStartDate, validDueDate)
+	t.Run("should clear a removed description", func(t *testing.T) {
+		service, repository := newService(t, stored)
+
+		expected := stored
+		expected.Description = ""
+		expected.UpdatedAt = now
+		repository.
+			EXPECT().
+			Update(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id), gomock.Eq(expected)).
+			Return(expected, nil)
+
+		_, err := service.Patch(ctx, email, id, 0, dtos.PatchTodo{Description: dtos.PatchField[string]{Set: true, Null: true}})
 		assert.NoError(t, err)
 	})
+
+	t.Run("should return ErrInvalidPatch if a required field is removed", func(t *testing.T) {
+		service, _ := newService(t, stored)
+
+		response, err := service.Patch(ctx, email, id, 0, dtos.PatchTodo{Name: dtos.PatchField[string]{Set: true, Null: true}})
+		assert.ErrorIs(t, err, todo.ErrInvalidPatch)
+		assert.Zero(t, response)
+	})
+
+	t.Run("should validate the dates against the stored ones", func(t *testing.T) {
+		service, _ := newService(t, stored)
+
+		response, err := service.Patch(ctx, email, id, 0, dtos.PatchTodo{StartDate: dtos.PatchField[string]{Set: true, Value: "2024-03-03 09:00:00"}})
+		assert.ErrorIs(t, err, todo.ErrStartDateMustBeGTDueDate)
+		assert.Zero(t, response)
+
+		response, err = service.Patch(ctx, email, id, 0, dtos.PatchTodo{DueDate: dtos.PatchField[string]{Set: true, Value: "tomorrow"}})
+		assert.ErrorIs(t, err, todo.ErrInvalidDueDate)
+		assert.Zero(t, response)
+	})
+
+	t.Run("should return ErrTodoIsCompleted if the todo is completed", func(t *testing.T) {
+		completed := stored
+		completed.Completed = true
+		service, _ := newService(t, completed)
+
+		response, err := service.Patch(ctx, email, id, 0, dtos.PatchTodo{Name: dtos.PatchField[string]{Set: true, Value: "updated"}})
+		assert.ErrorIs(t, err, todo.ErrTodoIsCompleted)
+		assert.Zero(t, response)
+	})
+
+	t.Run("should return ErrVersionMismatch if the version is stale", func(t *testing.T) {
+		service, _ := newService(t, stored)
+
+		response, err := service.Patch(ctx, email, id, 2, dtos.PatchTodo{Name: dtos.PatchField[string]{Set: true, Value: "updated"}})
+		assert.ErrorIs(t, err, todo.ErrVersionMismatch)
+		assert.Zero(t, response)
+	})
+}
+
+func TestTodosService_Complete(t *testing.T) {
+	email := todo.Owner("test@test.test")
+	ctx := context.TODO()
+	ctxMatcher := reflect.TypeOf((*context.Context)(nil)).Elem()
+	id := "279f4a4e-48dc-4569-83df-8b30ce488599"
+
+	t.Run("should return the GetById error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			GetByID(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
+			Return(models.Todo{}, todo.ErrTodoNotFound)
+		repository.
+			EXPECT().
+			GetShare(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
+			Return(models.Share{}, todo.ErrShareNotFound)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.Complete(ctx, email, id)
+		assert.ErrorIs(t, err, todo.ErrTodoNotFound)
+		assert.Zero(t, response)
+	})
+
+	t.Run("should return the todo.ErrTodoIsCompleted error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			GetByID(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
+			Return(models.Todo{ID: id, Completed: true}, nil)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.Complete(ctx, email, id)
+		assert.ErrorIs(t, err, todo.ErrTodoIsCompleted)
+		assert.Zero(t, response)
+	})
+
+	t.Run("should complete the todo", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			GetByID(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
+			Return(models.Todo{ID: id, Name: "name"}, nil)
+		repository.
+			EXPECT().
+			Update(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id), gomock.AssignableToTypeOf(models.Todo{})).
+			DoAndReturn(func(_ context.Context, _ todo.Owner, _ string, updated models.Todo) (models.Todo, error) {
+				return updated, nil
+			})
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.Complete(ctx, email, id)
+		assert.NoError(t, err)
+		assert.True(t, response.Completed)
+		if assert.NotNil(t, response.CompletedAt) {
+			assert.Equal(t, now, *response.CompletedAt)
+		}
+		assert.Equal(t, now, response.UpdatedAt)
+		assert.Equal(t, "name", response.Name)
+	})
+}
+
+func TestTodosService_Reopen(t *testing.T) {
+	email := todo.Owner("test@test.test")
+	ctx := context.TODO()
+	ctxMatcher := reflect.TypeOf((*context.Context)(nil)).Elem()
+	id := "279f4a4e-48dc-4569-83df-8b30ce488599"
+
+	t.Run("should return the GetById error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			GetByID(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
+			Return(models.Todo{}, todo.ErrTodoNotFound)
+		repository.
+			EXPECT().
+			GetShare(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
+			Return(models.Share{}, todo.ErrShareNotFound)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.Reopen(ctx, email, id)
+		assert.ErrorIs(t, err, todo.ErrTodoNotFound)
+		assert.Zero(t, response)
+	})
+
+	t.Run("should return the todo.ErrTodoIsNotCompleted error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			GetByID(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
+			Return(models.Todo{ID: id}, nil)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.Reopen(ctx, email, id)
+		assert.ErrorIs(t, err, todo.ErrTodoIsNotCompleted)
+		assert.Zero(t, response)
+	})
+
+	t.Run("should reopen the todo", func(t *testing.T) {
+		completedAt := time.Now()
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			GetByID(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
+			Return(models.Todo{ID: id, Completed: true, CompletedAt: &completedAt}, nil)
+		repository.
+			EXPECT().
+			Update(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id), gomock.AssignableToTypeOf(models.Todo{})).
+			DoAndReturn(func(_ context.Context, _ todo.Owner, _ string, updated models.Todo) (models.Todo, error) {
+				return updated, nil
+			})
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.Reopen(ctx, email, id)
+		assert.NoError(t, err)
+		assert.False(t, response.Completed)
+		assert.Nil(t, response.CompletedAt)
+	})
+
+	t.Run("should return ErrTodoIsArchived if the todo is archived", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			GetByID(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
+			Return(models.Todo{ID: id, Completed: true, CompletedAt: &now, ArchivedAt: &now}, nil)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		_, err := service.Reopen(ctx, email, id)
+		assert.ErrorIs(t, err, todo.ErrTodoIsArchived)
+	})
+}
+
+func TestTodosService_Archive(t *testing.T) {
+	email := todo.Owner("test@test.test")
+	ctx := context.TODO()
+	ctxMatcher := reflect.TypeOf((*context.Context)(nil)).Elem()
+	id := "279f4a4e-48dc-4569-83df-8b30ce488599"
+	completedAt := now.Add(-time.Hour)
+
+	t.Run("should return the GetById error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			GetByID(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
+			Return(models.Todo{}, todo.ErrTodoNotFound)
+		repository.
+			EXPECT().
+			GetShare(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
+			Return(models.Share{}, todo.ErrShareNotFound)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		_, err := service.Archive(ctx, email, id)
+		assert.ErrorIs(t, err, todo.ErrTodoNotFound)
+	})
+
+	t.Run("should return ErrTodoIsOpen if the todo isn't completed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			GetByID(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
+			Return(models.Todo{ID: id}, nil)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		_, err := service.Archive(ctx, email, id)
+		assert.ErrorIs(t, err, todo.ErrTodoIsOpen)
+	})
+
+	t.Run("should return ErrTodoIsArchived if the todo is already archived", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			GetByID(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
+			Return(models.Todo{ID: id, Completed: true, CompletedAt: &completedAt, ArchivedAt: &completedAt}, nil)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		_, err := service.Archive(ctx, email, id)
+		assert.ErrorIs(t, err, todo.ErrTodoIsArchived)
+	})
+
+	t.Run("should archive the todo", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			GetByID(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
+			Return(models.Todo{ID: id, Completed: true, CompletedAt: &completedAt}, nil)
+		repository.
+			EXPECT().
+			Update(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id), gomock.AssignableToTypeOf(models.Todo{})).
+			DoAndReturn(func(_ context.Context, _ todo.Owner, _ string, updated models.Todo) (models.Todo, error) {
+				return updated, nil
+			})
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.Archive(ctx, email, id)
+		assert.NoError(t, err)
+		require.NotNil(t, response.ArchivedAt)
+		assert.Equal(t, now, *response.ArchivedAt)
+		assert.Equal(t, now, response.UpdatedAt)
+	})
+}
+
+func TestTodosService_Unarchive(t *testing.T) {
+	email := todo.Owner("test@test.test")
+	ctx := context.TODO()
+	ctxMatcher := reflect.TypeOf((*context.Context)(nil)).Elem()
+	id := "279f4a4e-48dc-4569-83df-8b30ce488599"
+	archivedAt := now.Add(-time.Hour)
+
+	t.Run("should return ErrTodoIsNotArchived if the todo isn't archived", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			GetByID(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
+			Return(models.Todo{ID: id, Completed: true}, nil)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		_, err := service.Unarchive(ctx, email, id)
+		assert.ErrorIs(t, err, todo.ErrTodoIsNotArchived)
+	})
+
+	t.Run("should unarchive the todo", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			GetByID(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id)).
+			Return(models.Todo{ID: id, Completed: true, ArchivedAt: &archivedAt}, nil)
+		repository.
+			EXPECT().
+			Update(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq(id), gomock.AssignableToTypeOf(models.Todo{})).
+			DoAndReturn(func(_ context.Context, _ todo.Owner, _ string, updated models.Todo) (models.Todo, error) {
+				return updated, nil
+			})
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.Unarchive(ctx, email, id)
+		assert.NoError(t, err)
+		assert.Nil(t, response.ArchivedAt)
+		assert.True(t, response.Completed)
+		assert.Equal(t, now, response.UpdatedAt)
+	})
+}
+
+func Test_ValidateDates(t *testing.T) {
+	validStartDate := time.Now().Format(time.DateTime)
+	validDueDate := time.Now().Add(time.Minute * 5).Format(time.DateTime)
+
+	t.Run("should return the todo.ErrInvalidStartDate", func(t *testing.T) {
+		_, _, err := todo.ValidateDates("invalid", validDueDate)
+		assert.ErrorIs(t, err, todo.ErrInvalidStartDate)
+	})
+
+	t.Run("should return the todo.ErrInvalidDueDate", func(t *testing.T) {
+		_, _, err := todo.ValidateDates(validStartDate, "invalid")
+		assert.ErrorIs(t, err, todo.ErrInvalidDueDate)
+	})
+
+	t.Run("should return the todo.ErrStartDateMustBeGTDueDate", func(t *testing.T) {
+		startDate := time.Now().Format(time.DateTime)
+		dueDate := time.Now().Add(time.Minute * -10).Format(time.DateTime)
+		_, _, err := todo.ValidateDates(startDate, dueDate)
+		assert.ErrorIs(t, err, todo.ErrStartDateMustBeGTDueDate)
+	})
+
+	t.Run("should nil if no error happens", func(t *testing.T) {
+		_, _, err := todo.ValidateDates(validStartDate, validDueDate)
+		assert.NoError(t, err)
+	})
+}
+
+func TestTodosService_Search(t *testing.T) {
+	ctx := context.TODO()
+	ctxMatcher := reflect.TypeOf((*context.Context)(nil)).Elem()
+	email := todo.Owner("test@test.test")
+
+	t.Run("should search the distinct lowercase words of the query", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			Search(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Eq([]string{"buy", "milk"}), gomock.Eq(todo.DefaultPageLimit)).
+			Return([]models.Todo{{Name: "Buy milk"}}, nil)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.Search(ctx, email, "  Buy, MILK! buy", 0)
+		assert.NoError(t, err)
+		assert.Len(t, response, 1)
+	})
+
+	t.Run("should return an error if the query has no words", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.Search(ctx, email, " ?! ", 0)
+		assert.ErrorIs(t, err, todo.ErrInvalidQuery)
+		assert.Nil(t, response)
+	})
+
+	t.Run("should return an error if the query has too many words", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.Search(ctx, email, "a b c d e f g h i j k", 0)
+		assert.ErrorIs(t, err, todo.ErrInvalidQuery)
+		assert.Nil(t, response)
+	})
+
+	t.Run("should return an error if the limit is out of range", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.Search(ctx, email, "milk", todo.MaxPageLimit+1)
+		assert.ErrorIs(t, err, todo.ErrInvalidLimit)
+		assert.Nil(t, response)
+	})
+}
+
+func TestTodosService_Batch(t *testing.T) {
+	ctx := context.TODO()
+	ctxMatcher := reflect.TypeOf((*context.Context)(nil)).Elem()
+	email := todo.Owner("test@test.test")
+	id := "279f4a4e-48dc-4569-83df-8b30ce488599"
+	startDate, dueDate := "2024-03-01 09:00:00", "2024-03-02 09:00:00"
+
+	t.Run("should return ErrInvalidBatch if there are no operations or too many", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		service := todo.NewTodosService(repository, newClock(ctrl))
+
+		response, err := service.Batch(ctx, email, nil)
+		assert.ErrorIs(t, err, todo.ErrInvalidBatch)
+		assert.Nil(t, response)
+
+		response, err = service.Batch(ctx, email, make([]dtos.BatchOperation, todo.MaxBatchSize+1))
+		assert.ErrorIs(t, err, todo.ErrInvalidBatch)
+		assert.Nil(t, response)
+	})
+
+	t.Run("should only write the valid operations and keep their order", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			Batch(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ todo.Owner, writes []todo.BatchWrite) ([]todo.BatchResult, error) {
+				require.Len(t, writes, 2)
+				assert.Equal(t, todo.BatchCreate, writes[0].Op)
+				assert.Equal(t, "name", writes[0].Todo.Name)
+				assert.Equal(t, now, writes[0].Todo.CreatedAt)
+				assert.Equal(t, todo.BatchUpdate, writes[1].Op)
+				assert.Equal(t, id, writes[1].ID)
+				assert.Equal(t, int64(2), writes[1].Version)
+				deleted, err := writes[1].Apply(models.Todo{ID: id})
+				assert.NoError(t, err)
+				require.NotNil(t, deleted.DeletedAt)
+				assert.Equal(t, now, *deleted.DeletedAt)
+				return []todo.BatchResult{{Todo: models.Todo{ID: id}}, {Err: todo.ErrTodoNotFound}}, nil
+			})
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.Batch(ctx, email, []dtos.BatchOperation{
+			{Op: "archive", ID: id},
+			{Op: "create", Name: "name", StartDate: startDate, DueDate: dueDate},
+			{Op: "update", ID: "invalidid", StartDate: startDate, DueDate: dueDate},
+			{Op: "create", StartDate: dueDate, DueDate: startDate},
+			{Op: "delete", ID: id, Version: 2},
+		})
+		assert.NoError(t, err)
+		require.Len(t, response, 5)
+		assert.ErrorIs(t, response[0].Err, todo.ErrInvalidOperation)
+		assert.Equal(t, id, response[1].Todo.ID)
+		assert.ErrorIs(t, response[2].Err, todo.ErrInvalidID)
+		assert.ErrorIs(t, response[3].Err, todo.ErrStartDateMustBeGTDueDate)
+		assert.ErrorIs(t, response[4].Err, todo.ErrTodoNotFound)
+	})
+
+	t.Run("should not call the repository if every operation is invalid", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.Batch(ctx, email, []dtos.BatchOperation{{Op: "delete", ID: "invalidid"}})
+		assert.NoError(t, err)
+		require.Len(t, response, 1)
+		assert.ErrorIs(t, response[0].Err, todo.ErrInvalidID)
+	})
+
+	t.Run("should apply updates to open todos only", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			Batch(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Len(1)).
+			DoAndReturn(func(_ context.Context, _ todo.Owner, writes []todo.BatchWrite) ([]todo.BatchResult, error) {
+				_, err := writes[0].Apply(models.Todo{ID: id, Completed: true})
+				assert.ErrorIs(t, err, todo.ErrTodoIsCompleted)
+
+				updated, err := writes[0].Apply(models.Todo{ID: id, Name: "stored"})
+				assert.NoError(t, err)
+				assert.Equal(t, "updated", updated.Name)
+				assert.Equal(t, now, updated.UpdatedAt)
+				return []todo.BatchResult{{Todo: updated}}, nil
+			})
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.Batch(ctx, email, []dtos.BatchOperation{
+			{Op: "update", ID: id, Name: "updated", StartDate: startDate, DueDate: dueDate},
+		})
+		assert.NoError(t, err)
+		require.Len(t, response, 1)
+		assert.NoError(t, response[0].Err)
+	})
+
+	t.Run("should return the repository error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.
+			EXPECT().
+			Batch(gomock.AssignableToTypeOf(ctxMatcher), gomock.Eq(email), gomock.Any()).
+			Return(nil, todo.ErrWhileWriting)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		response, err := service.Batch(ctx, email, []dtos.BatchOperation{{Op: "delete", ID: id}})
+		assert.ErrorIs(t, err, todo.ErrWhileWriting)
+		assert.Nil(t, response)
+	})
+}
+
+func TestTodosService_Share(t *testing.T) {
+	ctx := context.TODO()
+	owner, grantee, other := todo.Owner("owner@test.test"), todo.Owner("grantee@test.test"), todo.Owner("other@test.test")
+	dto := dtos.CreateTodo{Name: "name", StartDate: "2024-03-01 00:00:00", DueDate: "2024-03-02 00:00:00"}
+
+	// share creates a todo of the owner shared with the grantee with the
+	// role given.
+	share := func(t *testing.T, role models.Role) (*todo.TodosService, models.Todo) {
+		ctrl := gomock.NewController(t)
+		service := todo.NewTodosService(todo.NewMemoryRepository(), newClock(ctrl))
+		created, err := service.Create(ctx, owner, dto)
+		require.NoError(t, err)
+
+		response, err := service.Share(ctx, owner, created.ID, grantee, role)
+		require.NoError(t, err)
+		assert.Equal(t, models.Share{TodoID: created.ID, Owner: owner.String(), Grantee: grantee.String(), Role: role}, response)
+		return service, created
+	}
+
+	t.Run("should reject an unknown role", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := todo.NewTodosService(mocks.NewMockRepository(ctrl), newClock(ctrl))
+		_, err := service.Share(ctx, owner, "279f4a4e-48dc-4569-83df-8b30ce488599", grantee, "admin")
+		assert.ErrorIs(t, err, todo.ErrInvalidRole)
+	})
+
+	t.Run("should not share a todo with its owner", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := todo.NewTodosService(mocks.NewMockRepository(ctrl), newClock(ctrl))
+		_, err := service.Share(ctx, owner, "279f4a4e-48dc-4569-83df-8b30ce488599", owner, models.RoleViewer)
+		assert.ErrorIs(t, err, todo.ErrInvalidGrantee)
+	})
+
+	t.Run("should let viewers get the todo with its owner but not change it", func(t *testing.T) {
+		service, created := share(t, models.RoleViewer)
+
+		response, err := service.GetByID(ctx, grantee, created.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, owner.String(), response.Owner)
+
+		_, err = service.Update(ctx, grantee, created.ID, 0, dtos.UpdateTodo{Name: "other", StartDate: dto.StartDate, DueDate: dto.DueDate})
+		assert.ErrorIs(t, err, todo.ErrForbidden)
+
+		_, err = service.Complete(ctx, grantee, created.ID)
+		assert.ErrorIs(t, err, todo.ErrForbidden)
+	})
+
+	t.Run("should let editors change the todo of the owner", func(t *testing.T) {
+		service, created := share(t, models.RoleEditor)
+
+		response, err := service.Update(ctx, grantee, created.ID, 1, dtos.UpdateTodo{Name: "other", StartDate: dto.StartDate, DueDate: dto.DueDate})
+		assert.NoError(t, err)
+		assert.Equal(t, owner.String(), response.Owner)
+
+		response, err = service.Complete(ctx, grantee, created.ID)
+		assert.NoError(t, err)
+		assert.True(t, response.Completed)
+
+		stored, err := service.GetByID(ctx, owner, created.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "other", stored.Name)
+		assert.True(t, stored.Completed)
+		assert.Empty(t, stored.Owner)
+	})
+
+	t.Run("should leave the rest to the owner", func(t *testing.T) {
+		service, created := share(t, models.RoleEditor)
+
+		assert.ErrorIs(t, service.Delete(ctx, grantee, created.ID, 0), todo.ErrForbidden)
+
+		_, err := service.Share(ctx, grantee, created.ID, other, models.RoleViewer)
+		assert.ErrorIs(t, err, todo.ErrForbidden)
+
+		_, err = service.GetShares(ctx, grantee, created.ID)
+		assert.ErrorIs(t, err, todo.ErrForbidden)
+	})
+
+	t.Run("should not find the todo for the others", func(t *testing.T) {
+		service, created := share(t, models.RoleEditor)
+
+		_, err := service.GetByID(ctx, other, created.ID)
+		assert.ErrorIs(t, err, todo.ErrTodoNotFound)
+
+		_, err = service.Share(ctx, other, created.ID, grantee, models.RoleViewer)
+		assert.ErrorIs(t, err, todo.ErrTodoNotFound)
+	})
+
+	t.Run("should change the role and revoke the share", func(t *testing.T) {
+		service, created := share(t, models.RoleEditor)
+
+		_, err := service.Share(ctx, owner, created.ID, grantee, models.RoleViewer)
+		assert.NoError(t, err)
+
+		shares, err := service.GetShares(ctx, owner, created.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.Share{{TodoID: created.ID, Owner: owner.String(), Grantee: grantee.String(), Role: models.RoleViewer}}, shares)
+
+		assert.NoError(t, service.Unshare(ctx, owner, created.ID, grantee))
+		assert.ErrorIs(t, service.Unshare(ctx, owner, created.ID, grantee), todo.ErrShareNotFound)
+
+		_, err = service.GetByID(ctx, grantee, created.ID)
+		assert.ErrorIs(t, err, todo.ErrTodoNotFound)
+	})
+
+	t.Run("should list the shared todos along with the own ones", func(t *testing.T) {
+		service, created := share(t, models.RoleViewer)
+		_, err := service.Create(ctx, owner, dto)
+		require.NoError(t, err)
+
+		own := make(map[string]bool)
+		for i := 0; i < 3; i++ {
+			response, err := service.Create(ctx, grantee, dto)
+			require.NoError(t, err)
+			own[response.ID] = true
+		}
+
+		var listed []models.Todo
+		page := todo.PageRequest{Limit: 2}
+		for {
+			response, err := service.GetAll(ctx, grantee, todo.TodoFilter{}, page)
+			require.NoError(t, err)
+			assert.LessOrEqual(t, len(response.Todos), 2)
+			listed = append(listed, response.Todos...)
+			if response.NextCursor == "" {
+				break
+			}
+
+			page.Cursor = response.NextCursor
+		}
+
+		require.Len(t, listed, 4)
+		for _, response := range listed {
+			if response.ID == created.ID {
+				assert.Equal(t, owner.String(), response.Owner)
+			} else {
+				assert.True(t, own[response.ID])
+				assert.Empty(t, response.Owner)
+			}
+		}
+	})
+
+	t.Run("should filter the shared todos", func(t *testing.T) {
+		service, _ := share(t, models.RoleViewer)
+
+		completed := true
+		response, err := service.GetAll(ctx, grantee, todo.TodoFilter{Completed: &completed}, todo.PageRequest{})
+		assert.NoError(t, err)
+		assert.Empty(t, response.Todos)
+	})
+
+	t.Run("should return the repository error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.EXPECT().GetSharedWith(gomock.Any(), gomock.Eq(grantee)).Return(nil, todo.ErrWhileRetrieving)
+
+		service := todo.NewTodosService(repository, newClock(ctrl))
+		_, err := service.GetAll(ctx, grantee, todo.TodoFilter{}, todo.PageRequest{})
+		assert.ErrorIs(t, err, todo.ErrWhileRetrieving)
+	})
 }
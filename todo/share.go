@@ -0,0 +1,68 @@
+package todo
+
+import (
+	"fmt"
+	"sort"
+
+	"todo-app/todo/models"
+)
+
+var (
+	ErrForbidden      = fmt.Errorf("the todo is shared with a role that doesn't allow this")
+	ErrInvalidRole    = fmt.Errorf("role must be viewer or editor")
+	ErrInvalidGrantee = fmt.Errorf("the todo can't be shared with its owner")
+)
+
+// permission is what an operation needs on a todo: viewing it, editing it or
+// managing it, which is left to its owner.
+type permission int
+
+const (
+	canView permission = iota
+	canEdit
+	canManage
+)
+
+// grantedTo tells whether the role allows the permission on a shared todo.
+func (p permission) grantedTo(role models.Role) bool {
+	switch role {
+	case models.RoleEditor:
+		return p <= canEdit
+	case models.RoleViewer:
+		return p == canView
+	default:
+		return false
+	}
+}
+
+func validateRole(role models.Role) error {
+	if role != models.RoleViewer && role != models.RoleEditor {
+		return ErrInvalidRole
+	}
+
+	return nil
+}
+
+// sharedBy sets the owner of the todo if it's shared with the email.
+func sharedBy(todo models.Todo, owner Owner, email Owner) models.Todo {
+	if owner != email {
+		todo.Owner = owner.String()
+	}
+
+	return todo
+}
+
+// sortShares orders the shares by owner, todo and grantee.
+func sortShares(shares []models.Share) {
+	sort.Slice(shares, func(i, j int) bool {
+		if shares[i].Owner != shares[j].Owner {
+			return shares[i].Owner < shares[j].Owner
+		}
+
+		if shares[i].TodoID != shares[j].TodoID {
+			return shares[i].TodoID < shares[j].TodoID
+		}
+
+		return shares[i].Grantee < shares[j].Grantee
+	})
+}
@@ -0,0 +1,79 @@
+package todo
+
+import (
+	"fmt"
+
+	"todo-app/todo/models"
+)
+
+type SortField string
+
+const (
+	SortByCreatedAt SortField = "created_at"
+	SortByDueDate   SortField = "due_date"
+	SortByStartDate SortField = "start_date"
+	SortByName      SortField = "name"
+)
+
+type SortOrder string
+
+const (
+	Ascending  SortOrder = "asc"
+	Descending SortOrder = "desc"
+)
+
+// sortTimeLayout has a fixed width, so times formatted in UTC with it sort
+// lexicographically in chronological order.
+const sortTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+var (
+	ErrInvalidSort  = fmt.Errorf("sort must be one of created_at, due_date, start_date or name")
+	ErrInvalidOrder = fmt.Errorf("order must be asc or desc")
+)
+
+var sortFields = []SortField{SortByCreatedAt, SortByDueDate, SortByStartDate, SortByName}
+
+func validateSort(field SortField, order SortOrder) error {
+	switch field {
+	case SortByCreatedAt, SortByDueDate, SortByStartDate, SortByName:
+	default:
+		return ErrInvalidSort
+	}
+
+	if order != Ascending && order != Descending {
+		return ErrInvalidOrder
+	}
+
+	return nil
+}
+
+// sortValue returns the field of the todo as a string that compares
+// byte-wise in the same order as the field.
+func sortValue(todo models.Todo, field SortField) string {
+	switch field {
+	case SortByDueDate:
+		return todo.DueDate.UTC().Format(sortTimeLayout)
+	case SortByStartDate:
+		return todo.StartDate.UTC().Format(sortTimeLayout)
+	case SortByName:
+		return todo.Name
+	default:
+		return todo.CreatedAt.UTC().Format(sortTimeLayout)
+	}
+}
+
+// sortKey orders todos by the field and then by id. It's the member stored
+// in the Redis sort indexes.
+func sortKey(todo models.Todo, field SortField) string {
+	return sortValue(todo, field) + "\x00" + todo.ID
+}
+
+// isAfter tells whether a todo with the given sort key comes after the one
+// with the other key in the given order.
+func isAfter(key, other string, order SortOrder) bool {
+	if order == Descending {
+		return key < other
+	}
+
+	return key > other
+}
@@ -0,0 +1,125 @@
+package todo
+
+import (
+	"database/sql"
+	"fmt"
+
+	"todo-app/internal/storage"
+	"todo-app/todo/models"
+)
+
+// todoColumns are the columns scanTodo reads and shareColumns the ones
+// scanShare reads, shared by the SQL repositories.
+const (
+	todoColumns  = "id, name, description, start_date, due_date, completed, completed_at, created_at, updated_at, deleted_at, archived_at, version"
+	shareColumns = "todo_id, owner, grantee, role"
+)
+
+func scanTodo(row storage.Scanner) (models.Todo, error) {
+	var todo models.Todo
+	var completedAt, deletedAt, archivedAt sql.NullTime
+	err := row.Scan(&todo.ID, &todo.Name, &todo.Description, &todo.StartDate, &todo.DueDate, &todo.Completed, &completedAt, &todo.CreatedAt, &todo.UpdatedAt, &deletedAt, &archivedAt, &todo.Version)
+	if err != nil {
+		return models.Todo{}, err
+	}
+
+	if completedAt.Valid {
+		todo.CompletedAt = &completedAt.Time
+	}
+
+	if deletedAt.Valid {
+		todo.DeletedAt = &deletedAt.Time
+	}
+
+	if archivedAt.Valid {
+		todo.ArchivedAt = &archivedAt.Time
+	}
+
+	return todo, nil
+}
+
+func scanShare(row storage.Scanner) (models.Share, error) {
+	var share models.Share
+	err := row.Scan(&share.TodoID, &share.Owner, &share.Grantee, &share.Role)
+	return share, err
+}
+
+// listQuery builds the query of a page of the todos of an owner that match
+// the filter, sorted by column and then by id. placeholder returns the
+// placeholder of the n-th argument in the dialect of the database.
+func listQuery(column string, email Owner, filter TodoFilter, page PageRequest, after *cursor, placeholder func(n int) string) (string, []any) {
+	var args []any
+	bind := func(value any) string {
+		args = append(args, value)
+		return placeholder(len(args))
+	}
+
+	query := "SELECT " + todoColumns + " FROM todos WHERE owner = " + bind(email)
+	if filter.Deleted {
+		query += " AND deleted_at IS NOT NULL"
+	} else {
+		query += " AND deleted_at IS NULL"
+	}
+
+	if !filter.IncludeArchived {
+		query += " AND archived_at IS NULL"
+	}
+
+	if filter.Completed != nil {
+		query += " AND completed = " + bind(*filter.Completed)
+	}
+
+	if filter.DueBefore != nil {
+		query += " AND due_date < " + bind(filter.DueBefore.UTC())
+	}
+
+	if filter.DueAfter != nil {
+		query += " AND due_date > " + bind(filter.DueAfter.UTC())
+	}
+
+	if filter.StartBefore != nil {
+		query += " AND start_date < " + bind(filter.StartBefore.UTC())
+	}
+
+	if filter.StartAfter != nil {
+		query += " AND start_date > " + bind(filter.StartAfter.UTC())
+	}
+
+	comparison, direction := ">", "ASC"
+	if page.order() == Descending {
+		comparison, direction = "<", "DESC"
+	}
+
+	if after != nil {
+		query += fmt.Sprintf(" AND (%s, id) %s (%s, %s)", column, comparison, bind(after.arg()), bind(after.ID))
+	}
+
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT %s", column, direction, direction, bind(page.limit()+1))
+	return query, args
+}
+
+// searchQuery builds the query of the todos of an owner, outside the trash
+// and the archive, with words starting with every term. The upper bound of
+// the prefix ranges is the greatest code point, so the index on the terms can
+// be used.
+func searchQuery(email Owner, terms []string, placeholder func(n int) string) (string, []any) {
+	var args []any
+	bind := func(value any) string {
+		args = append(args, value)
+		return placeholder(len(args))
+	}
+
+	query := "SELECT " + todoColumns + " FROM todos WHERE owner = " + bind(email) + " AND deleted_at IS NULL AND archived_at IS NULL AND id IN ("
+	for i, term := range terms {
+		if i > 0 {
+			query += " INTERSECT "
+		}
+
+		query += fmt.Sprintf(
+			"SELECT todo_id FROM todo_terms WHERE owner = %s AND term >= %s AND term < %s",
+			bind(email), bind(term), bind(term+"\U0010FFFF"),
+		)
+	}
+
+	return query + ")", args
+}
@@ -0,0 +1,477 @@
+package todo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"todo-app/internal/storage"
+	"todo-app/todo/models"
+)
+
+type SQLiteRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteRepository(db *sql.DB) *SQLiteRepository {
+	return &SQLiteRepository{
+		db: db,
+	}
+}
+
+func (s *SQLiteRepository) Create(ctx context.Context, email Owner, todo models.Todo) (models.Todo, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Todo{}, ErrWhileCreating
+	}
+	defer tx.Rollback()
+
+	todo.ID = uuid.NewString()
+	todo.Version = 1
+	if err = s.insert(ctx, tx, email, todo); err != nil {
+		return models.Todo{}, ErrWhileCreating
+	}
+
+	if err = tx.Commit(); err != nil {
+		return models.Todo{}, ErrWhileCreating
+	}
+
+	return todo, nil
+}
+
+func (s *SQLiteRepository) GetAll(ctx context.Context, email Owner, filter TodoFilter, page PageRequest) (Page, error) {
+	after, err := page.after()
+	if err != nil {
+		return Page{}, err
+	}
+
+	query, args := listQuery(string(page.sort()), email, filter, page, after, func(int) string {
+		return "?"
+	})
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return Page{}, ErrWhileRetrieving
+	}
+	defer rows.Close()
+
+	var todos []models.Todo
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return Page{}, ErrWhileRetrieving
+		}
+
+		todos = append(todos, todo)
+	}
+
+	if rows.Err() != nil {
+		return Page{}, ErrWhileRetrieving
+	}
+
+	return newPage(todos, page), nil
+}
+
+func (s *SQLiteRepository) GetByID(ctx context.Context, email Owner, id string) (models.Todo, error) {
+	if err := validateID(id); err != nil {
+		return models.Todo{}, err
+	}
+
+	row := s.db.QueryRowContext(ctx, "SELECT "+todoColumns+" FROM todos WHERE owner = ? AND id = ?", email, id)
+	todo, err := scanTodo(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Todo{}, ErrTodoNotFound
+	}
+
+	if err != nil {
+		return models.Todo{}, ErrWhileRetrieving
+	}
+
+	return todo, nil
+}
+
+func (s *SQLiteRepository) GetByIDs(ctx context.Context, email Owner, ids []string) ([]models.Todo, error) {
+	if err := validateIDs(ids); err != nil {
+		return nil, err
+	}
+
+	todos := make([]models.Todo, 0, len(ids))
+	if len(ids) == 0 {
+		return todos, nil
+	}
+
+	args := []any{email}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	placeholders := strings.Repeat(", ?", len(ids))[2:]
+	rows, err := s.db.QueryContext(ctx, "SELECT "+todoColumns+" FROM todos WHERE owner = ? AND id IN ("+placeholders+")", args...)
+	if err != nil {
+		return nil, ErrWhileRetrieving
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, ErrWhileRetrieving
+		}
+
+		todos = append(todos, todo)
+	}
+
+	if rows.Err() != nil {
+		return nil, ErrWhileRetrieving
+	}
+
+	return todos, nil
+}
+
+func (s *SQLiteRepository) Update(ctx context.Context, email Owner, id string, todo models.Todo) (models.Todo, error) {
+	if err := validateID(id); err != nil {
+		return models.Todo{}, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Todo{}, ErrWhileUpdating
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(
+		ctx,
+		"UPDATE todos SET name = ?, description = ?, start_date = ?, due_date = ?, completed = ?, completed_at = ?, updated_at = ?, deleted_at = ?, archived_at = ?, version = version + 1 WHERE owner = ? AND id = ? AND version = ? RETURNING created_at, version",
+		todo.Name, todo.Description, todo.StartDate.UTC(), todo.DueDate.UTC(), todo.Completed, storage.NullTime(todo.CompletedAt), todo.UpdatedAt.UTC(), storage.NullTime(todo.DeletedAt), storage.NullTime(todo.ArchivedAt), email, id, todo.Version,
+	).Scan(&todo.CreatedAt, &todo.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Todo{}, s.conflict(ctx, tx, email, id, ErrWhileUpdating)
+	}
+
+	if err != nil {
+		return models.Todo{}, ErrWhileUpdating
+	}
+
+	todo.ID = id
+	if _, err = tx.ExecContext(ctx, "DELETE FROM todo_terms WHERE todo_id = ?", id); err != nil {
+		return models.Todo{}, ErrWhileUpdating
+	}
+
+	if err = s.indexTerms(ctx, tx, email, todo); err != nil {
+		return models.Todo{}, ErrWhileUpdating
+	}
+
+	if err = tx.Commit(); err != nil {
+		return models.Todo{}, ErrWhileUpdating
+	}
+
+	return todo, nil
+}
+
+func (s *SQLiteRepository) Search(ctx context.Context, email Owner, terms []string, limit int) ([]models.Todo, error) {
+	query, args := searchQuery(email, terms, func(int) string {
+		return "?"
+	})
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, ErrWhileRetrieving
+	}
+	defer rows.Close()
+
+	var todos []models.Todo
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, ErrWhileRetrieving
+		}
+
+		todos = append(todos, todo)
+	}
+
+	if rows.Err() != nil {
+		return nil, ErrWhileRetrieving
+	}
+
+	return rank(todos, terms, limit), nil
+}
+
+// Purge relies on the foreign key to remove the search entries of the todos.
+func (s *SQLiteRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM todos WHERE deleted_at < ?", before.UTC())
+	if err != nil {
+		return 0, ErrWhilePurging
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, ErrWhilePurging
+	}
+
+	return int(affected), nil
+}
+
+func (s *SQLiteRepository) ArchiveCompleted(ctx context.Context, before time.Time, at time.Time) (int, error) {
+	result, err := s.db.ExecContext(
+		ctx,
+		"UPDATE todos SET archived_at = ?, updated_at = ?, version = version + 1 WHERE completed = ? AND completed_at < ? AND archived_at IS NULL AND deleted_at IS NULL",
+		at.UTC(), at.UTC(), true, before.UTC(),
+	)
+	if err != nil {
+		return 0, ErrWhileArchiving
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, ErrWhileArchiving
+	}
+
+	return int(affected), nil
+}
+
+// Batch reads the todos the writes refer to and persists the changes in a
+// single transaction.
+func (s *SQLiteRepository) Batch(ctx context.Context, email Owner, writes []BatchWrite) ([]BatchResult, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, ErrWhileWriting
+	}
+	defer tx.Rollback()
+
+	stored := make(map[string]models.Todo)
+	if ids := batchIDs(writes); len(ids) > 0 {
+		args := []any{email}
+		for _, id := range ids {
+			args = append(args, id)
+		}
+
+		placeholders := strings.Repeat(", ?", len(ids))[2:]
+		rows, err := tx.QueryContext(ctx, "SELECT "+todoColumns+" FROM todos WHERE owner = ? AND id IN ("+placeholders+")", args...)
+		if err != nil {
+			return nil, ErrWhileWriting
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			todo, err := scanTodo(rows)
+			if err != nil {
+				return nil, ErrWhileWriting
+			}
+
+			stored[todo.ID] = todo
+		}
+
+		if rows.Err() != nil {
+			return nil, ErrWhileWriting
+		}
+	}
+
+	results, changes := planBatch(writes, stored)
+	for _, change := range changes {
+		if err = s.apply(ctx, tx, email, change); err != nil {
+			return nil, ErrWhileWriting
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, ErrWhileWriting
+	}
+
+	return results, nil
+}
+
+// apply persists a change of a batch. The version of the todo is checked
+// again in case it changed since it was read.
+func (s *SQLiteRepository) apply(ctx context.Context, tx *sql.Tx, email Owner, change batchChange) error {
+	if change.before == nil {
+		return s.insert(ctx, tx, email, *change.after)
+	}
+
+	var result sql.Result
+	var err error
+	if change.after == nil {
+		result, err = tx.ExecContext(ctx, "DELETE FROM todos WHERE owner = ? AND id = ? AND version = ?", email, change.id, change.before.Version)
+	} else {
+		todo := change.after
+		result, err = tx.ExecContext(
+			ctx,
+			"UPDATE todos SET name = ?, description = ?, start_date = ?, due_date = ?, completed = ?, completed_at = ?, updated_at = ?, deleted_at = ?, archived_at = ?, version = ? WHERE owner = ? AND id = ? AND version = ?",
+			todo.Name, todo.Description, todo.StartDate.UTC(), todo.DueDate.UTC(), todo.Completed, storage.NullTime(todo.CompletedAt), todo.UpdatedAt.UTC(), storage.NullTime(todo.DeletedAt), storage.NullTime(todo.ArchivedAt), todo.Version, email, change.id, change.before.Version,
+		)
+	}
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrVersionMismatch
+	}
+
+	if change.after == nil {
+		return nil
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM todo_terms WHERE todo_id = ?", change.id); err != nil {
+		return err
+	}
+
+	return s.indexTerms(ctx, tx, email, *change.after)
+}
+
+func (s *SQLiteRepository) insert(ctx context.Context, tx *sql.Tx, email Owner, todo models.Todo) error {
+	_, err := tx.ExecContext(
+		ctx,
+		"INSERT INTO todos (id, owner, name, description, start_date, due_date, completed, completed_at, created_at, updated_at, deleted_at, archived_at, version) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		todo.ID, email, todo.Name, todo.Description, todo.StartDate.UTC(), todo.DueDate.UTC(), todo.Completed, storage.NullTime(todo.CompletedAt), todo.CreatedAt.UTC(), todo.UpdatedAt.UTC(), storage.NullTime(todo.DeletedAt), storage.NullTime(todo.ArchivedAt), todo.Version,
+	)
+	if err != nil {
+		return err
+	}
+
+	return s.indexTerms(ctx, tx, email, todo)
+}
+
+// conflict tells why a write matched no rows: the todo doesn't exist or it
+// has another version. fallback is returned if that can't be checked.
+func (s *SQLiteRepository) conflict(ctx context.Context, q interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}, email Owner, id string, fallback error) error {
+	var exists bool
+	err := q.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM todos WHERE owner = ? AND id = ?)", email, id).Scan(&exists)
+	if err != nil {
+		return fallback
+	}
+
+	if !exists {
+		return ErrTodoNotFound
+	}
+
+	return ErrVersionMismatch
+}
+
+// indexTerms adds the words of the todo to the search index. Its entries
+// are removed along with the todo by the foreign key.
+func (s *SQLiteRepository) indexTerms(ctx context.Context, tx *sql.Tx, email Owner, todo models.Todo) error {
+	for _, term := range searchTerms(todo) {
+		_, err := tx.ExecContext(ctx, "INSERT INTO todo_terms (todo_id, owner, term) VALUES (?, ?, ?)", todo.ID, email, term)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Share only inserts the share if the todo belongs to the email, changing the
+// role if it was already shared with the grantee. The shares are removed
+// along with the todo by the foreign key.
+func (s *SQLiteRepository) Share(ctx context.Context, email Owner, id string, grantee Owner, role models.Role) error {
+	if err := validateID(id); err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(
+		ctx,
+		"INSERT INTO todo_shares ("+shareColumns+") SELECT id, owner, ?, ? FROM todos WHERE owner = ? AND id = ? ON CONFLICT (todo_id, grantee) DO UPDATE SET role = excluded.role",
+		grantee, role, email, id,
+	)
+	if err != nil {
+		return ErrWhileSharing
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return ErrWhileSharing
+	}
+
+	if affected == 0 {
+		return ErrTodoNotFound
+	}
+
+	return nil
+}
+
+func (s *SQLiteRepository) Unshare(ctx context.Context, email Owner, id string, grantee Owner) error {
+	if err := validateID(id); err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM todo_shares WHERE owner = ? AND todo_id = ? AND grantee = ?", email, id, grantee)
+	if err != nil {
+		return ErrWhileSharing
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return ErrWhileSharing
+	}
+
+	if affected == 0 {
+		return ErrShareNotFound
+	}
+
+	return nil
+}
+
+func (s *SQLiteRepository) GetShares(ctx context.Context, email Owner, id string) ([]models.Share, error) {
+	if err := validateID(id); err != nil {
+		return nil, err
+	}
+
+	return s.queryShares(ctx, "SELECT "+shareColumns+" FROM todo_shares WHERE owner = ? AND todo_id = ? ORDER BY grantee", email, id)
+}
+
+func (s *SQLiteRepository) GetSharedWith(ctx context.Context, grantee Owner) ([]models.Share, error) {
+	return s.queryShares(ctx, "SELECT "+shareColumns+" FROM todo_shares WHERE grantee = ? ORDER BY owner, todo_id", grantee)
+}
+
+func (s *SQLiteRepository) GetShare(ctx context.Context, grantee Owner, id string) (models.Share, error) {
+	if err := validateID(id); err != nil {
+		return models.Share{}, err
+	}
+
+	row := s.db.QueryRowContext(ctx, "SELECT "+shareColumns+" FROM todo_shares WHERE grantee = ? AND todo_id = ?", grantee, id)
+	share, err := scanShare(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Share{}, ErrShareNotFound
+	}
+
+	if err != nil {
+		return models.Share{}, ErrWhileRetrieving
+	}
+
+	return share, nil
+}
+
+func (s *SQLiteRepository) queryShares(ctx context.Context, query string, args ...any) ([]models.Share, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, ErrWhileRetrieving
+	}
+	defer rows.Close()
+
+	shares := []models.Share{}
+	for rows.Next() {
+		share, err := scanShare(rows)
+		if err != nil {
+			return nil, ErrWhileRetrieving
+		}
+
+		shares = append(shares, share)
+	}
+
+	if rows.Err() != nil {
+		return nil, ErrWhileRetrieving
+	}
+
+	return shares, nil
+}
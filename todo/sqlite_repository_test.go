@@ -0,0 +1,120 @@
+package todo
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"todo-app/internal/storage"
+	"todo-app/internal/storage/storagetest"
+	"todo-app/todo/models"
+)
+
+func TestNewSQLiteRepository(t *testing.T) {
+	t.Run("should return a not nil instance", func(t *testing.T) {
+		repository := NewSQLiteRepository(&sql.DB{})
+		assert.NotNil(t, repository)
+		assert.IsType(t, &SQLiteRepository{}, repository)
+	})
+}
+
+func TestSQLiteRepository_GetAll(t *testing.T) {
+	t.Run("should return an error if the todos can't be queried", func(t *testing.T) {
+		ctx := context.TODO()
+		db := storagetest.SQLiteDB(t)
+		_, err := db.ExecContext(ctx, "DROP TABLE todos")
+		assert.NoError(t, err)
+
+		repository := NewSQLiteRepository(db)
+		response, err := repository.GetAll(ctx, "test@test.test", TodoFilter{}, PageRequest{})
+		assert.ErrorIs(t, err, ErrWhileRetrieving)
+		assert.Zero(t, response)
+	})
+}
+
+func TestSQLiteRepository_Search(t *testing.T) {
+	t.Run("should find the todos written before the search index", func(t *testing.T) {
+		ctx := context.TODO()
+		db := storagetest.SQLiteDB(t)
+		todo := models.Todo{
+			ID:          "279f4a4e-48dc-4569-83df-8b30ce488599",
+			Name:        "Buy MILK",
+			Description: "two bottles, at the store-24 (milk)",
+		}
+		_, err := db.ExecContext(
+			ctx,
+			"INSERT INTO todos (id, owner, name, description, start_date, due_date) VALUES (?, ?, ?, ?, ?, ?)",
+			todo.ID, "test@test.test", todo.Name, todo.Description, time.Now().UTC(), time.Now().UTC(),
+		)
+		assert.NoError(t, err)
+
+		_, err = db.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = 9")
+		assert.NoError(t, err)
+		assert.NoError(t, storage.MigrateSQLite(ctx, db))
+
+		rows, err := db.QueryContext(ctx, "SELECT term FROM todo_terms WHERE todo_id = ?", todo.ID)
+		assert.NoError(t, err)
+		defer rows.Close()
+
+		var terms []string
+		for rows.Next() {
+			var term string
+			assert.NoError(t, rows.Scan(&term))
+			terms = append(terms, term)
+		}
+		assert.ElementsMatch(t, searchTerms(todo), terms)
+
+		repository := NewSQLiteRepository(db)
+		response, err := repository.Search(ctx, "test@test.test", []string{"milk", "bot"}, 10)
+		assert.NoError(t, err)
+		assert.Len(t, response, 1)
+	})
+}
+
+func TestSQLiteRepository_NormalizeOwners(t *testing.T) {
+	t.Run("should find the todos and shares written before the owners were normalized", func(t *testing.T) {
+		ctx := context.TODO()
+		db := storagetest.SQLiteDB(t)
+		id := "279f4a4e-48dc-4569-83df-8b30ce488599"
+		_, err := db.ExecContext(
+			ctx,
+			"INSERT INTO todos (id, owner, name, description, start_date, due_date) VALUES (?, ?, ?, ?, ?, ?)",
+			id, " Bob@Example.COM", "Buy milk", "", time.Now().UTC(), time.Now().UTC(),
+		)
+		assert.NoError(t, err)
+
+		for _, statement := range []string{
+			"INSERT INTO todo_terms (todo_id, owner, term) VALUES (?, ' Bob@Example.COM', 'milk')",
+			"INSERT INTO todo_shares (todo_id, owner, grantee, role) VALUES (?, ' Bob@Example.COM', 'Alice@Example.com', 'viewer')",
+			"INSERT INTO todo_shares (todo_id, owner, grantee, role) VALUES (?, ' Bob@Example.COM', 'alice@example.com', 'editor')",
+			"INSERT INTO todo_shares (todo_id, owner, grantee, role) VALUES (?, ' Bob@Example.COM', 'Carol@Example.com', 'viewer')",
+			"INSERT INTO todo_shares (todo_id, owner, grantee, role) VALUES (?, ' Bob@Example.COM', 'CAROL@example.com', 'editor')",
+		} {
+			_, err = db.ExecContext(ctx, statement, id)
+			assert.NoError(t, err)
+		}
+
+		_, err = db.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = 12")
+		assert.NoError(t, err)
+		assert.NoError(t, storage.MigrateSQLite(ctx, db))
+
+		repository := NewSQLiteRepository(db)
+		page, err := repository.GetAll(ctx, "bob@example.com", TodoFilter{}, PageRequest{Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, page.Todos, 1)
+
+		found, err := repository.Search(ctx, "bob@example.com", []string{"milk"}, 10)
+		assert.NoError(t, err)
+		assert.Len(t, found, 1)
+
+		shares, err := repository.GetShares(ctx, "bob@example.com", id)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []models.Share{
+			{TodoID: id, Owner: "bob@example.com", Grantee: "alice@example.com", Role: models.RoleEditor},
+			{TodoID: id, Owner: "bob@example.com", Grantee: "carol@example.com", Role: models.RoleEditor},
+		}, shares)
+	})
+}
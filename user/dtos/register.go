@@ -0,0 +1,6 @@
+package dtos
+
+type Register struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
@@ -0,0 +1,6 @@
+package dtos
+
+type Verify struct {
+	Email string `json:"email"`
+	Token string `json:"token"`
+}
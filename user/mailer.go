@@ -0,0 +1,68 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+
+	"todo-app/config"
+)
+
+// Mailer sends the tokens that verify the emails of the accounts, so only
+// the owner of an email can log in with it.
+//
+//go:generate mockgen -destination mocks/mailer_mock.go -package mocks . Mailer
+type Mailer interface {
+	SendVerification(ctx context.Context, email string, token string) error
+}
+
+// NewMailer sends the tokens through the SMTP server of the config, or writes
+// them to the log if mail_log is set.
+func NewMailer(configs config.Config) Mailer {
+	if configs.MailLog {
+		return LogMailer{}
+	}
+
+	return NewSMTPMailer(configs)
+}
+
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPMailer authenticates with the username and password of the config
+// if they're set. net/smtp only sends them over TLS or to localhost.
+func NewSMTPMailer(configs config.Config) *SMTPMailer {
+	var auth smtp.Auth
+	if configs.SMTPUsername != "" {
+		host, _, _ := net.SplitHostPort(configs.SMTPAddr)
+		auth = smtp.PlainAuth("", configs.SMTPUsername, configs.SMTPPassword, host)
+	}
+
+	return &SMTPMailer{
+		addr: configs.SMTPAddr,
+		from: configs.SMTPFrom,
+		auth: auth,
+	}
+}
+
+func (s *SMTPMailer) SendVerification(_ context.Context, email string, token string) error {
+	message := fmt.Sprintf(
+		"From: %s\r\nTo: %s\r\nSubject: Verify your email\r\n\r\nYour verification token is %s\r\n",
+		s.from, email, token,
+	)
+	return smtp.SendMail(s.addr, s.auth, s.from, []string{email}, []byte(message))
+}
+
+// LogMailer is meant for development, only the ones who can read the log of
+// the server can verify the emails.
+type LogMailer struct{}
+
+func (LogMailer) SendVerification(_ context.Context, email string, token string) error {
+	log.Printf("verification token of %s: %s", email, token)
+	return nil
+}
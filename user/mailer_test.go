@@ -0,0 +1,21 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"todo-app/config"
+)
+
+func TestNewMailer(t *testing.T) {
+	t.Run("should send the tokens through the smtp server", func(t *testing.T) {
+		mailer := NewMailer(config.Config{SMTPAddr: "smtp.example.com:587", SMTPFrom: "todos@example.com"})
+		assert.IsType(t, &SMTPMailer{}, mailer)
+	})
+
+	t.Run("should only log the tokens if mail_log is set", func(t *testing.T) {
+		mailer := NewMailer(config.Config{MailLog: true})
+		assert.IsType(t, LogMailer{}, mailer)
+	})
+}
@@ -0,0 +1,67 @@
+package user
+
+import (
+	"context"
+	"sync"
+
+	"todo-app/user/models"
+)
+
+type MemoryRepository struct {
+	mu    sync.RWMutex
+	users map[string]models.User
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		users: make(map[string]models.User),
+	}
+}
+
+func (m *MemoryRepository) Create(ctx context.Context, user models.User) (models.User, error) {
+	if ctx.Err() != nil {
+		return models.User{}, ErrWhileCreating
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[user.Email]; ok {
+		return models.User{}, ErrEmailTaken
+	}
+
+	m.users[user.Email] = user
+	return user, nil
+}
+
+func (m *MemoryRepository) GetByEmail(ctx context.Context, email string) (models.User, error) {
+	if ctx.Err() != nil {
+		return models.User{}, ErrWhileRetrieving
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	user, ok := m.users[email]
+	if !ok {
+		return models.User{}, ErrUserNotFound
+	}
+
+	return user, nil
+}
+
+func (m *MemoryRepository) Update(ctx context.Context, user models.User) (models.User, error) {
+	if ctx.Err() != nil {
+		return models.User{}, ErrWhileUpdating
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[user.Email]; !ok {
+		return models.User{}, ErrUserNotFound
+	}
+
+	m.users[user.Email] = user
+	return user, nil
+}
@@ -0,0 +1,36 @@
+package user
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"todo-app/user/models"
+)
+
+func TestNewMemoryRepository(t *testing.T) {
+	t.Run("should return a not nil instance", func(t *testing.T) {
+		repository := NewMemoryRepository()
+		assert.NotNil(t, repository)
+		assert.IsType(t, &MemoryRepository{}, repository)
+	})
+}
+
+func TestMemoryRepository(t *testing.T) {
+	testRepository(t, func(*testing.T) Repository {
+		return NewMemoryRepository()
+	})
+
+	t.Run("should return an error if the context is canceled", func(t *testing.T) {
+		canceled, cancel := context.WithCancel(context.TODO())
+		cancel()
+
+		repository := NewMemoryRepository()
+		_, err := repository.Create(canceled, models.User{Email: "test@test.test"})
+		assert.ErrorIs(t, err, ErrWhileCreating)
+
+		_, err = repository.GetByEmail(canceled, "test@test.test")
+		assert.ErrorIs(t, err, ErrWhileRetrieving)
+	})
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// User is a local account, identified by its email. Only the bcrypt hash of
+// the password is stored, and the SHA-256 VerificationHash of the token sent
+// to the email until it's verified at VerifiedAt.
+type User struct {
+	Email            string     `json:"email"`
+	PasswordHash     string     `json:"-"`
+	VerificationHash string     `json:"-"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	VerifiedAt       *time.Time `json:"verified_at"`
+}
+
+// Session is a token issued to a user on login.
+type Session struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,69 @@
+package user
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"todo-app/internal/storage"
+	"todo-app/user/models"
+)
+
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{
+		pool: pool,
+	}
+}
+
+func (p *PostgresRepository) Create(ctx context.Context, user models.User) (models.User, error) {
+	tag, err := p.pool.Exec(
+		ctx,
+		"INSERT INTO users ("+userColumns+") VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (email) DO NOTHING",
+		user.Email, user.PasswordHash, user.VerificationHash, user.CreatedAt.UTC(), user.UpdatedAt.UTC(), storage.NullTime(user.VerifiedAt),
+	)
+	if err != nil {
+		return models.User{}, ErrWhileCreating
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.User{}, ErrEmailTaken
+	}
+
+	return user, nil
+}
+
+func (p *PostgresRepository) GetByEmail(ctx context.Context, email string) (models.User, error) {
+	user, err := scanUser(p.pool.QueryRow(ctx, "SELECT "+userColumns+" FROM users WHERE email = $1", email))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.User{}, ErrUserNotFound
+	}
+
+	if err != nil {
+		return models.User{}, ErrWhileRetrieving
+	}
+
+	return user, nil
+}
+
+func (p *PostgresRepository) Update(ctx context.Context, user models.User) (models.User, error) {
+	tag, err := p.pool.Exec(
+		ctx,
+		"UPDATE users SET password_hash = $1, verification_hash = $2, updated_at = $3, verified_at = $4 WHERE email = $5",
+		user.PasswordHash, user.VerificationHash, user.UpdatedAt.UTC(), storage.NullTime(user.VerifiedAt), user.Email,
+	)
+	if err != nil {
+		return models.User{}, ErrWhileUpdating
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.User{}, ErrUserNotFound
+	}
+
+	return user, nil
+}
@@ -0,0 +1,13 @@
+package user
+
+import (
+	"testing"
+
+	"todo-app/internal/storage/storagetest"
+)
+
+func TestPostgresRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository {
+		return NewPostgresRepository(storagetest.PostgresPool(t))
+	})
+}
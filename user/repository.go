@@ -0,0 +1,226 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+
+	"todo-app/internal/storage"
+	"todo-app/todo"
+	"todo-app/user/models"
+)
+
+// redisKey holds a user, its hash tag is the email like the keys of the
+// todos of the user. redisMigratedKey holds the version of the last
+// migration.
+const (
+	redisKey         = "user-{%s}"
+	redisMigratedKey = "user-migrated"
+)
+
+// redisMaxRetries bounds the attempts of a transaction aborted by concurrent
+// writes. redisMigrationVersion is increased whenever Migrate has new keys
+// to upgrade.
+const (
+	redisMaxRetries       = 5
+	redisMigrationVersion = 1
+)
+
+var (
+	ErrWhileCreating   = fmt.Errorf("error while creating")
+	ErrWhileRetrieving = fmt.Errorf("error while retreving")
+	ErrWhileUpdating   = fmt.Errorf("error while updating")
+	ErrWhileMigrating  = fmt.Errorf("error while migrating")
+	ErrUserNotFound    = fmt.Errorf("user not found")
+	ErrEmailTaken      = fmt.Errorf("the email is already registered")
+)
+
+// Repository stores the users by email. Create returns ErrEmailTaken if the
+// email is already registered, and Update ErrUserNotFound if it isn't.
+//
+//go:generate mockgen -destination mocks/repository_mock.go -package mocks . Repository
+type Repository interface {
+	Create(ctx context.Context, user models.User) (models.User, error)
+	GetByEmail(ctx context.Context, email string) (models.User, error)
+	Update(ctx context.Context, user models.User) (models.User, error)
+}
+
+// redisRecord is a user as it's stored, the hashes are left out of the JSON
+// of models.User.
+type redisRecord struct {
+	models.User
+	PasswordHash     string `json:"password_hash"`
+	VerificationHash string `json:"verification_hash"`
+}
+
+type RedisRepository struct {
+	client redis.UniversalClient
+}
+
+func NewRedisRepository(client redis.UniversalClient) *RedisRepository {
+	return &RedisRepository{
+		client: client,
+	}
+}
+
+func (r *RedisRepository) Create(ctx context.Context, user models.User) (models.User, error) {
+	userBytes, err := json.Marshal(redisRecord{User: user, PasswordHash: user.PasswordHash, VerificationHash: user.VerificationHash})
+	if err != nil {
+		return models.User{}, ErrWhileCreating
+	}
+
+	created, err := r.client.SetNX(ctx, fmt.Sprintf(redisKey, user.Email), userBytes, 0).Result()
+	if err != nil {
+		return models.User{}, ErrWhileCreating
+	}
+
+	if !created {
+		return models.User{}, ErrEmailTaken
+	}
+
+	return user, nil
+}
+
+func (r *RedisRepository) GetByEmail(ctx context.Context, email string) (models.User, error) {
+	result, err := r.client.Get(ctx, fmt.Sprintf(redisKey, email)).Result()
+	if errors.Is(err, redis.Nil) {
+		return models.User{}, ErrUserNotFound
+	}
+
+	if err != nil {
+		return models.User{}, ErrWhileRetrieving
+	}
+
+	var record redisRecord
+	if err = json.Unmarshal([]byte(result), &record); err != nil {
+		return models.User{}, ErrWhileRetrieving
+	}
+
+	user := record.User
+	user.PasswordHash = record.PasswordHash
+	user.VerificationHash = record.VerificationHash
+	return user, nil
+}
+
+func (r *RedisRepository) Update(ctx context.Context, user models.User) (models.User, error) {
+	userBytes, err := json.Marshal(redisRecord{User: user, PasswordHash: user.PasswordHash, VerificationHash: user.VerificationHash})
+	if err != nil {
+		return models.User{}, ErrWhileUpdating
+	}
+
+	updated, err := r.client.SetXX(ctx, fmt.Sprintf(redisKey, user.Email), userBytes, 0).Result()
+	if err != nil {
+		return models.User{}, ErrWhileUpdating
+	}
+
+	if !updated {
+		return models.User{}, ErrUserNotFound
+	}
+
+	return user, nil
+}
+
+// Migrate moves the users registered before their emails were normalized to
+// the normalized emails. An email registered with several spellings keeps
+// the normalized user, or else the first one moved. It only runs once, the
+// version is recorded when it's done.
+func (r *RedisRepository) Migrate(ctx context.Context) error {
+	version, err := r.client.Get(ctx, redisMigratedKey).Int()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("%w: %w", ErrWhileMigrating, err)
+	}
+
+	if version >= redisMigrationVersion {
+		return nil
+	}
+
+	keys, err := storage.ScanRedisKeys(ctx, r.client, fmt.Sprintf(redisKey, "*"), "string")
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrWhileMigrating, err)
+	}
+
+	for _, key := range keys {
+		email, ok := strings.CutPrefix(key, "user-{")
+		if email, ok = strings.CutSuffix(email, "}"); !ok {
+			continue
+		}
+
+		owner, err := todo.NewOwner(email)
+		if err != nil || owner.String() == email {
+			continue
+		}
+
+		if err = r.move(ctx, email, owner.String()); err != nil {
+			return fmt.Errorf("%w: moving %s: %w", ErrWhileMigrating, key, err)
+		}
+	}
+
+	if err = r.client.Set(ctx, redisMigratedKey, redisMigrationVersion, 0).Err(); err != nil {
+		return fmt.Errorf("%w: %w", ErrWhileMigrating, err)
+	}
+
+	return nil
+}
+
+// move copies a user to another email, unless it's taken, and then removes
+// it. The users that can't be decoded are left as they are. Both keys may be in different slots, so the user is copied before the
+// transaction that removes it, which is retried if it changed meanwhile.
+func (r *RedisRepository) move(ctx context.Context, from string, to string) error {
+	fromKey, toKey := fmt.Sprintf(redisKey, from), fmt.Sprintf(redisKey, to)
+	var copied []byte
+	fn := func(tx *redis.Tx) error {
+		value, err := tx.Get(ctx, fromKey).Result()
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		var record redisRecord
+		if json.Unmarshal([]byte(value), &record) != nil {
+			return nil
+		}
+
+		record.Email = to
+		userBytes, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+
+		if copied == nil {
+			created, err := r.client.SetNX(ctx, toKey, userBytes, 0).Result()
+			if err != nil {
+				return err
+			}
+
+			if created {
+				copied = userBytes
+			}
+		} else if !bytes.Equal(copied, userBytes) {
+			if err = r.client.Set(ctx, toKey, userBytes, 0).Err(); err != nil {
+				return err
+			}
+			copied = userBytes
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, fromKey)
+			return nil
+		})
+		return err
+	}
+
+	var err error = redis.TxFailedErr
+	for i := 0; i < redisMaxRetries && errors.Is(err, redis.TxFailedErr); i++ {
+		err = r.client.Watch(ctx, fn, fromKey)
+	}
+
+	return err
+}
@@ -0,0 +1,125 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"todo-app/internal/storage/storagetest"
+	"todo-app/user/models"
+)
+
+func TestNewRedisRepository(t *testing.T) {
+	t.Run("should return a not nil instance", func(t *testing.T) {
+		repository := NewRedisRepository(redis.NewClient(&redis.Options{}))
+		assert.NotNil(t, repository)
+		assert.IsType(t, &RedisRepository{}, repository)
+	})
+}
+
+func TestRedisRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository {
+		return NewRedisRepository(storagetest.RedisClient(t))
+	})
+}
+
+func TestRedisRepository_Migrate(t *testing.T) {
+	t.Run("should move the users registered before the emails were normalized", func(t *testing.T) {
+		ctx := context.TODO()
+		client := storagetest.RedisClient(t)
+		for email, passwordHash := range map[string]string{
+			"Alice@Example.com": "old hash",
+			"alice@example.com": "hash",
+			"Bob@Example.com":   "hash",
+		} {
+			user, err := json.Marshal(redisRecord{User: models.User{Email: email}, PasswordHash: passwordHash})
+			assert.NoError(t, err)
+			assert.NoError(t, client.Set(ctx, fmt.Sprintf(redisKey, email), user, 0).Err())
+		}
+
+		repository := NewRedisRepository(client)
+		assert.NoError(t, repository.Migrate(ctx))
+
+		for _, email := range []string{"alice@example.com", "bob@example.com"} {
+			user, err := repository.GetByEmail(ctx, email)
+			assert.NoError(t, err)
+			assert.Equal(t, email, user.Email)
+			assert.Equal(t, "hash", user.PasswordHash)
+		}
+
+		exists, err := client.Exists(ctx, fmt.Sprintf(redisKey, "Alice@Example.com"), fmt.Sprintf(redisKey, "Bob@Example.com")).Result()
+		assert.NoError(t, err)
+		assert.Zero(t, exists)
+	})
+}
+
+// testRepository checks the behaviour every Repository must share.
+func testRepository(t *testing.T, newRepository func(t *testing.T) Repository) {
+	ctx := context.TODO()
+	createdAt := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	newUser := func(email string) models.User {
+		return models.User{
+			Email:            email,
+			PasswordHash:     "hash",
+			VerificationHash: "verification hash",
+			CreatedAt:        createdAt,
+			UpdatedAt:        createdAt,
+		}
+	}
+
+	t.Run("should create and get a user by email with its password hash", func(t *testing.T) {
+		repository := newRepository(t)
+		_, err := repository.Create(ctx, newUser("test@test.test"))
+		assert.NoError(t, err)
+
+		stored, err := repository.GetByEmail(ctx, "test@test.test")
+		assert.NoError(t, err)
+		assert.Equal(t, "test@test.test", stored.Email)
+		assert.Equal(t, "hash", stored.PasswordHash)
+		assert.Equal(t, "verification hash", stored.VerificationHash)
+		assert.True(t, createdAt.Equal(stored.CreatedAt))
+		assert.Nil(t, stored.VerifiedAt)
+	})
+
+	t.Run("should not register an email twice", func(t *testing.T) {
+		repository := newRepository(t)
+		_, err := repository.Create(ctx, newUser("test@test.test"))
+		assert.NoError(t, err)
+
+		_, err = repository.Create(ctx, newUser("test@test.test"))
+		assert.ErrorIs(t, err, ErrEmailTaken)
+	})
+
+	t.Run("should return an error for an unknown email", func(t *testing.T) {
+		repository := newRepository(t)
+
+		_, err := repository.GetByEmail(ctx, "test@test.test")
+		assert.ErrorIs(t, err, ErrUserNotFound)
+
+		_, err = repository.Update(ctx, newUser("test@test.test"))
+		assert.ErrorIs(t, err, ErrUserNotFound)
+	})
+
+	t.Run("should update the user", func(t *testing.T) {
+		repository := newRepository(t)
+		user, _ := repository.Create(ctx, newUser("test@test.test"))
+		user.PasswordHash = "new hash"
+		user.VerificationHash = ""
+		user.VerifiedAt = &createdAt
+
+		_, err := repository.Update(ctx, user)
+		assert.NoError(t, err)
+
+		stored, _ := repository.GetByEmail(ctx, "test@test.test")
+		assert.Equal(t, "new hash", stored.PasswordHash)
+		assert.Empty(t, stored.VerificationHash)
+		if assert.NotNil(t, stored.VerifiedAt) {
+			assert.True(t, createdAt.Equal(*stored.VerifiedAt))
+		}
+	})
+}
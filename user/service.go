@@ -0,0 +1,265 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"todo-app/todo"
+	"todo-app/user/dtos"
+	"todo-app/user/models"
+)
+
+// minPasswordLength is the shortest password accepted, maxPasswordLength
+// the longest bcrypt can hash, in bytes. tokenSize is the number of random
+// bytes of a verification token.
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 72
+	tokenSize         = 32
+)
+
+var (
+	ErrInvalidEmail        = fmt.Errorf("the email is invalid")
+	ErrInvalidPassword     = fmt.Errorf("the password must have between %d and %d bytes", minPasswordLength, maxPasswordLength)
+	ErrInvalidCredentials  = fmt.Errorf("invalid email or password")
+	ErrInvalidVerification = fmt.Errorf("invalid email or verification token")
+	ErrEmailNotVerified    = fmt.Errorf("the email isn't verified yet")
+	ErrWhileSending        = fmt.Errorf("error while sending the verification token")
+)
+
+// TokenIssuer issues the tokens the users are authenticated with, with the
+// email as subject.
+//
+//go:generate mockgen -destination mocks/token_issuer_mock.go -package mocks . TokenIssuer
+type TokenIssuer interface {
+	Issue(subject string) (string, time.Time, error)
+}
+
+// Service manages the local accounts. Register sends a token to the email,
+// which Verify checks, and Login returns ErrEmailNotVerified until then.
+// Registering an email that isn't verified yet replaces its password and
+// token, so it can't be held by someone who doesn't own it. Login and
+// ChangePassword return ErrInvalidCredentials for an unknown email as well
+// as for a wrong password.
+//
+//go:generate mockgen -destination mocks/service_mock.go -package mocks . Service
+type Service interface {
+	Register(ctx context.Context, dto dtos.Register) (models.User, error)
+	Verify(ctx context.Context, dto dtos.Verify) error
+	Login(ctx context.Context, dto dtos.Login) (models.Session, error)
+	ChangePassword(ctx context.Context, email string, dto dtos.ChangePassword) error
+}
+
+type UsersService struct {
+	repository Repository
+	issuer     TokenIssuer
+	mailer     Mailer
+	clock      todo.Clock
+}
+
+func NewUsersService(repository Repository, issuer TokenIssuer, mailer Mailer, clock todo.Clock) *UsersService {
+	return &UsersService{
+		repository: repository,
+		issuer:     issuer,
+		mailer:     mailer,
+		clock:      clock,
+	}
+}
+
+func (u *UsersService) Register(ctx context.Context, dto dtos.Register) (models.User, error) {
+	email, err := validateEmail(dto.Email)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	hash, err := hashPassword(dto.Password)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	token := make([]byte, tokenSize)
+	if _, err = rand.Read(token); err != nil {
+		return models.User{}, ErrWhileCreating
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(token)
+	now := u.clock.Now()
+	user := models.User{
+		Email:            email,
+		PasswordHash:     hash,
+		VerificationHash: hashToken(encoded),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+
+	created, err := u.repository.Create(ctx, user)
+	if errors.Is(err, ErrEmailTaken) {
+		created, err = u.reregister(ctx, user)
+	}
+
+	if err != nil {
+		return models.User{}, err
+	}
+
+	if err = u.mailer.SendVerification(ctx, email, encoded); err != nil {
+		log.Printf("sending the verification token of %s: %v", email, err)
+		return models.User{}, ErrWhileSending
+	}
+
+	return created, nil
+}
+
+// reregister replaces the password and the token of the account of the user
+// unless it's verified.
+func (u *UsersService) reregister(ctx context.Context, user models.User) (models.User, error) {
+	stored, err := u.repository.GetByEmail(ctx, user.Email)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	if stored.VerifiedAt != nil {
+		return models.User{}, ErrEmailTaken
+	}
+
+	stored.PasswordHash = user.PasswordHash
+	stored.VerificationHash = user.VerificationHash
+	stored.UpdatedAt = user.UpdatedAt
+	return u.repository.Update(ctx, stored)
+}
+
+func (u *UsersService) Verify(ctx context.Context, dto dtos.Verify) error {
+	email, err := validateEmail(dto.Email)
+	if err != nil {
+		return ErrInvalidVerification
+	}
+
+	user, err := u.repository.GetByEmail(ctx, email)
+	if errors.Is(err, ErrUserNotFound) {
+		return ErrInvalidVerification
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if user.VerificationHash == "" || subtle.ConstantTimeCompare([]byte(hashToken(dto.Token)), []byte(user.VerificationHash)) != 1 {
+		return ErrInvalidVerification
+	}
+
+	now := u.clock.Now()
+	user.VerificationHash = ""
+	user.VerifiedAt = &now
+	user.UpdatedAt = now
+
+	_, err = u.repository.Update(ctx, user)
+	return err
+}
+
+func (u *UsersService) Login(ctx context.Context, dto dtos.Login) (models.Session, error) {
+	user, err := u.authenticate(ctx, dto.Email, dto.Password)
+	if err != nil {
+		return models.Session{}, err
+	}
+
+	if user.VerifiedAt == nil {
+		return models.Session{}, ErrEmailNotVerified
+	}
+
+	token, expiresAt, err := u.issuer.Issue(user.Email)
+	if err != nil {
+		return models.Session{}, err
+	}
+
+	return models.Session{Token: token, ExpiresAt: expiresAt}, nil
+}
+
+func (u *UsersService) ChangePassword(ctx context.Context, email string, dto dtos.ChangePassword) error {
+	user, err := u.authenticate(ctx, email, dto.CurrentPassword)
+	if err != nil {
+		return err
+	}
+
+	hash, err := hashPassword(dto.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	user.PasswordHash = hash
+	user.UpdatedAt = u.clock.Now()
+
+	_, err = u.repository.Update(ctx, user)
+	return err
+}
+
+// authenticate returns the user with the email if the password is its own.
+// The password is hashed for unknown emails too, so they take as long to be
+// rejected as wrong passwords.
+func (u *UsersService) authenticate(ctx context.Context, email string, password string) (models.User, error) {
+	email, err := validateEmail(email)
+	if err != nil {
+		return models.User{}, ErrInvalidCredentials
+	}
+
+	user, err := u.repository.GetByEmail(ctx, email)
+	if errors.Is(err, ErrUserNotFound) {
+		_ = bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+		return models.User{}, ErrInvalidCredentials
+	}
+
+	if err != nil {
+		return models.User{}, err
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return models.User{}, ErrInvalidCredentials
+	}
+
+	return user, nil
+}
+
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+	return hash
+})
+
+// validateEmail normalizes the email like the owners of the todos, so an
+// account always maps to the same todos.
+func validateEmail(email string) (string, error) {
+	owner, err := todo.NewOwner(email)
+	if err != nil {
+		return "", ErrInvalidEmail
+	}
+
+	return owner.String(), nil
+}
+
+func hashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return "", ErrInvalidPassword
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// hashToken is enough for the verification tokens, like the secrets of the
+// API keys they're random and long enough not to be guessed.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
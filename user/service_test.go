@@ -0,0 +1,346 @@
+package user_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+
+	todomocks "todo-app/todo/mocks"
+	"todo-app/user"
+	"todo-app/user/dtos"
+	"todo-app/user/mocks"
+	"todo-app/user/models"
+)
+
+var now = time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+func newClock(ctrl *gomock.Controller) *todomocks.MockClock {
+	clock := todomocks.NewMockClock(ctrl)
+	clock.EXPECT().Now().Return(now).AnyTimes()
+	return clock
+}
+
+func hash(t *testing.T, password string) string {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(hash)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestNewUsersService(t *testing.T) {
+	t.Run("should return a not nil instance", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := user.NewUsersService(mocks.NewMockRepository(ctrl), mocks.NewMockTokenIssuer(ctrl), mocks.NewMockMailer(ctrl), newClock(ctrl))
+		assert.NotNil(t, service)
+		assert.IsType(t, &user.UsersService{}, service)
+	})
+}
+
+func TestUsersService_Register(t *testing.T) {
+	ctx := context.TODO()
+	ctxMatcher := reflect.TypeOf((*context.Context)(nil)).Elem()
+
+	for name, test := range map[string]struct {
+		dto dtos.Register
+		err error
+	}{
+		"should reject an invalid email":     {dtos.Register{Email: "invalid", Password: "password"}, user.ErrInvalidEmail},
+		"should reject an email with a name": {dtos.Register{Email: "Test <test@test.test>", Password: "password"}, user.ErrInvalidEmail},
+		"should reject a short password":     {dtos.Register{Email: "test@test.test", Password: "short"}, user.ErrInvalidPassword},
+		"should reject a password too long":  {dtos.Register{Email: "test@test.test", Password: string(make([]byte, 73))}, user.ErrInvalidPassword},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			service := user.NewUsersService(mocks.NewMockRepository(ctrl), mocks.NewMockTokenIssuer(ctrl), mocks.NewMockMailer(ctrl), newClock(ctrl))
+			response, err := service.Register(ctx, test.dto)
+			assert.ErrorIs(t, err, test.err)
+			assert.Zero(t, response)
+		})
+	}
+
+	t.Run("should store the user with the hashes of the password and of the sent token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		var stored models.User
+		repository.
+			EXPECT().
+			Create(gomock.AssignableToTypeOf(ctxMatcher), gomock.AssignableToTypeOf(models.User{})).
+			DoAndReturn(func(_ context.Context, u models.User) (models.User, error) {
+				stored = u
+				return u, nil
+			})
+		mailer := mocks.NewMockMailer(ctrl)
+		var sent string
+		mailer.
+			EXPECT().
+			SendVerification(gomock.Any(), gomock.Eq("test@test.test"), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, token string) error {
+				sent = token
+				return nil
+			})
+
+		service := user.NewUsersService(repository, mocks.NewMockTokenIssuer(ctrl), mailer, newClock(ctrl))
+		response, err := service.Register(ctx, dtos.Register{Email: " Test@Test.test ", Password: "password"})
+		assert.NoError(t, err)
+		assert.Equal(t, "test@test.test", response.Email)
+		assert.Equal(t, now, stored.CreatedAt)
+		assert.Equal(t, now, stored.UpdatedAt)
+		assert.Nil(t, stored.VerifiedAt)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(stored.PasswordHash), []byte("password")))
+		assert.NotEmpty(t, sent)
+		assert.Equal(t, hashToken(sent), stored.VerificationHash)
+	})
+
+	t.Run("should replace the password and the token of an email not verified yet", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.EXPECT().Create(gomock.Any(), gomock.Any()).Return(models.User{}, user.ErrEmailTaken)
+		repository.
+			EXPECT().
+			GetByEmail(gomock.Any(), gomock.Eq("test@test.test")).
+			Return(models.User{Email: "test@test.test", PasswordHash: hash(t, "other password"), VerificationHash: hashToken("other"), CreatedAt: now.Add(-time.Hour)}, nil)
+		var stored models.User
+		repository.
+			EXPECT().
+			Update(gomock.Any(), gomock.AssignableToTypeOf(models.User{})).
+			DoAndReturn(func(_ context.Context, u models.User) (models.User, error) {
+				stored = u
+				return u, nil
+			})
+		mailer := mocks.NewMockMailer(ctrl)
+		var sent string
+		mailer.
+			EXPECT().
+			SendVerification(gomock.Any(), gomock.Eq("test@test.test"), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, token string) error {
+				sent = token
+				return nil
+			})
+
+		service := user.NewUsersService(repository, mocks.NewMockTokenIssuer(ctrl), mailer, newClock(ctrl))
+		_, err := service.Register(ctx, dtos.Register{Email: "test@test.test", Password: "password"})
+		assert.NoError(t, err)
+		assert.Equal(t, now.Add(-time.Hour), stored.CreatedAt)
+		assert.Equal(t, now, stored.UpdatedAt)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(stored.PasswordHash), []byte("password")))
+		assert.Equal(t, hashToken(sent), stored.VerificationHash)
+	})
+
+	t.Run("should reject a verified email", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.EXPECT().Create(gomock.Any(), gomock.Any()).Return(models.User{}, user.ErrEmailTaken)
+		repository.
+			EXPECT().
+			GetByEmail(gomock.Any(), gomock.Eq("test@test.test")).
+			Return(models.User{Email: "test@test.test", PasswordHash: hash(t, "other password"), VerifiedAt: &now}, nil)
+
+		service := user.NewUsersService(repository, mocks.NewMockTokenIssuer(ctrl), mocks.NewMockMailer(ctrl), newClock(ctrl))
+		response, err := service.Register(ctx, dtos.Register{Email: "test@test.test", Password: "password"})
+		assert.ErrorIs(t, err, user.ErrEmailTaken)
+		assert.Zero(t, response)
+	})
+
+	t.Run("should return the repository error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.EXPECT().Create(gomock.Any(), gomock.Any()).Return(models.User{}, user.ErrWhileCreating)
+
+		service := user.NewUsersService(repository, mocks.NewMockTokenIssuer(ctrl), mocks.NewMockMailer(ctrl), newClock(ctrl))
+		response, err := service.Register(ctx, dtos.Register{Email: "test@test.test", Password: "password"})
+		assert.ErrorIs(t, err, user.ErrWhileCreating)
+		assert.Zero(t, response)
+	})
+
+	t.Run("should return an error if the token can't be sent", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, u models.User) (models.User, error) {
+			return u, nil
+		})
+		mailer := mocks.NewMockMailer(ctrl)
+		mailer.EXPECT().SendVerification(gomock.Any(), gomock.Any(), gomock.Any()).Return(fmt.Errorf("connection refused"))
+
+		service := user.NewUsersService(repository, mocks.NewMockTokenIssuer(ctrl), mailer, newClock(ctrl))
+		response, err := service.Register(ctx, dtos.Register{Email: "test@test.test", Password: "password"})
+		assert.ErrorIs(t, err, user.ErrWhileSending)
+		assert.Zero(t, response)
+	})
+}
+
+func TestUsersService_Verify(t *testing.T) {
+	ctx := context.TODO()
+	email := "test@test.test"
+
+	t.Run("should mark the email as verified and forget the token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.EXPECT().GetByEmail(gomock.Any(), gomock.Eq(email)).Return(models.User{Email: email, VerificationHash: hashToken("token")}, nil)
+		var stored models.User
+		repository.
+			EXPECT().
+			Update(gomock.Any(), gomock.AssignableToTypeOf(models.User{})).
+			DoAndReturn(func(_ context.Context, u models.User) (models.User, error) {
+				stored = u
+				return u, nil
+			})
+
+		service := user.NewUsersService(repository, mocks.NewMockTokenIssuer(ctrl), mocks.NewMockMailer(ctrl), newClock(ctrl))
+		err := service.Verify(ctx, dtos.Verify{Email: " Test@Test.test ", Token: "token"})
+		assert.NoError(t, err)
+		assert.Empty(t, stored.VerificationHash)
+		assert.Equal(t, &now, stored.VerifiedAt)
+		assert.Equal(t, now, stored.UpdatedAt)
+	})
+
+	for name, stored := range map[string]models.User{
+		"should reject a wrong token":             {Email: email, VerificationHash: hashToken("other")},
+		"should reject an email already verified": {Email: email, VerifiedAt: &now},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repository := mocks.NewMockRepository(ctrl)
+			repository.EXPECT().GetByEmail(gomock.Any(), gomock.Eq(email)).Return(stored, nil)
+
+			service := user.NewUsersService(repository, mocks.NewMockTokenIssuer(ctrl), mocks.NewMockMailer(ctrl), newClock(ctrl))
+			err := service.Verify(ctx, dtos.Verify{Email: email, Token: "token"})
+			assert.ErrorIs(t, err, user.ErrInvalidVerification)
+		})
+	}
+
+	t.Run("should reject an unknown or invalid email the same way", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.EXPECT().GetByEmail(gomock.Any(), gomock.Eq(email)).Return(models.User{}, user.ErrUserNotFound)
+
+		service := user.NewUsersService(repository, mocks.NewMockTokenIssuer(ctrl), mocks.NewMockMailer(ctrl), newClock(ctrl))
+		err := service.Verify(ctx, dtos.Verify{Email: email, Token: "token"})
+		assert.ErrorIs(t, err, user.ErrInvalidVerification)
+
+		err = service.Verify(ctx, dtos.Verify{Email: "invalid", Token: "token"})
+		assert.ErrorIs(t, err, user.ErrInvalidVerification)
+	})
+}
+
+func TestUsersService_Login(t *testing.T) {
+	ctx := context.TODO()
+	email := "test@test.test"
+
+	t.Run("should issue a token for the email if the password is right", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.EXPECT().GetByEmail(gomock.Any(), gomock.Eq(email)).Return(models.User{Email: email, PasswordHash: hash(t, "password"), VerifiedAt: &now}, nil)
+		issuer := mocks.NewMockTokenIssuer(ctrl)
+		issuer.EXPECT().Issue(gomock.Eq(email)).Return("token", now.Add(time.Hour), nil)
+
+		service := user.NewUsersService(repository, issuer, mocks.NewMockMailer(ctrl), newClock(ctrl))
+		response, err := service.Login(ctx, dtos.Login{Email: email, Password: "password"})
+		assert.NoError(t, err)
+		assert.Equal(t, models.Session{Token: "token", ExpiresAt: now.Add(time.Hour)}, response)
+	})
+
+	t.Run("should reject an email not verified yet", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.EXPECT().GetByEmail(gomock.Any(), gomock.Eq(email)).Return(models.User{Email: email, PasswordHash: hash(t, "password")}, nil)
+
+		service := user.NewUsersService(repository, mocks.NewMockTokenIssuer(ctrl), mocks.NewMockMailer(ctrl), newClock(ctrl))
+		response, err := service.Login(ctx, dtos.Login{Email: email, Password: "password"})
+		assert.ErrorIs(t, err, user.ErrEmailNotVerified)
+		assert.Zero(t, response)
+	})
+
+	t.Run("should reject a wrong password", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.EXPECT().GetByEmail(gomock.Any(), gomock.Eq(email)).Return(models.User{Email: email, PasswordHash: hash(t, "password")}, nil)
+
+		service := user.NewUsersService(repository, mocks.NewMockTokenIssuer(ctrl), mocks.NewMockMailer(ctrl), newClock(ctrl))
+		response, err := service.Login(ctx, dtos.Login{Email: email, Password: "wrong password"})
+		assert.ErrorIs(t, err, user.ErrInvalidCredentials)
+		assert.Zero(t, response)
+	})
+
+	t.Run("should reject an unknown or invalid email the same way", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.EXPECT().GetByEmail(gomock.Any(), gomock.Eq(email)).Return(models.User{}, user.ErrUserNotFound)
+
+		service := user.NewUsersService(repository, mocks.NewMockTokenIssuer(ctrl), mocks.NewMockMailer(ctrl), newClock(ctrl))
+		_, err := service.Login(ctx, dtos.Login{Email: email, Password: "password"})
+		assert.ErrorIs(t, err, user.ErrInvalidCredentials)
+
+		_, err = service.Login(ctx, dtos.Login{Email: "invalid", Password: "password"})
+		assert.ErrorIs(t, err, user.ErrInvalidCredentials)
+	})
+
+	t.Run("should return the repository error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.EXPECT().GetByEmail(gomock.Any(), gomock.Eq(email)).Return(models.User{}, user.ErrWhileRetrieving)
+
+		service := user.NewUsersService(repository, mocks.NewMockTokenIssuer(ctrl), mocks.NewMockMailer(ctrl), newClock(ctrl))
+		_, err := service.Login(ctx, dtos.Login{Email: email, Password: "password"})
+		assert.ErrorIs(t, err, user.ErrWhileRetrieving)
+	})
+}
+
+func TestUsersService_ChangePassword(t *testing.T) {
+	ctx := context.TODO()
+	email := "test@test.test"
+
+	t.Run("should store the hash of the new password", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.EXPECT().GetByEmail(gomock.Any(), gomock.Eq(email)).Return(models.User{Email: email, PasswordHash: hash(t, "password")}, nil)
+		var stored models.User
+		repository.
+			EXPECT().
+			Update(gomock.Any(), gomock.AssignableToTypeOf(models.User{})).
+			DoAndReturn(func(_ context.Context, u models.User) (models.User, error) {
+				stored = u
+				return u, nil
+			})
+
+		service := user.NewUsersService(repository, mocks.NewMockTokenIssuer(ctrl), mocks.NewMockMailer(ctrl), newClock(ctrl))
+		err := service.ChangePassword(ctx, email, dtos.ChangePassword{CurrentPassword: "password", NewPassword: "new password"})
+		assert.NoError(t, err)
+		assert.Equal(t, now, stored.UpdatedAt)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(stored.PasswordHash), []byte("new password")))
+	})
+
+	t.Run("should require the current password", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.EXPECT().GetByEmail(gomock.Any(), gomock.Eq(email)).Return(models.User{Email: email, PasswordHash: hash(t, "password")}, nil)
+
+		service := user.NewUsersService(repository, mocks.NewMockTokenIssuer(ctrl), mocks.NewMockMailer(ctrl), newClock(ctrl))
+		err := service.ChangePassword(ctx, email, dtos.ChangePassword{CurrentPassword: "wrong password", NewPassword: "new password"})
+		assert.ErrorIs(t, err, user.ErrInvalidCredentials)
+	})
+
+	t.Run("should validate the new password", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockRepository(ctrl)
+		repository.EXPECT().GetByEmail(gomock.Any(), gomock.Eq(email)).Return(models.User{Email: email, PasswordHash: hash(t, "password")}, nil)
+
+		service := user.NewUsersService(repository, mocks.NewMockTokenIssuer(ctrl), mocks.NewMockMailer(ctrl), newClock(ctrl))
+		err := service.ChangePassword(ctx, email, dtos.ChangePassword{CurrentPassword: "password", NewPassword: "short"})
+		assert.ErrorIs(t, err, user.ErrInvalidPassword)
+	})
+}
@@ -0,0 +1,26 @@
+package user
+
+import (
+	"database/sql"
+
+	"todo-app/internal/storage"
+	"todo-app/user/models"
+)
+
+// userColumns are the columns scanUser reads, shared by the SQL repositories.
+const userColumns = "email, password_hash, verification_hash, created_at, updated_at, verified_at"
+
+func scanUser(row storage.Scanner) (models.User, error) {
+	var user models.User
+	var verifiedAt sql.NullTime
+	err := row.Scan(&user.Email, &user.PasswordHash, &user.VerificationHash, &user.CreatedAt, &user.UpdatedAt, &verifiedAt)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	if verifiedAt.Valid {
+		user.VerifiedAt = &verifiedAt.Time
+	}
+
+	return user, nil
+}
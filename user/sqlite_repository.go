@@ -0,0 +1,77 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"todo-app/internal/storage"
+	"todo-app/user/models"
+)
+
+type SQLiteRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteRepository(db *sql.DB) *SQLiteRepository {
+	return &SQLiteRepository{
+		db: db,
+	}
+}
+
+func (s *SQLiteRepository) Create(ctx context.Context, user models.User) (models.User, error) {
+	result, err := s.db.ExecContext(
+		ctx,
+		"INSERT INTO users ("+userColumns+") VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT (email) DO NOTHING",
+		user.Email, user.PasswordHash, user.VerificationHash, user.CreatedAt.UTC(), user.UpdatedAt.UTC(), storage.NullTime(user.VerifiedAt),
+	)
+	if err != nil {
+		return models.User{}, ErrWhileCreating
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return models.User{}, ErrWhileCreating
+	}
+
+	if affected == 0 {
+		return models.User{}, ErrEmailTaken
+	}
+
+	return user, nil
+}
+
+func (s *SQLiteRepository) GetByEmail(ctx context.Context, email string) (models.User, error) {
+	user, err := scanUser(s.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE email = ?", email))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.User{}, ErrUserNotFound
+	}
+
+	if err != nil {
+		return models.User{}, ErrWhileRetrieving
+	}
+
+	return user, nil
+}
+
+func (s *SQLiteRepository) Update(ctx context.Context, user models.User) (models.User, error) {
+	result, err := s.db.ExecContext(
+		ctx,
+		"UPDATE users SET password_hash = ?, verification_hash = ?, updated_at = ?, verified_at = ? WHERE email = ?",
+		user.PasswordHash, user.VerificationHash, user.UpdatedAt.UTC(), storage.NullTime(user.VerifiedAt), user.Email,
+	)
+	if err != nil {
+		return models.User{}, ErrWhileUpdating
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return models.User{}, ErrWhileUpdating
+	}
+
+	if affected == 0 {
+		return models.User{}, ErrUserNotFound
+	}
+
+	return user, nil
+}
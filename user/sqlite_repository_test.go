@@ -0,0 +1,78 @@
+package user
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"todo-app/internal/storage"
+	"todo-app/internal/storage/storagetest"
+	"todo-app/user/models"
+)
+
+func TestSQLiteRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository {
+		return NewSQLiteRepository(storagetest.SQLiteDB(t))
+	})
+}
+
+func TestSQLiteRepository_Errors(t *testing.T) {
+	t.Run("should return an error if the users can't be written or queried", func(t *testing.T) {
+		ctx := context.TODO()
+		db := storagetest.SQLiteDB(t)
+		_, err := db.ExecContext(ctx, "DROP TABLE users")
+		assert.NoError(t, err)
+
+		repository := NewSQLiteRepository(db)
+		user := models.User{Email: "test@test.test", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+		_, err = repository.Create(ctx, user)
+		assert.ErrorIs(t, err, ErrWhileCreating)
+
+		_, err = repository.GetByEmail(ctx, "test@test.test")
+		assert.ErrorIs(t, err, ErrWhileRetrieving)
+
+		_, err = repository.Update(ctx, user)
+		assert.ErrorIs(t, err, ErrWhileUpdating)
+	})
+}
+
+func TestSQLiteRepository_NormalizeOwners(t *testing.T) {
+	t.Run("should keep an account per email written before they were normalized", func(t *testing.T) {
+		ctx := context.TODO()
+		db := storagetest.SQLiteDB(t)
+		createdAt := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+		for i, email := range []string{
+			"Alice@Example.com", "alice@example.com",
+			"Carol@Example.com", " CAROL@example.com",
+			"Dave@Example.com",
+		} {
+			_, err := db.ExecContext(
+				ctx,
+				"INSERT INTO users ("+userColumns+") VALUES (?, ?, '', ?, ?, NULL)",
+				email, email, createdAt.Add(time.Duration(i)*time.Hour), createdAt,
+			)
+			assert.NoError(t, err)
+		}
+
+		_, err := db.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = 12")
+		assert.NoError(t, err)
+		assert.NoError(t, storage.MigrateSQLite(ctx, db))
+
+		repository := NewSQLiteRepository(db)
+		for email, passwordHash := range map[string]string{
+			"alice@example.com": "alice@example.com",
+			"carol@example.com": "Carol@Example.com",
+			"dave@example.com":  "Dave@Example.com",
+		} {
+			user, err := repository.GetByEmail(ctx, email)
+			assert.NoError(t, err)
+			assert.Equal(t, passwordHash, user.PasswordHash)
+		}
+
+		var count int
+		assert.NoError(t, db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&count))
+		assert.Equal(t, 3, count)
+	})
+}